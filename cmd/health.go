@@ -1,17 +1,24 @@
 package cmd
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/klog/v2"
@@ -19,6 +26,10 @@ import (
 	"k8s.io/kubectl/pkg/util/term"
 
 	"github.com/rhobs/kube-health/pkg/analyze"
+	// Extra analyzers for cert-manager.
+	_ "github.com/rhobs/kube-health/pkg/analyze/certmanager"
+	// Extra analyzers for Flux.
+	_ "github.com/rhobs/kube-health/pkg/analyze/flux"
 	// Extra analyzers for Red Hat related projects.
 	_ "github.com/rhobs/kube-health/pkg/analyze/redhat"
 	"github.com/rhobs/kube-health/pkg/eval"
@@ -60,21 +71,77 @@ func execName() string {
 }
 
 type flags struct {
-	waitForever  bool
-	waitProgress bool
-	waitOk       bool
-	showGroup    bool
-	showOk       bool
-	printVersion bool
-	width        int
-	configFlags  *genericclioptions.ConfigFlags
-	printFlags   *genericclioptions.PrintFlags
+	waitForever          bool
+	waitProgress         bool
+	waitOk               bool
+	waitDeleted          bool
+	waitCreate           time.Duration
+	showGroup            bool
+	showOk               bool
+	compact              bool
+	hideLogs             bool
+	only                 string
+	statuses             []string
+	maxDepth             int
+	focus                bool
+	sortBy               string
+	legend               bool
+	printVersion         bool
+	width                int
+	timestamps           string
+	score                bool
+	stream               bool
+	dependencyOrder      bool
+	fieldManagers        bool
+	orphans              bool
+	networkPolicies      bool
+	checkReferences      bool
+	checkWebhooks        bool
+	debugAnalyzers       bool
+	summaryFormat        string
+	kindConditions       string
+	rules                string
+	ignoreKinds          []string
+	includeKinds         []string
+	rbacPreflight        bool
+	drift                bool
+	driftExtra           bool
+	ignoreProgressingBit bool
+	allNamespaces        bool
+	includeSystem        bool
+	excludedNamespaces   []string
+	maxParallel          int
+	limit                int
+	scoreWeights         string
+	progressingTimeout   time.Duration
+	certRenewalWindow    time.Duration
+	csrApprovalTimeout   time.Duration
+	objectTimeout        time.Duration
+	restartsThreshold    int32
+	logFilter            string
+	junitFailOnWarning   bool
+	logFilterLines       int
+	logLines             int64
+	previousLogs         bool
+	ignoreEvicted        bool
+	configFlags          *genericclioptions.ConfigFlags
+	printFlags           *genericclioptions.PrintFlags
 }
 
 func newFlags() *flags {
 	return &flags{
 		configFlags: genericclioptions.NewConfigFlags(true),
 		printFlags:  genericclioptions.NewPrintFlags("").WithDefaultOutput("tree+color"),
+
+		progressingTimeout: analyze.DefaultProgressingTimeout,
+		certRenewalWindow:  analyze.DefaultCertificateRenewalWindow,
+		csrApprovalTimeout: analyze.DefaultCSRApprovalTimeout,
+		restartsThreshold:  analyze.DefaultRestartsThreshold,
+		logFilterLines:     analyze.DefaultLogFilterLines,
+		logLines:           eval.DefaultLogTailLines,
+		ignoreEvicted:      analyze.DefaultOptions().IgnoreReplacedEvictedPods,
+		maxParallel:        eval.DefaultMaxConcurrentLists,
+		limit:              defaultLimit,
 	}
 }
 
@@ -88,12 +155,105 @@ func (f *flags) addFlags(cmd *cobra.Command) {
 		"Wait until resources finish progressing (regarless of the result)")
 	fs.BoolVarP(&f.waitOk, "wait-ok", "O", false,
 		"Wait until the resources are ready (success only)")
+	fs.BoolVar(&f.waitDeleted, "wait-deleted", false,
+		"Wait until the resources no longer exist (e.g. the finalizers finished after deletion)")
+	fs.DurationVar(&f.waitCreate, "wait-create", 0,
+		"Wait up to the given time (1m if set without a value) for the named resources to exist, e.g. right after kubectl apply")
+	fs.Lookup("wait-create").NoOptDefVal = "1m"
 	fs.BoolVarP(&f.waitForever, "wait-forever", "F", false,
 		"Wait forever")
 	fs.BoolVarP(&f.showGroup, "show-group", "G", false,
 		"For each object, show API group it belongs to")
 	fs.BoolVarP(&f.showOk, "show-healthy", "H", false,
 		"Show details for all objects, including those with OK status")
+	fs.StringVar(&f.summaryFormat, "summary-format", "",
+		"Print a summary of the final results of the objects to stderr when finished, next to the exit code. One of: json")
+	fs.BoolVar(&f.ignoreProgressingBit, "ignore-progressing-bit", false,
+		"Don't add 8 to the exit code when some resources are still progressing")
+	fs.BoolVar(&f.junitFailOnWarning, "junit-fail-on-warning", false,
+		"With --output=junit, report the objects with warnings as failures as well")
+	fs.BoolVar(&f.compact, "compact", false,
+		"Show only the objects in the tree, without their conditions")
+	fs.BoolVar(&f.hideLogs, "hide-logs", false,
+		"Collapse the container logs to a single line with the number of the lines")
+	fs.StringVar(&f.only, "only", "",
+		"Show only the objects with at least the given result (or leading to such objects). One of: ok, warning, error")
+	fs.StringSliceVar(&f.statuses, "status", nil,
+		"Show only the objects with the given results (or leading to such objects), e.g. --status=error --status=unknown. Doesn't affect the exit code")
+	fs.IntVar(&f.maxDepth, "max-depth", 0,
+		"Maximum levels of sub-objects to show in the tree, the deeper ones are summarized. Set to 0 for unlimited")
+	fs.BoolVar(&f.focus, "focus", false,
+		"Show only the path to the worst sub-objects under each top-level object, hiding the siblings")
+	fs.StringVar(&f.sortBy, "sort-by", "name",
+		"How to order the objects in the tree. One of: name, severity (the failing objects first)")
+	fs.BoolVar(&f.legend, "legend", false,
+		"Print a key explaining the statuses and the tree symbols before the output")
+	fs.StringVar(&f.timestamps, "timestamps", "relative",
+		"How to show the condition times. One of: relative, local, utc")
+	fs.BoolVar(&f.rbacPreflight, "rbac-preflight", false,
+		"Check up front which resources can be listed and skip the rest, instead of failing on Forbidden errors")
+	fs.BoolVar(&f.drift, "drift", false,
+		"Treat the manifests passed via stdin as the expected state and report the drift of the live objects")
+	fs.BoolVar(&f.driftExtra, "drift-extra", false,
+		"With --drift, also report the live objects of the same kinds in the same namespaces missing in the manifests")
+	fs.BoolVar(&f.stream, "stream", false,
+		"Show the results as the objects get evaluated, without waiting for all of them")
+	fs.BoolVar(&f.fieldManagers, "field-managers", false,
+		"For the failing objects, show which field managers last changed their spec and status")
+	fs.BoolVar(&f.orphans, "orphans", false,
+		"Report the objects whose owners (from the ownerReferences) no longer exist with a warning")
+	fs.BoolVar(&f.networkPolicies, "network-policies", false,
+		"Warn about the Deployments whose pods are not selected by any NetworkPolicy in a namespace using NetworkPolicies")
+	fs.BoolVar(&f.checkReferences, "check-references", false,
+		"Report the ConfigMaps and Secrets referenced by the Pods and Deployments that don't exist")
+	fs.BoolVar(&f.checkWebhooks, "check-webhooks", false,
+		"Report the admission webhooks calling a missing Service or one without ready endpoints")
+	fs.StringVar(&f.kindConditions, "kind-conditions", "",
+		"YAML file describing the authoritative conditions of kinds without dedicated analyzers (type, polarity, severity)")
+	fs.StringVar(&f.rules, "rules", "",
+		"YAML file with rules evaluating the conditions matching a type or a pattern, optionally limited to some kinds")
+	fs.StringSliceVar(&f.ignoreKinds, "ignore-kind", nil,
+		"Kinds (in the KIND.GROUP format, e.g. Widget.example.com) not to show among the owned objects")
+	fs.StringSliceVar(&f.includeKinds, "include-kind", nil,
+		"Kinds (in the KIND.GROUP format, e.g. Secret) to show among the owned objects, even if ignored by default")
+	fs.BoolVar(&f.debugAnalyzers, "debug-analyzers", false,
+		"Print to stderr which analyzer was chosen for each evaluated object and which other ones support it")
+	fs.BoolVar(&f.dependencyOrder, "dependency-order", false,
+		"Evaluate the owners before the objects they own to avoid loading the same objects repeatedly")
+	fs.BoolVar(&f.score, "score", false,
+		"Print the overall health score: percentage of healthy objects, weighted by their result")
+	fs.StringVar(&f.scoreWeights, "score-weights", "",
+		"Weights of the results for the health score, e.g. 'warning=0.5,unknown=0'")
+	fs.DurationVar(&f.progressingTimeout, "progressing-timeout", f.progressingTimeout,
+		"Time since the last restart of a waiting container after which it's no longer considered progressing")
+	fs.DurationVar(&f.certRenewalWindow, "cert-renewal-window", f.certRenewalWindow,
+		"Time before the expiry of a cert-manager Certificate to report it with a warning")
+	fs.DurationVar(&f.csrApprovalTimeout, "csr-approval-timeout", f.csrApprovalTimeout,
+		"Age from which a CertificateSigningRequest waiting for the approval is reported with a warning")
+	fs.Int32Var(&f.restartsThreshold, "restarts-threshold", f.restartsThreshold,
+		"Number of recent restarts from which a running container is reported with a warning. Set to 0 to disable")
+	fs.StringVar(&f.logFilter, "log-filter", "",
+		"Show only the container log lines matching the regular expression, e.g. '(?i)error|fatal'")
+	fs.IntVar(&f.logFilterLines, "log-filter-lines", f.logFilterLines,
+		"Number of the last matching log lines to show with --log-filter. Set to 0 to show all of them")
+	fs.Int64Var(&f.logLines, "log-lines", f.logLines,
+		"Number of lines shown from the end of the logs of the failing containers")
+	fs.BoolVar(&f.previousLogs, "previous-logs", false,
+		"For the restarted containers, show the logs of the previous (terminated) instance instead of the current one")
+	fs.BoolVar(&f.ignoreEvicted, "ignore-replaced-evicted", f.ignoreEvicted,
+		"Hide the evicted pods of ReplicaSets and StatefulSets once the other pods cover the desired replicas")
+	fs.BoolVarP(&f.allNamespaces, "all-namespaces", "A", false,
+		"Look for the resources across all namespaces, except the system ones (see --include-system)")
+	fs.BoolVar(&f.includeSystem, "include-system", false,
+		"Include objects from system namespaces when looking across all namespaces")
+	fs.StringSliceVar(&f.excludedNamespaces, "excluded-namespaces", eval.DefaultExcludedNamespaces,
+		"Namespaces (shell patterns) considered as system ones, see --include-system")
+	fs.IntVar(&f.maxParallel, "max-parallel", f.maxParallel,
+		"Maximum number of API requests and object evaluations running in parallel. Set to 1 to disable the concurrency")
+	fs.IntVar(&f.limit, "limit", f.limit,
+		"Maximum number of the objects to evaluate, e.g. when looking across a large cluster. Set to 0 to evaluate all")
+	fs.DurationVar(&f.objectTimeout, "object-timeout", 0,
+		"Maximum time to spend on evaluating a single object, e.g. 30s. The objects hitting it are reported as Unknown. Set to 0 to disable")
 	fs.IntVar(&f.width, "width", -1,
 		"Width of the output. By default, it's inferred from the terminal width. Set to 0 to disable wrapping")
 	fs.BoolVar(&f.printVersion, "version", false, "Print version information")
@@ -106,7 +266,7 @@ func (f *flags) addPrintFlags(cmd *cobra.Command) {
 	f.printFlags.JSONYamlPrintFlags.AddFlags(cmd)
 	f.printFlags.TemplatePrinterFlags.AddFlags(cmd)
 
-	allowedFormats := append([]string{"tree", "tree+color"}, f.printFlags.AllowedFormats()...)
+	allowedFormats := append([]string{"tree", "tree+color", "junit", "oneline", "markdown", "dot"}, f.printFlags.AllowedFormats()...)
 
 	if f.printFlags.OutputFormat != nil {
 		cmd.Flags().StringVarP(f.printFlags.OutputFormat, "output", "o", *f.printFlags.OutputFormat,
@@ -119,31 +279,90 @@ func (f *flags) addPrintFlags(cmd *cobra.Command) {
 	}
 }
 
-func (f *flags) printOpts() print.PrintOptions {
+// printOpts builds the options of the tree-like printers. The colors are
+// used only when writing to a terminal (tty), so that the redirected output
+// stays readable.
+func (f *flags) printOpts(tty bool) (print.PrintOptions, error) {
+	timeFormat, err := print.ParseTimeFormat(f.timestamps)
+	if err != nil {
+		return print.PrintOptions{}, err
+	}
+
+	scoreWeights, err := status.ParseScoreWeights(f.scoreWeights)
+	if err != nil {
+		return print.PrintOptions{}, err
+	}
+
+	sortBy, err := print.ParseSortOrder(f.sortBy)
+	if err != nil {
+		return print.PrintOptions{}, err
+	}
+
+	var minResult status.Result
+	if f.only != "" {
+		if minResult, err = status.ParseResult(f.only); err != nil {
+			return print.PrintOptions{}, err
+		}
+	}
+
+	var results []status.Result
+	for _, s := range f.statuses {
+		result, err := status.ParseResult(s)
+		if err != nil {
+			return print.PrintOptions{}, err
+		}
+		results = append(results, result)
+	}
+
 	termWidth := f.width
 	if termWidth < 0 {
-		termsize := term.GetSize(os.Stdout.Fd())
-		if termsize != nil {
-			termWidth = int(termsize.Width)
-		}
+		termWidth = terminalWidth()
 	}
 	po := print.PrintOptions{
 		ShowGroup: f.showGroup,
 		ShowOk:    f.showOk,
 		Width:     termWidth,
+		Compact:   f.compact,
+		HideLogs:  f.hideLogs,
+		MinResult: minResult,
+		Results:   results,
+		MaxDepth:  f.maxDepth,
+		Focus:     f.focus,
+
+		TimeFormat: timeFormat,
+		SortBy:     sortBy,
+
+		ShowScore:    f.score,
+		ScoreWeights: scoreWeights,
 	}
 
-	if strings.Contains(*f.printFlags.OutputFormat, "+color") {
+	if tty && strings.Contains(*f.printFlags.OutputFormat, "+color") {
 		po.Color = true
 	}
 
-	return po
+	return po, nil
 }
 
-func (f *flags) toPrinter() (print.StatusPrinter, error) {
+func (f *flags) toPrinter(tty bool) (print.StatusPrinter, error) {
 	switch *f.printFlags.OutputFormat {
 	case "tree", "tree+color":
-		return print.NewTreePrinter(f.printOpts()), nil
+		opts, err := f.printOpts(tty)
+		if err != nil {
+			return nil, err
+		}
+		return print.NewTreePrinter(opts), nil
+	case "junit":
+		return print.JUnitPrinter{FailOnWarning: f.junitFailOnWarning}, nil
+	case "oneline":
+		return print.OneLinePrinter{}, nil
+	case "markdown":
+		opts, err := f.printOpts(tty)
+		if err != nil {
+			return nil, err
+		}
+		return print.MarkdownPrinter{PrintOpts: opts}, nil
+	case "dot":
+		return print.DotPrinter{}, nil
 	default:
 		kubectlPrinter, err := f.printFlags.ToPrinter()
 		if err != nil {
@@ -162,6 +381,12 @@ func runFunc(fl *flags) func(cmd *cobra.Command, args []string) error {
 		if len(posArgs) == 0 {
 			return fmt.Errorf("no resources specified")
 		}
+		if fl.summaryFormat != "" && fl.summaryFormat != "json" {
+			return fmt.Errorf("unsupported summary format %q, expected one of: json", fl.summaryFormat)
+		}
+		if fl.maxParallel < 1 {
+			return fmt.Errorf("invalid --max-parallel %d: must be at least 1", fl.maxParallel)
+		}
 
 		filenameOpts := &resource.FilenameOptions{}
 		if len(posArgs) == 1 && posArgs[0] == "-" {
@@ -176,67 +401,240 @@ func runFunc(fl *flags) func(cmd *cobra.Command, args []string) error {
 			return err
 		}
 
-		resources := make([]*resource.Info, 0)
-		objects := make([]*status.Object, 0)
-
-		resource.NewBuilder(fl.configFlags).
-			Unstructured().
-			NamespaceParam(namespace).DefaultNamespace().
-			ResourceTypeOrNameArgs(true, posArgs...).
-			FilenameParam(explicitNamespace, filenameOpts).
-			Flatten().
-			ContinueOnError().
-			Do().
-			Visit(func(info *resource.Info, err error) error {
-				if err != nil {
-					return err
-				}
-				resources = append(resources, info)
-
-				unst, ok := info.Object.(*unstructured.Unstructured)
-				if !ok {
-					return fmt.Errorf("expected *unstructured.Unstructured, got %T", info.Object)
-				}
+		resolve := func() ([]*status.Object, error) {
+			return fl.resolve(func() *resource.Builder { return resource.NewBuilder(fl.configFlags) },
+				namespace, explicitNamespace, posArgs, filenameOpts)
+		}
 
-				obj, err := status.NewObjectFromUnstructured(unst)
-				if err != nil {
-					return err
-				}
-				objects = append(objects, obj)
-				return nil
-			})
+		var objects []*status.Object
+		// The manifests from stdin can be read only once.
+		if fl.waitCreate > 0 && len(filenameOpts.Filenames) == 0 {
+			objects, err = resolveWithRetry(cmd.Context(), fl.waitCreate, waitCreateInterval, resolve)
+			if err != nil {
+				return err
+			}
+		} else {
+			objects, _ = resolve()
+		}
+		objects, msg := limitObjects(objects, fl.limit)
+		if msg != "" {
+			fmt.Fprintln(cmd.ErrOrStderr(), msg)
+		}
 
 		ctx := cmd.Context()
 		ctx, cancelFunc := context.WithCancel(ctx)
 		defer cancelFunc()
 
-		ldr, err := eval.NewRealLoader(f)
+		ldr, err := eval.NewRealLoader(ctx, f, fl.loaderOptions(namespace))
 		if err != nil {
 			return fmt.Errorf("Can't create loader: %w", err)
 		}
+		if skipped := ldr.Skipped(); len(skipped) > 0 {
+			fmt.Fprintf(cmd.ErrOrStderr(),
+				"Warning: skipping %d resources not permitted to list (use -v=2 to see them)\n", len(skipped))
+		}
+
+		if fl.drift {
+			if len(filenameOpts.Filenames) == 0 {
+				return fmt.Errorf("--drift requires the expected manifests to be passed via stdin")
+			}
+			report, err := eval.ComputeDrift(ctx, ldr, objects, eval.DriftOptions{Extra: fl.driftExtra})
+			if err != nil {
+				return fmt.Errorf("Can't compute drift: %w", err)
+			}
+			print.PrintDrift(report, cmd.OutOrStdout())
+			fmt.Fprintln(cmd.OutOrStdout())
+		}
 
-		evaluator := eval.NewEvaluator(analyze.DefaultAnalyzers(), ldr)
+		opts := analyze.DefaultOptions()
+		opts.ProgressingTimeout = fl.progressingTimeout
+		opts.RestartsThreshold = fl.restartsThreshold
+		opts.LogTailLines = fl.logLines
+		opts.PreviousLogs = fl.previousLogs
+		opts.IgnoreReplacedEvictedPods = fl.ignoreEvicted
+		opts.NetworkPolicyCoverage = fl.networkPolicies
+		opts.ReferenceChecks = fl.checkReferences
+		opts.WebhookChecks = fl.checkWebhooks
+		opts.CertificateRenewalWindow = fl.certRenewalWindow
+		opts.CSRApprovalTimeout = fl.csrApprovalTimeout
+		if fl.logFilter != "" {
+			re, err := regexp.Compile(fl.logFilter)
+			if err != nil {
+				return fmt.Errorf("Invalid --log-filter: %w", err)
+			}
+			opts.LogFilter = re
+			opts.LogFilterLines = fl.logFilterLines
+		}
+		plugins := []analyze.Plugin{analyze.WithOptions(opts)}
+		if fl.kindConditions != "" {
+			table, err := analyze.ReadKindConditions(fl.kindConditions)
+			if err != nil {
+				return fmt.Errorf("Can't read kind conditions: %w", err)
+			}
+			plugins = append(plugins, func(r *analyze.AnalyzerRegister) {
+				r.RegisterKindConditions(table)
+			})
+		}
+		if fl.rules != "" {
+			rules, err := analyze.ReadConditionRules(fl.rules)
+			if err != nil {
+				return fmt.Errorf("Can't read rules: %w", err)
+			}
+			plugins = append(plugins, analyze.AddConditionRules(rules...))
+		}
+		if len(fl.ignoreKinds) > 0 {
+			plugins = append(plugins, analyze.IgnoreKinds(parseGroupKinds(fl.ignoreKinds)...))
+		}
+		if len(fl.includeKinds) > 0 {
+			plugins = append(plugins, analyze.IncludeKinds(parseGroupKinds(fl.includeKinds)...))
+		}
+		evaluator := eval.NewEvaluator(analyze.AnalyzersWithPlugins(plugins...), ldr).
+			WithParallelism(fl.maxParallel).
+			WithObjectTimeout(fl.objectTimeout)
+		if fl.debugAnalyzers {
+			evaluator.WithAnalyzerDebug(cmd.ErrOrStderr())
+		}
 
 		poller := eval.NewStatusPoller(2*time.Second, evaluator, objects)
+		if fl.stream {
+			poller.WithStreaming()
+		}
+		if fl.dependencyOrder {
+			poller.WithDependencyOrder()
+		}
+		if fl.fieldManagers {
+			poller.WithFieldManagers()
+		}
+		if fl.orphans {
+			poller.WithOrphans()
+		}
 		updatesChan := poller.Start(ctx)
 
-		printer, err := fl.toPrinter()
+		outStreams := print.OutStreams{
+			Std: cmd.OutOrStdout(),
+			Err: cmd.ErrOrStderr(),
+		}
+		// When the output is redirected (e.g. to a file or in CI), the updates
+		// are printed plainly: without colors and without resetting the screen.
+		tty := term.IsTerminal(outStreams.Std)
+
+		printer, err := fl.toPrinter(tty)
 		if err != nil {
 			return fmt.Errorf("Can't create printer: %w", err)
 		}
 
-		outStreams := print.OutStreams{
-			Std: cmd.OutOrStdout(),
-			Err: cmd.ErrOrStderr(),
+		if tp, ok := printer.(*print.TreePrinter); ok && fl.legend {
+			print.PrintLegend(outStreams.Std, tp.PrintOpts)
 		}
 
+		var final []status.ObjectStatus
 		wf := waitFunction(fl, cancelFunc)
-		print.NewPeriodicPrinter(printer, outStreams, updatesChan, wf).Start()
+		pp := print.NewPeriodicPrinter(printer, outStreams, updatesChan, func(statuses []status.ObjectStatus) {
+			final = statuses
+			wf(statuses)
+		})
+		if tty {
+			pp.WithTerminal(terminalWidth)
+			if fl.width < 0 {
+				pp.WithAutoWidth()
+			}
+		}
+		pp.Start()
 
+		if fl.summaryFormat == "json" {
+			// Failing to print the summary must not change the exit code.
+			if err := print.PrintExitSummaryJSON(outStreams.Err, final, exitCode); err != nil {
+				klog.ErrorS(err, "Failed to print the summary")
+			}
+		}
 		return nil
 	}
 }
 
+// terminalWidth returns the current width of the terminal, or 0 if stdout
+// is not a terminal.
+func terminalWidth() int {
+	if termsize := term.GetSize(os.Stdout.Fd()); termsize != nil {
+		return int(termsize.Width)
+	}
+	return 0
+}
+
+// defaultLimit is the default of --limit: far beyond the usual selections,
+// but keeping an accidental evaluation of a whole large cluster manageable.
+const defaultLimit = 1000
+
+// limitObjects returns the first limit objects, ordered by their namespace,
+// kind and name, together with a message telling how many were skipped.
+// Non-positive limit keeps all the objects.
+func limitObjects(objects []*status.Object, limit int) ([]*status.Object, string) {
+	if limit <= 0 || len(objects) <= limit {
+		return objects, ""
+	}
+
+	sorted := slices.Clone(objects)
+	slices.SortStableFunc(sorted, func(a, b *status.Object) int {
+		return cmp.Or(
+			strings.Compare(a.GetNamespace(), b.GetNamespace()),
+			strings.Compare(a.GroupVersionKind().Group, b.GroupVersionKind().Group),
+			strings.Compare(a.Kind, b.Kind),
+			strings.Compare(a.GetName(), b.GetName()))
+	})
+	msg := fmt.Sprintf("Showing the first %d of %d objects; use --limit 0 for all", limit, len(objects))
+	return sorted[:limit], msg
+}
+
+// parseGroupKinds parses the kinds in the KIND.GROUP format, such as
+// Deployment.apps, or just KIND for the core group.
+func parseGroupKinds(kinds []string) []schema.GroupKind {
+	ret := make([]schema.GroupKind, 0, len(kinds))
+	for _, k := range kinds {
+		ret = append(ret, schema.ParseGroupKind(k))
+	}
+	return ret
+}
+
+// waitCreateInterval is the delay between the attempts to resolve
+// the resources with --wait-create.
+const waitCreateInterval = time.Second
+
+// resolveWithRetry calls resolve until it stops failing due to some
+// of the resources not being found, for up to the timeout. Other errors
+// are returned immediately.
+func resolveWithRetry(ctx context.Context, timeout, interval time.Duration,
+	resolve func() ([]*status.Object, error)) ([]*status.Object, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		objects, err := resolve()
+		if err == nil {
+			return objects, nil
+		}
+		if !isNotFound(err) {
+			return nil, err
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return nil, fmt.Errorf("resources not created within %s: %w", timeout, err)
+		}
+		klog.V(1).InfoS("Waiting for the resources to be created", "error", err)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// isNotFound returns true if the error (or any of the aggregated errors)
+// is NotFound.
+func isNotFound(err error) bool {
+	var agg utilerrors.Aggregate
+	if errors.As(err, &agg) {
+		return slices.ContainsFunc(utilerrors.Flatten(agg).Errors(), apierrors.IsNotFound)
+	}
+	return apierrors.IsNotFound(err)
+}
+
 // waitFunction decides when to stop waiting for the resources.
 // It's used by the PeriodicPrinter to decide when to stop the loop.
 func waitFunction(fl *flags, cancelFunc func()) func([]status.ObjectStatus) {
@@ -246,10 +644,22 @@ func waitFunction(fl *flags, cancelFunc func()) func([]status.ObjectStatus) {
 		}
 
 		finish := func() {
-			setExitCode(statuses)
+			setExitCode(statuses, fl.ignoreProgressingBit)
 			cancelFunc()
 		}
 
+		if fl.waitDeleted {
+			for _, os := range statuses {
+				if !apierrors.IsNotFound(os.ObjStatus.Err) {
+					return
+				}
+			}
+			// All the objects are gone: that's the success.
+			exitCode = 0
+			cancelFunc()
+			return
+		}
+
 		progressing := false
 		if fl.waitProgress || fl.waitOk {
 			for _, os := range statuses {
@@ -288,32 +698,149 @@ func waitFunction(fl *flags, cancelFunc func()) func([]status.ObjectStatus) {
 	}
 }
 
-func setExitCode(statuses []status.ObjectStatus) {
-	exitCode = 0
+func setExitCode(statuses []status.ObjectStatus, ignoreProgressing bool) {
+	exitCode = statusesExitCode(statuses, ignoreProgressing)
+}
+
+// statusesExitCode calculates the exit code based on the worst result.
+// Unless ignoreProgressing is set, the 4th bit indicates some of the objects
+// are still progressing.
+func statusesExitCode(statuses []status.ObjectStatus, ignoreProgressing bool) int {
+	code := 0
 	for _, os := range statuses {
 		res := os.Status().Result
 
 		switch res {
 		case status.Unknown:
-			exitCode = 3
-			break
+			code = 3
 		case status.Error:
-			exitCode = max(exitCode, 2)
+			code = max(code, 2)
 		case status.Warning:
-			exitCode = max(exitCode, 1)
+			code = max(code, 1)
 		case status.Ok:
-			exitCode = max(exitCode, 0)
+			code = max(code, 0)
 		}
 	}
 
+	if ignoreProgressing {
+		return code
+	}
+
 	for _, os := range statuses {
 		if os.Status().Progressing {
 			// Add 4th bit to the exit code if still progressing
-			exitCode = exitCode | 0b1000
+			code = code | 0b1000
 		}
 	}
+	return code
 }
 
 func PrintVersion() {
 	fmt.Printf("kube-health %s (commit %s, built at %s)\n", Version, Commit, Date)
 }
+
+// resolve resolves the objects requested by the arguments. When looking across
+// all namespaces, the objects from the system namespaces are left out. They
+// often hold most of the objects of the cluster, hence the exclusion is sent
+// to the server as a field selector, falling back to the filtering on
+// the client side for the kinds not supporting it (the cluster-scoped ones).
+func (f *flags) resolve(newBuilder func() *resource.Builder, namespace string, explicitNamespace bool,
+	posArgs []string, filenameOpts *resource.FilenameOptions) ([]*status.Object, error) {
+	var excluded []string
+	if f.allNamespaces {
+		excluded = f.systemNamespaces()
+	}
+
+	var fieldSelector string
+	// The builder doesn't allow selectors together with the names.
+	if !hasNames(posArgs) {
+		fieldSelector = eval.ExcludedNamespacesFieldSelector(excluded)
+	}
+	objects, err := resolveObjects(newBuilder(), namespace, explicitNamespace, f.allNamespaces,
+		fieldSelector, posArgs, filenameOpts)
+	if fieldSelector != "" && isBadRequest(err) {
+		klog.V(2).InfoS("Field selector not supported, filtering namespaces on the client side", "error", err)
+		objects, err = resolveObjects(newBuilder(), namespace, explicitNamespace, f.allNamespaces,
+			"", posArgs, filenameOpts)
+	}
+
+	return slices.DeleteFunc(objects, func(obj *status.Object) bool {
+		return eval.IsExcludedNamespace(obj.GetNamespace(), excluded)
+	}), err
+}
+
+// resolveObjects visits the objects matching the arguments.
+func resolveObjects(b *resource.Builder, namespace string, explicitNamespace, allNamespaces bool,
+	fieldSelector string, posArgs []string, filenameOpts *resource.FilenameOptions) ([]*status.Object, error) {
+	objects := make([]*status.Object, 0)
+	err := b.Unstructured().
+		NamespaceParam(namespace).DefaultNamespace().AllNamespaces(allNamespaces).
+		FieldSelectorParam(fieldSelector).
+		ResourceTypeOrNameArgs(true, posArgs...).
+		FilenameParam(explicitNamespace, filenameOpts).
+		Flatten().
+		ContinueOnError().
+		Do().
+		Visit(func(info *resource.Info, err error) error {
+			if err != nil {
+				return err
+			}
+
+			unst, ok := info.Object.(*unstructured.Unstructured)
+			if !ok {
+				return fmt.Errorf("expected *unstructured.Unstructured, got %T", info.Object)
+			}
+
+			// Make sure no List itself gets evaluated, whatever Flatten() left.
+			objs, err := status.NewObjectsFromUnstructured(unst)
+			if err != nil {
+				return err
+			}
+			objects = append(objects, objs...)
+			return nil
+		})
+	return objects, err
+}
+
+// hasNames returns true if the arguments name the objects, such as
+// `deploy/foo` or `deploy foo`, as opposed to selecting them by the type only.
+func hasNames(posArgs []string) bool {
+	return len(posArgs) > 1 || slices.ContainsFunc(posArgs, func(arg string) bool {
+		return strings.Contains(arg, "/")
+	})
+}
+
+// isBadRequest returns true if the error (or any of the aggregated errors)
+// is BadRequest.
+func isBadRequest(err error) bool {
+	var agg utilerrors.Aggregate
+	if errors.As(err, &agg) {
+		return slices.ContainsFunc(utilerrors.Flatten(agg).Errors(), apierrors.IsBadRequest)
+	}
+	return apierrors.IsBadRequest(err)
+}
+
+// loaderOptions returns the options of the loader for the resources
+// resolved in the namespace.
+func (f *flags) loaderOptions(namespace string) eval.LoaderOptions {
+	preflightNamespace := namespace
+	if f.allNamespaces {
+		// The access is checked across all namespaces, like the lists.
+		preflightNamespace = ""
+	}
+	return eval.LoaderOptions{
+		RBACPreflight:      f.rbacPreflight,
+		PreflightNamespace: preflightNamespace,
+		ExcludedNamespaces: f.systemNamespaces(),
+		MaxConcurrentLists: f.maxParallel,
+	}
+}
+
+// systemNamespaces returns the namespaces to exclude when looking across
+// all namespaces.
+func (f *flags) systemNamespaces() []string {
+	if f.includeSystem {
+		return nil
+	}
+	return f.excludedNamespaces
+}
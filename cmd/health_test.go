@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/cli-runtime/pkg/resource"
+	restfake "k8s.io/client-go/rest/fake"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+
+	"github.com/rhobs/kube-health/pkg/analyze"
+	"github.com/rhobs/kube-health/pkg/eval"
+	"github.com/rhobs/kube-health/pkg/print"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestStatusesExitCode(t *testing.T) {
+	withStatus := func(result status.Result, progressing bool) status.ObjectStatus {
+		return status.ObjectStatus{ObjStatus: status.Status{Result: result, Progressing: progressing}}
+	}
+
+	progressingOk := []status.ObjectStatus{withStatus(status.Ok, false), withStatus(status.Ok, true)}
+	assert.Equal(t, 8, statusesExitCode(progressingOk, false))
+	assert.Equal(t, 0, statusesExitCode(progressingOk, true))
+
+	progressingError := []status.ObjectStatus{withStatus(status.Warning, true), withStatus(status.Error, false)}
+	assert.Equal(t, 10, statusesExitCode(progressingError, false))
+	assert.Equal(t, 2, statusesExitCode(progressingError, true))
+
+	assert.Equal(t, 3, statusesExitCode([]status.ObjectStatus{withStatus(status.Unknown, false),
+		withStatus(status.Error, false)}, false))
+}
+
+// deletingLoader reports the objects as deleted after a few loads.
+type deletingLoader struct {
+	*eval.FakeLoader
+	gets       atomic.Int32
+	deleteFrom int32
+}
+
+func (l *deletingLoader) Get(ctx context.Context, obj *status.Object) (*status.Object, error) {
+	if l.gets.Add(1) >= l.deleteFrom {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, obj.GetName())
+	}
+	return l.FakeLoader.Get(ctx, obj)
+}
+
+func TestWaitDeleted(t *testing.T) {
+	u := unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind("ConfigMap")
+	u.SetNamespace("default")
+	u.SetName("cm1")
+	u.SetUID("uid-1")
+
+	loader := &deletingLoader{FakeLoader: eval.NewFakeLoader(), deleteFrom: 3}
+	objs, err := loader.Register(u)
+	require.NoError(t, err)
+
+	evaluator := eval.NewEvaluator(analyze.DefaultAnalyzers(), loader)
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	exitCode = -1
+	updates := eval.NewStatusPoller(10*time.Millisecond, evaluator, objs).Start(ctx)
+	wf := waitFunction(&flags{waitDeleted: true}, cancel)
+	print.NewPeriodicPrinter(print.NewTreePrinter(print.PrintOptions{}),
+		print.OutStreams{Std: io.Discard, Err: io.Discard}, updates, wf).Start()
+
+	require.NotErrorIs(t, ctx.Err(), context.DeadlineExceeded, "the objects weren't reported as deleted")
+	assert.Equal(t, 0, exitCode)
+	assert.Equal(t, int32(3), loader.gets.Load())
+}
+
+func TestPrintOptsColorOnlyInTerminal(t *testing.T) {
+	fl := newFlags()
+	*fl.printFlags.OutputFormat = "tree+color"
+
+	po, err := fl.printOpts(true)
+	require.NoError(t, err)
+	assert.True(t, po.Color)
+
+	po, err = fl.printOpts(false)
+	require.NoError(t, err)
+	assert.False(t, po.Color, "no colors when the output is redirected")
+}
+
+func TestResolveWithRetry(t *testing.T) {
+	notFound := apierrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "deployments"}, "foo")
+	dp := &status.Object{}
+
+	// The resource appears after a short delay.
+	created := time.Now().Add(30 * time.Millisecond)
+	attempts := 0
+	resolve := func() ([]*status.Object, error) {
+		attempts++
+		if time.Now().Before(created) {
+			return nil, utilerrors.NewAggregate([]error{notFound})
+		}
+		return []*status.Object{dp}, nil
+	}
+	objects, err := resolveWithRetry(t.Context(), time.Second, 10*time.Millisecond, resolve)
+	require.NoError(t, err)
+	assert.Equal(t, []*status.Object{dp}, objects)
+	assert.Greater(t, attempts, 1)
+
+	// Not created in time.
+	_, err = resolveWithRetry(t.Context(), 30*time.Millisecond, 10*time.Millisecond, func() ([]*status.Object, error) {
+		return nil, notFound
+	})
+	assert.ErrorContains(t, err, `resources not created within 30ms: deployments.apps "foo" not found`)
+
+	// Other errors are not retried.
+	attempts = 0
+	_, err = resolveWithRetry(t.Context(), time.Second, 10*time.Millisecond, func() ([]*status.Object, error) {
+		attempts++
+		return nil, errors.New("the server doesn't have a resource type \"foos\"")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestLimitObjects(t *testing.T) {
+	var objs []*status.Object
+	for _, nsName := range [][2]string{{"b", "cm1"}, {"a", "cm2"}, {"b", "cm0"}, {"a", "cm1"}} {
+		u := unstructured.Unstructured{}
+		u.SetAPIVersion("v1")
+		u.SetKind("ConfigMap")
+		u.SetNamespace(nsName[0])
+		u.SetName(nsName[1])
+		obj, err := status.NewObjectFromUnstructured(&u)
+		require.NoError(t, err)
+		objs = append(objs, obj)
+	}
+
+	limited, msg := limitObjects(objs, 3)
+	assert.Equal(t, "Showing the first 3 of 4 objects; use --limit 0 for all", msg)
+	var names []string
+	for _, obj := range limited {
+		names = append(names, obj.GetNamespace()+"/"+obj.GetName())
+	}
+	assert.Equal(t, []string{"a/cm1", "a/cm2", "b/cm0"}, names)
+	// The input is left untouched.
+	assert.Equal(t, "cm1", objs[0].GetName())
+
+	for _, limit := range []int{0, 4, 5} {
+		limited, msg = limitObjects(objs, limit)
+		assert.Equal(t, objs, limited)
+		assert.Empty(t, msg)
+	}
+}
+
+func TestResolveAllNamespaces(t *testing.T) {
+	tf := cmdtesting.NewTestFactory().WithNamespace("default")
+	defer tf.Cleanup()
+
+	var fieldSelectors []string
+	var rejectSelector bool
+	tf.UnstructuredClient = &restfake.RESTClient{
+		NegotiatedSerializer: resource.UnstructuredPlusDefaultContentConfig().NegotiatedSerializer,
+		Client: restfake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			selector := req.URL.Query().Get("fieldSelector")
+			fieldSelectors = append(fieldSelectors, selector)
+			header := http.Header{"Content-Type": []string{"application/json"}}
+			if rejectSelector && selector != "" {
+				return &http.Response{StatusCode: http.StatusBadRequest, Header: header,
+					Body: io.NopCloser(strings.NewReader(`{"kind":"Status","apiVersion":"v1",` +
+						`"status":"Failure","reason":"BadRequest","code":400}`))}, nil
+			}
+			return &http.Response{StatusCode: http.StatusOK, Header: header,
+				Body: io.NopCloser(strings.NewReader(`{"kind":"PodList","apiVersion":"v1","items":[` +
+					`{"kind":"Pod","apiVersion":"v1","metadata":{"name":"app","namespace":"default"}},` +
+					`{"kind":"Pod","apiVersion":"v1","metadata":{"name":"dns","namespace":"kube-system"}},` +
+					`{"kind":"Pod","apiVersion":"v1","metadata":{"name":"console","namespace":"openshift-console"}}]}`))}, nil
+		}),
+	}
+
+	resolve := func(fl *flags, posArgs ...string) []string {
+		objects, err := fl.resolve(tf.NewBuilder, "default", false, posArgs, &resource.FilenameOptions{})
+		require.NoError(t, err)
+		var names []string
+		for _, obj := range objects {
+			names = append(names, obj.GetNamespace()+"/"+obj.GetName())
+		}
+		return names
+	}
+	allNsFlags := func(includeSystem bool) *flags {
+		return &flags{allNamespaces: true, includeSystem: includeSystem,
+			excludedNamespaces: eval.DefaultExcludedNamespaces}
+	}
+
+	// The system namespaces are excluded by default, on the server side where possible.
+	assert.Equal(t, []string{"default/app"}, resolve(allNsFlags(false), "pods"))
+	assert.Equal(t, []string{eval.ExcludedNamespacesFieldSelector(eval.DefaultExcludedNamespaces)}, fieldSelectors)
+
+	fieldSelectors = nil
+	assert.Equal(t, []string{"default/app", "kube-system/dns", "openshift-console/console"},
+		resolve(allNsFlags(true), "pods"))
+	assert.Equal(t, []string{""}, fieldSelectors)
+
+	// An explicit namespace composes with the exclusion: nothing is excluded.
+	fieldSelectors = nil
+	assert.Len(t, resolve(&flags{excludedNamespaces: eval.DefaultExcludedNamespaces}, "pods"), 3)
+	assert.Equal(t, []string{""}, fieldSelectors)
+
+	// The kinds not supporting the selector are filtered on the client side.
+	fieldSelectors = nil
+	rejectSelector = true
+	assert.Equal(t, []string{"default/app"}, resolve(allNsFlags(false), "pods"))
+	assert.Equal(t, []string{eval.ExcludedNamespacesFieldSelector(eval.DefaultExcludedNamespaces), ""},
+		fieldSelectors)
+}
+
+func TestLoaderOptionsPreflightNamespace(t *testing.T) {
+	fl := &flags{rbacPreflight: true}
+	assert.Equal(t, "default", fl.loaderOptions("default").PreflightNamespace)
+
+	// The lists go across all namespaces: so does the access check.
+	fl.allNamespaces = true
+	assert.Empty(t, fl.loaderOptions("default").PreflightNamespace)
+}
+
+func TestRunMaxParallel(t *testing.T) {
+	for _, maxParallel := range []int{0, -1} {
+		fl := newFlags()
+		fl.maxParallel = maxParallel
+		err := runFunc(fl)(&cobra.Command{}, []string{"pods"})
+		assert.ErrorContains(t, err, "invalid --max-parallel")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -16,6 +17,10 @@ import (
 	healthcmd "github.com/rhobs/kube-health/cmd"
 	"github.com/rhobs/kube-health/pkg/analyze"
 
+	// Extra analyzers for cert-manager.
+	_ "github.com/rhobs/kube-health/pkg/analyze/certmanager"
+	// Extra analyzers for Flux.
+	_ "github.com/rhobs/kube-health/pkg/analyze/flux"
 	// Extra analyzers for Red Hat related projects.
 	_ "github.com/rhobs/kube-health/pkg/analyze/redhat"
 	"github.com/rhobs/kube-health/pkg/eval"
@@ -45,21 +50,38 @@ func Execute() {
 }
 
 type flags struct {
-	printVersion bool
-	configFile   string
-	configFlags  *genericclioptions.ConfigFlags
-	printOnly    bool
-	interval     int // refresh interval in seconds
-	host         string
-	port         int
+	printVersion       bool
+	configFile         string
+	configFlags        *genericclioptions.ConfigFlags
+	printOnly          bool
+	interval           int // refresh interval in seconds
+	pollJitter         float64
+	host               string
+	port               int
+	tlsCertFile        string
+	tlsKeyFile         string
+	bearerTokenFile    string
+	consistentList     bool
+	watch              bool
+	snapshotFile       string
+	snapshotFormat     string
+	includeSystem      bool
+	excludedNamespaces []string
+	maxParallel        int
+	scoreWeights       string
+	conditionMetrics   []string
+	objectTimeout      time.Duration
 }
 
 func newFlags() *flags {
 	return &flags{
-		configFlags: genericclioptions.NewConfigFlags(true),
-		interval:    30,
-		host:        "localhost",
-		port:        8080,
+		configFlags:    genericclioptions.NewConfigFlags(true),
+		interval:       30,
+		pollJitter:     eval.DefaultPollJitter,
+		host:           "localhost",
+		port:           8080,
+		snapshotFormat: "json",
+		maxParallel:    eval.DefaultMaxConcurrentLists,
 	}
 }
 
@@ -71,8 +93,36 @@ func (f *flags) addFlags(fl *pflag.FlagSet) {
 	fs.BoolVar(&f.printVersion, "version", false, "Print version information")
 	fs.BoolVar(&f.printOnly, "print-only", false, "Print the status and exit")
 	fs.IntVarP(&f.interval, "interval", "i", f.interval, "Refresh interval in seconds")
+	fs.Float64Var(&f.pollJitter, "poll-jitter", f.pollJitter,
+		"Maximum fraction of the interval to randomly lengthen or shorten it by, spreading the API load of multiple monitors. Set to 0 to disable")
 	fs.StringVar(&f.host, "host", f.host, "Host to bind the server to")
 	fs.IntVar(&f.port, "port", f.port, "Port to bind the server to")
+	fs.StringVar(&f.tlsCertFile, "tls-cert-file", f.tlsCertFile,
+		"Path to the TLS certificate to serve the metrics over HTTPS. Requires --tls-key-file")
+	fs.StringVar(&f.tlsKeyFile, "tls-key-file", f.tlsKeyFile,
+		"Path to the TLS private key matching --tls-cert-file")
+	fs.StringVar(&f.bearerTokenFile, "bearer-token-file", f.bearerTokenFile,
+		"Path to a file with the bearer token required to access the metrics")
+	fs.BoolVar(&f.consistentList, "consistent-list", false,
+		"Load all objects of a single poll at the same resourceVersion, where supported by the API")
+	fs.BoolVar(&f.watch, "watch", false,
+		"Keep the objects up-to-date via watches instead of listing them on every poll. Can't be used with --consistent-list")
+	fs.StringVar(&f.snapshotFile, "snapshot-file", f.snapshotFile,
+		"Path to a file to write the latest status into after each poll")
+	fs.StringVar(&f.snapshotFormat, "snapshot-format", f.snapshotFormat,
+		"Format of the snapshot file. One of: (json, yaml)")
+	fs.BoolVar(&f.includeSystem, "include-system", false,
+		"Include objects from system namespaces when looking across all namespaces")
+	fs.StringSliceVar(&f.excludedNamespaces, "excluded-namespaces", eval.DefaultExcludedNamespaces,
+		"Namespaces (shell patterns) considered as system ones, see --include-system")
+	fs.IntVar(&f.maxParallel, "max-parallel", f.maxParallel,
+		"Maximum number of API requests and object evaluations running in parallel. Set to 1 to disable the concurrency")
+	fs.DurationVar(&f.objectTimeout, "object-timeout", f.objectTimeout,
+		"Maximum time to spend on evaluating a single object, e.g. 30s. The objects hitting it are reported as Unknown. Set to 0 to disable")
+	fs.StringVar(&f.scoreWeights, "score-weights", f.scoreWeights,
+		"Weights of the results for the kube:health:score metric, e.g. 'warning=0.5,unknown=0'")
+	fs.StringSliceVar(&f.conditionMetrics, "condition-metrics", nil,
+		"Condition types (e.g. Progressing,Available) to expose via the kube:health:condition metric, one series per condition of each object")
 	fl.AddFlagSet(fs)
 }
 
@@ -82,6 +132,12 @@ func runFunc(fl *flags) func(cmd *cobra.Command, args []string) error {
 			healthcmd.PrintVersion()
 			return nil
 		}
+		if fl.watch && fl.consistentList {
+			return fmt.Errorf("--consistent-list can't be used with --watch")
+		}
+		if fl.maxParallel < 1 {
+			return fmt.Errorf("invalid --max-parallel %d: must be at least 1", fl.maxParallel)
+		}
 
 		f := util.NewFactory(fl.configFlags)
 
@@ -95,22 +151,51 @@ func runFunc(fl *flags) func(cmd *cobra.Command, args []string) error {
 			return err
 		}
 
+		scoreWeights, err := status.ParseScoreWeights(fl.scoreWeights)
+		if err != nil {
+			return err
+		}
+
+		var snapshotWriter *monitor.SnapshotWriter
+		if fl.snapshotFile != "" {
+			snapshotWriter, err = monitor.NewSnapshotWriter(fl.snapshotFile, fl.snapshotFormat)
+			if err != nil {
+				return err
+			}
+		}
+
 		ctx := cmd.Context()
 		ctx, cancelFunc := context.WithCancel(ctx)
 		defer cancelFunc()
 
-		ldr, err := eval.NewRealLoader(f)
+		loaderOpts := eval.LoaderOptions{
+			ConsistentList:     fl.consistentList,
+			ExcludedNamespaces: fl.systemNamespaces(),
+			MaxConcurrentLists: fl.maxParallel,
+		}
+		var ldr eval.Loader
+		if fl.watch {
+			ldr, err = eval.NewInformerLoader(ctx, f, loaderOpts, 0)
+		} else {
+			ldr, err = eval.NewRealLoader(ctx, f, loaderOpts)
+		}
 		if err != nil {
 			return fmt.Errorf("Can't create loader: %w", err)
 		}
 
-		evaluator := eval.NewEvaluator(analyze.DefaultAnalyzers(), ldr)
+		evaluator := eval.NewEvaluator(analyze.DefaultAnalyzers(), ldr).
+			WithParallelism(fl.maxParallel).
+			WithObjectTimeout(fl.objectTimeout)
 
 		interval := time.Duration(fl.interval) * time.Second
-		poller := monitor.NewMonitorPoller(interval, evaluator, cfg)
+		poller := monitor.NewMonitorPoller(interval, evaluator, cfg).WithJitter(fl.pollJitter)
 
 		klog.V(1).InfoS("starting poller", "interval", interval)
 		updatesChan := poller.Start(ctx)
+		if snapshotWriter != nil {
+			// Every poll gets written, before the updates are altered.
+			updatesChan = snapshotWriter.Filter(updatesChan)
+		}
 		dedupUpdatesChan := dedupFilter(updatesChan)
 
 		if fl.printOnly {
@@ -118,7 +203,7 @@ func runFunc(fl *flags) func(cmd *cobra.Command, args []string) error {
 			return nil
 		}
 
-		err = fl.startServer(ctx, dedupUpdatesChan)
+		err = fl.startServer(ctx, dedupUpdatesChan, scoreWeights)
 		if err != nil {
 			return err
 		}
@@ -143,11 +228,31 @@ func (fl *flags) printStatus(ctx context.Context, cmd *cobra.Command, updatesCha
 	print.NewPeriodicPrinter(printer, outStreams, updatesChan, wf).Start()
 }
 
-func (fl *flags) startServer(ctx context.Context, updatesChan <-chan monitor.TargetsStatusUpdate) error {
+func (fl *flags) startServer(ctx context.Context, updatesChan <-chan monitor.TargetsStatusUpdate,
+	scoreWeights status.ScoreWeights) error {
 	klog.V(1).InfoS("starting metrics server", "host", fl.host, "port", fl.port)
 	server := monitor.NewSimpleServer(fl.host, fl.port)
+	if fl.tlsCertFile != "" || fl.tlsKeyFile != "" {
+		if fl.tlsCertFile == "" || fl.tlsKeyFile == "" {
+			return fmt.Errorf("Both --tls-cert-file and --tls-key-file need to be set")
+		}
+		server.WithTLS(fl.tlsCertFile, fl.tlsKeyFile)
+	}
+	if fl.bearerTokenFile != "" {
+		data, err := os.ReadFile(fl.bearerTokenFile)
+		if err != nil {
+			return fmt.Errorf("Can't read bearer token: %w", err)
+		}
+		token := strings.TrimSpace(string(data))
+		if token == "" {
+			return fmt.Errorf("Bearer token file %s is empty", fl.bearerTokenFile)
+		}
+		server.WithBearerToken(token)
+	}
 	exporter := monitor.NewExporter(updatesChan, server,
-		"kube:health", "Kubernetes objects health status")
+		"kube:health", "Kubernetes objects health status").
+		WithScoreWeights(scoreWeights).
+		WithConditionMetrics(fl.conditionMetrics...)
 
 	return exporter.Start(ctx)
 }
@@ -170,8 +275,9 @@ func dedup(update monitor.TargetsStatusUpdate) monitor.TargetsStatusUpdate {
 	var targetStatuses []monitor.TargetStatuses
 	for _, target := range update.Statuses {
 		for _, s := range target.Statuses {
-			for _, id := range subObjectsIDs(s) {
-				seen[id] = struct{}{}
+			// Skipping the root: only the sub-objects make another status redundant.
+			for _, sub := range status.Flatten(s)[1:] {
+				seen[string(sub.Object.UID)] = struct{}{}
 			}
 		}
 	}
@@ -187,12 +293,17 @@ func dedup(update monitor.TargetsStatusUpdate) monitor.TargetsStatusUpdate {
 		}
 
 		targetStatuses = append(targetStatuses, monitor.TargetStatuses{
-			Target:   target.Target,
-			Statuses: statuses,
+			Target:    target.Target,
+			Statuses:  statuses,
+			Namespace: target.Namespace,
 		})
 	}
 
-	return monitor.TargetsStatusUpdate{Statuses: targetStatuses}
+	return monitor.TargetsStatusUpdate{
+		Statuses:      targetStatuses,
+		Duration:      update.Duration,
+		FailedTargets: update.FailedTargets,
+	}
 }
 
 func printerAdapter(updateChan <-chan monitor.TargetsStatusUpdate) <-chan eval.StatusUpdate {
@@ -206,22 +317,6 @@ func printerAdapter(updateChan <-chan monitor.TargetsStatusUpdate) <-chan eval.S
 	return outChan
 }
 
-func subObjectsIDs(obj status.ObjectStatus) []string {
-	var ids []string
-	subStatuses := obj.SubStatuses
-
-	for len(subStatuses) > 0 {
-		var nextSubStatuses []status.ObjectStatus
-		for _, sub := range subStatuses {
-			ids = append(ids, string(sub.Object.UID))
-			nextSubStatuses = append(nextSubStatuses, sub.SubStatuses...)
-		}
-		subStatuses = nextSubStatuses
-	}
-
-	return ids
-}
-
 // waitFunction decides when to stop waiting for the resources.
 // It's used by the PeriodicPrinter to decide when to stop the loop.
 func waitFunction(fl *flags, cancelFunc func()) func([]status.ObjectStatus) {
@@ -230,6 +325,15 @@ func waitFunction(fl *flags, cancelFunc func()) func([]status.ObjectStatus) {
 	}
 }
 
+// systemNamespaces returns the namespaces skipped by the targets without
+// a namespace.
+func (f *flags) systemNamespaces() []string {
+	if f.includeSystem {
+		return nil
+	}
+	return f.excludedNamespaces
+}
+
 func main() {
 	Execute()
 }
@@ -40,6 +40,14 @@ func TestEvaluator(testdata ...string) (*eval.Evaluator, *eval.FakeLoader, []*st
 	return evaluator, loader, objs
 }
 
+// TestEvaluatorWithOptions is like TestEvaluator, configuring the built-in
+// analyzers with the options.
+func TestEvaluatorWithOptions(opts analyze.Options, testdata ...string) (*eval.Evaluator, *eval.FakeLoader, []*status.Object) {
+	_, loader, objs := TestEvaluator(testdata...)
+	evaluator := eval.NewEvaluator(analyze.AnalyzersWithPlugins(analyze.WithOptions(opts)), loader)
+	return evaluator, loader, objs
+}
+
 func RegisterTestData(loader *eval.FakeLoader, file string) []*status.Object {
 	data, err := LoadObject[unstructured.UnstructuredList](file)
 	if err != nil {
@@ -150,12 +150,40 @@ func (a AlwaysGreenAnalyzer) Analyze(ctx context.Context, obj *status.Object) st
 // AnalyzerRegister is a registry of analyzers.
 // It allows to register new analyzers and ignored GroupKinds.
 type AnalyzerRegister struct {
-	analyzerInits []eval.AnalyzerInit
-	ignored       []schema.GroupKind
+	analyzerInits  []RegisterAwareInit
+	ignored        []schema.GroupKind
+	included       []schema.GroupKind
+	kindConditions KindConditionTable
+	conditionRules []conditionRule
+	options        *Options // nil means DefaultOptions
+}
+
+// RegisterAwareInit is like eval.AnalyzerInit, getting also the register
+// the analyzers are built from, e.g. to consult its ignored kinds.
+type RegisterAwareInit func(e *eval.Evaluator, r *AnalyzerRegister) eval.Analyzer
+
+// Plugin registers a set of analyzers and ignored kinds into the register.
+// It's the supported entrypoint for shipping analyzers built outside of this
+// repository: instead of relying on init() side effects, the plugin is passed
+// explicitly when building the analyzers (see AnalyzersWithPlugins).
+type Plugin func(r *AnalyzerRegister)
+
+// NewRegister returns an empty register, isolated from the global Register.
+func NewRegister() *AnalyzerRegister {
+	return &AnalyzerRegister{}
 }
 
 // Register registers new analyzers.
-func (r *AnalyzerRegister) Register(a ...eval.AnalyzerInit) {
+func (r *AnalyzerRegister) Register(as ...eval.AnalyzerInit) {
+	for _, a := range as {
+		r.RegisterAware(func(e *eval.Evaluator, _ *AnalyzerRegister) eval.Analyzer {
+			return a(e)
+		})
+	}
+}
+
+// RegisterAware registers new analyzers that need access to the register.
+func (r *AnalyzerRegister) RegisterAware(a ...RegisterAwareInit) {
 	r.analyzerInits = append(r.analyzerInits, a...)
 }
 
@@ -169,29 +197,125 @@ func (r *AnalyzerRegister) RegisterSimple(as ...eval.Analyzer) {
 }
 
 func (r AnalyzerRegister) IsIgnoredKind(gvk schema.GroupKind) bool {
-	return slices.Contains(r.ignored, gvk)
+	return slices.Contains(r.ignored, gvk) && !slices.Contains(r.included, gvk)
 }
 
 func (r *AnalyzerRegister) RegisterIgnoredKinds(gk ...schema.GroupKind) {
 	r.ignored = append(r.ignored, gk...)
 }
 
+// RegisterIncludedKinds stops ignoring the kinds, taking precedence
+// over RegisterIgnoredKinds regardless of the order of the calls.
+func (r *AnalyzerRegister) RegisterIncludedKinds(gk ...schema.GroupKind) {
+	r.included = append(r.included, gk...)
+}
+
+// IgnoredKinds returns the kinds ignored by the register, i.e. those
+// registered as ignored and not as included.
+func (r *AnalyzerRegister) IgnoredKinds() []schema.GroupKind {
+	var ret []schema.GroupKind
+	for _, gk := range r.ignored {
+		if r.IsIgnoredKind(gk) && !slices.Contains(ret, gk) {
+			ret = append(ret, gk)
+		}
+	}
+	return ret
+}
+
 func (r *AnalyzerRegister) AnalyzerInits() []eval.AnalyzerInit {
-	return r.analyzerInits
+	ret := make([]eval.AnalyzerInit, 0, len(r.analyzerInits))
+	for _, a := range r.analyzerInits {
+		ret = append(ret, func(e *eval.Evaluator) eval.Analyzer {
+			return a(e, r)
+		})
+	}
+	return ret
 }
 
-func DefaultAnalyzers() []eval.AnalyzerInit {
-	ret := make([]eval.AnalyzerInit, len(Register.AnalyzerInits()))
-	copy(ret, Register.AnalyzerInits())
-	ret = append(ret,
+// RegisterKindConditions registers the authoritative conditions of the kinds
+// used by the generic analyzer. For the same condition type, the conditions
+// registered first take precedence.
+func (r *AnalyzerRegister) RegisterKindConditions(t KindConditionTable) {
+	if r.kindConditions == nil {
+		r.kindConditions = make(KindConditionTable)
+	}
+	for gk, conds := range t {
+		r.kindConditions[gk] = append(r.kindConditions[gk], conds...)
+	}
+}
+
+// Analyzers returns the registered analyzers followed by the fallback ones
+// (always-green and generic analyzers). The generic analyzer uses the ignored
+// kinds from this register when evaluating the owned objects.
+func (r *AnalyzerRegister) Analyzers() []eval.AnalyzerInit {
+	ret := append(r.AnalyzerInits(),
 		func(_ *eval.Evaluator) eval.Analyzer { return DefaultAlwaysGreenAnalyzer },
-		DefaultAnalyzerInit)
+		func(e *eval.Evaluator) eval.Analyzer {
+			return &GenericAnalyzer{
+				e:                   e,
+				conditionsAnalyzers: DefaultConditionAnalyzers,
+				register:            r,
+			}
+		})
 	return ret
 }
 
+// OwnerQuerySpec returns a query for the objects owned by obj, skipping
+// the kinds ignored by the register.
+func (r *AnalyzerRegister) OwnerQuerySpec(obj *status.Object) eval.OwnerQuerySpec {
+	return eval.OwnerQuerySpec{
+		Object: obj,
+		GK: eval.GroupKindMatcher{
+			IncludeAll:    true,
+			ExcludedKinds: r.IgnoredKinds(),
+		},
+	}
+}
+
+func DefaultAnalyzers() []eval.AnalyzerInit {
+	return Register.Analyzers()
+}
+
+// AnalyzersWithPlugins returns the default analyzers extended by the plugins.
+// The plugins are applied to an isolated register, leaving the global Register
+// untouched. The analyzers registered by the plugins take precedence over
+// the built-in ones.
+func AnalyzersWithPlugins(plugins ...Plugin) []eval.AnalyzerInit {
+	r := NewRegister()
+	for _, p := range plugins {
+		p(r)
+	}
+
+	r.RegisterAware(Register.analyzerInits...)
+	r.RegisterIgnoredKinds(Register.ignored...)
+	r.RegisterIncludedKinds(Register.included...)
+	r.RegisterKindConditions(Register.kindConditions)
+	r.conditionRules = append(r.conditionRules, Register.conditionRules...)
+	if r.options == nil {
+		r.options = Register.options
+	}
+	return r.Analyzers()
+}
+
+// IgnoreKinds returns a plugin ignoring the kinds, e.g. the noisy CRDs
+// owned by the evaluated objects.
+func IgnoreKinds(gk ...schema.GroupKind) Plugin {
+	return func(r *AnalyzerRegister) {
+		r.RegisterIgnoredKinds(gk...)
+	}
+}
+
+// IncludeKinds returns a plugin that stops ignoring the kinds, including
+// the ones ignored by default.
+func IncludeKinds(gk ...schema.GroupKind) Plugin {
+	return func(r *AnalyzerRegister) {
+		r.RegisterIncludedKinds(gk...)
+	}
+}
+
 // TODO: add support for more kinds from
 // https://github.com/kubernetes-sigs/cli-utils/blob/master/pkg/kstatus/status/core.go
-// - [  ] statefulset
-// - [  ] job
-// - [  ] daemonset
+// - [x] statefulset
+// - [x] job
+// - [x] daemonset
 // - [  ] pdb
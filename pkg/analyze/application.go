@@ -0,0 +1,85 @@
+package analyze
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/rhobs/kube-health/pkg/eval"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+var gkApplication = schema.GroupKind{Group: "app.k8s.io", Kind: "Application"}
+
+// ApplicationAnalyzer analyzes the Application from kubernetes-sigs/application.
+// The components are selected based on spec.selector and spec.componentKinds
+// and evaluated as sub-objects.
+type ApplicationAnalyzer struct {
+	e *eval.Evaluator
+}
+
+func (_ ApplicationAnalyzer) Supports(obj *status.Object) bool {
+	return obj.GroupVersionKind().GroupKind() == gkApplication
+}
+
+func (a ApplicationAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	var subStatuses []status.ObjectStatus
+
+	kinds := applicationComponentKinds(obj)
+	if len(kinds) > 0 {
+		q := eval.NewSelectorLabelQuerySpec(obj, kinds[0])
+		q.GK = eval.GroupKindMatcher{IncludedKinds: kinds}
+
+		var err error
+		subStatuses, err = a.e.EvalQuery(ctx, q, nil)
+		if err != nil {
+			return status.UnknownStatusWithError(obj, err)
+		}
+	}
+
+	conditions, err := AnalyzeObjectConditions(obj, append(
+		[]ConditionAnalyzer{applicationConditionsAnalyzer},
+		DefaultConditionAnalyzers...))
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+
+	return AggregateResult(obj, subStatuses, conditions)
+}
+
+var applicationConditionsAnalyzer = GenericConditionAnalyzer{
+	Conditions:                 NewStringMatchers("Ready", "Settled"),
+	ReversedPolarityConditions: NewStringMatchers("Error"),
+	ProgressingConditions:      NewStringMatchers("Settled"),
+}
+
+// applicationComponentKinds returns the kinds listed in spec.componentKinds.
+func applicationComponentKinds(obj *status.Object) []schema.GroupKind {
+	componentKinds, _, _ := unstructured.NestedSlice(obj.Unstructured.Object, "spec", "componentKinds")
+
+	var ret []schema.GroupKind
+	for _, ck := range componentKinds {
+		ck, ok := ck.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		group, _, _ := unstructured.NestedString(ck, "group")
+		kind, _, _ := unstructured.NestedString(ck, "kind")
+		if kind == "" {
+			continue
+		}
+		if group == "core" {
+			// The core group is often referenced explicitly.
+			group = ""
+		}
+		ret = append(ret, schema.GroupKind{Group: group, Kind: kind})
+	}
+	return ret
+}
+
+func init() {
+	Register.Register(func(e *eval.Evaluator) eval.Analyzer {
+		return ApplicationAnalyzer{e: e}
+	})
+}
@@ -0,0 +1,32 @@
+package analyze_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/print"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestApplicationAnalyzer(t *testing.T) {
+	e, _, objs := test.TestEvaluator("applications.yaml")
+
+	os := e.Eval(t.Context(), objs[0])
+	assert.Equal(t, status.Error, os.Status().Result)
+
+	sb := &strings.Builder{}
+	print.NewTreePrinter(print.PrintOptions{ShowOk: true}).PrintStatuses([]status.ObjectStatus{os}, sb)
+	test.AssertStr(t, `
+OBJECT           CONDITION                       AGE    REASON
+Error default/Application/app1
+│                Ready=True                      24h    ComponentsReady
+│                Error=False                     24h    NoError
+├─ Ok ConfigMap/app1-config
+└─ Error Deployment/app1-web
+                 (Error) Available=False         24h    MinimumReplicasUnavailable
+                   Deployment does not have minimum availability.
+`, sb.String())
+}
@@ -0,0 +1,132 @@
+package certmanager
+
+// certificate.go implements an analyzer for the certificates issued by cert-manager
+// (https://cert-manager.io/).
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/klog/v2"
+
+	"github.com/rhobs/kube-health/pkg/analyze"
+	"github.com/rhobs/kube-health/pkg/eval"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+var (
+	gkCertificate = schema.GroupKind{Group: "cert-manager.io", Kind: "Certificate"}
+	grSecret      = schema.GroupResource{Group: "", Resource: "secrets"}
+
+	certificateConditionsAnalyzer = analyze.GenericConditionAnalyzer{
+		Conditions:                 analyze.NewStringMatchers("Ready"),
+		ReversedPolarityConditions: analyze.NewStringMatchers("Issuing"),
+		ProgressingConditions:      analyze.NewStringMatchers("Issuing"),
+	}
+)
+
+// CertificateAnalyzer evaluates the cert-manager Certificates, including
+// their expiry and the existence of the Secret holding the certificate.
+type CertificateAnalyzer struct {
+	e    *eval.Evaluator
+	opts analyze.Options
+}
+
+func (_ CertificateAnalyzer) Supports(obj *status.Object) bool {
+	return obj.GroupVersionKind().GroupKind() == gkCertificate
+}
+
+func (a CertificateAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	conditions, err := analyze.AnalyzeObjectConditions(obj, append(
+		[]analyze.ConditionAnalyzer{certificateConditionsAnalyzer},
+		analyze.DefaultConditionAnalyzers...))
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+
+	if expiryCond := expiryCondition(obj, time.Now(), a.opts.CertificateRenewalWindow); expiryCond != nil {
+		conditions = append(conditions, *expiryCond)
+	}
+
+	// The Secret is expected once the first issuance finishes.
+	issuing := status.GetCondition(conditions, "Issuing")
+	if issuing == nil || !issuing.Status().Progressing {
+		secretCond, err := a.secretCondition(ctx, obj)
+		if err != nil {
+			klog.V(2).ErrorS(err, "Failed to check Secret for Certificate", "object", obj)
+		} else if secretCond != nil {
+			conditions = append(conditions, *secretCond)
+		}
+	}
+
+	return analyze.AggregateResult(obj, nil, conditions)
+}
+
+// expiryCondition reports the expiry of the certificate (status.notAfter):
+// an error once expired, a warning within the renewal window.
+// It returns nil when the certificate was not issued yet.
+func expiryCondition(obj *status.Object, now time.Time, renewalWindow time.Duration) *status.ConditionStatus {
+	notAfterStr, _, _ := unstructured.NestedString(obj.Unstructured.Object, "status", "notAfter")
+	if notAfterStr == "" {
+		return nil
+	}
+	notAfter, err := time.Parse(time.RFC3339, notAfterStr)
+	if err != nil {
+		cond := analyze.ConditionStatusUnknownWithError(
+			analyze.SyntheticCondition("Expiry", false, "", "", time.Time{}), err)
+		return &cond
+	}
+
+	var cond status.ConditionStatus
+	left := notAfter.Sub(now)
+	switch {
+	case left <= 0:
+		cond = analyze.SyntheticConditionError("Expiry", "Expired",
+			fmt.Sprintf("Expired %s ago", duration.HumanDuration(-left)))
+	case left <= renewalWindow:
+		cond = analyze.SyntheticConditionWarning("Expiry", "ExpiringSoon",
+			fmt.Sprintf("Expires in %s", duration.HumanDuration(left)))
+	default:
+		cond = analyze.SyntheticConditionOk("Expiry",
+			fmt.Sprintf("Expires in %s", duration.HumanDuration(left)))
+	}
+	return &cond
+}
+
+// secretCondition reports the Secret of the certificate missing. Only
+// the existence of the Secret is checked: its content is not shown.
+// The Secret that can't be loaded (e.g. due to missing permissions)
+// is reported as Unknown.
+func (a CertificateAnalyzer) secretCondition(ctx context.Context, obj *status.Object) (*status.ConditionStatus, error) {
+	secretName, _, _ := unstructured.NestedString(obj.Unstructured.Object, "spec", "secretName")
+	if secretName == "" {
+		return nil, nil
+	}
+
+	secret, err := a.e.GetResource(ctx, grSecret, obj.GetNamespace(), secretName)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		cond := analyze.SyntheticConditionUnknown("Secret", "SecretUnavailable",
+			fmt.Sprintf("Can't check Secret %s", secretName), err)
+		return &cond, nil
+	}
+	if secret != nil {
+		return nil, nil
+	}
+
+	cond := analyze.SyntheticConditionError("Secret", "SecretNotFound",
+		fmt.Sprintf("Secret %s not found", secretName))
+	return &cond, nil
+}
+
+func init() {
+	analyze.Register.RegisterAware(func(e *eval.Evaluator, r *analyze.AnalyzerRegister) eval.Analyzer {
+		return CertificateAnalyzer{e: e, opts: r.Options()}
+	})
+}
@@ -0,0 +1,82 @@
+package certmanager_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/analyze"
+	_ "github.com/rhobs/kube-health/pkg/analyze/certmanager"
+	"github.com/rhobs/kube-health/pkg/eval"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestCertificateAnalyzer(t *testing.T) {
+	var os status.ObjectStatus
+	ctx := context.Background()
+	e, l, objs := test.TestEvaluator("certificates.yaml")
+
+	// The times in the testdata are in the past: the certificate is expired.
+	os = e.Eval(ctx, objs[0])
+	assert.Equal(t, status.Error, os.Status().Result)
+	expiry := status.GetCondition(os.Conditions, "Expiry")
+	require.NotNil(t, expiry)
+	assert.Equal(t, "Expired", expiry.Reason)
+	assert.Equal(t, "Expired 24h ago", expiry.Message)
+
+	setNotAfter := func(obj *status.Object, notAfter time.Time) {
+		require.NoError(t, unstructured.SetNestedField(obj.Unstructured.Object,
+			notAfter.Format(time.RFC3339), "status", "notAfter"))
+		e.Reset()
+	}
+
+	setNotAfter(objs[0], time.Now().Add(72*time.Hour+time.Minute))
+	os = e.Eval(ctx, objs[0])
+	assert.Equal(t, status.Warning, os.Status().Result)
+	test.AssertConditions(t, `
+Ready Ready Certificate is up to date and has not expired (Ok)
+Expiry ExpiringSoon Expires in 3d (Warning)
+`, os.Conditions)
+
+	setNotAfter(objs[0], time.Now().Add(60*24*time.Hour+time.Minute))
+	os = e.Eval(ctx, objs[0])
+	assert.Equal(t, status.Ok, os.Status().Result)
+
+	opts := analyze.DefaultOptions()
+	opts.CertificateRenewalWindow = 90 * 24 * time.Hour
+	we := eval.NewEvaluator(analyze.AnalyzersWithPlugins(analyze.WithOptions(opts)), l)
+	os = we.Eval(ctx, objs[0])
+	assert.Equal(t, status.Warning, os.Status().Result)
+
+	// The Secret is not expected before the first issuance.
+	os = e.Eval(ctx, objs[1])
+	assert.True(t, os.Status().Progressing)
+	assert.Nil(t, status.GetCondition(os.Conditions, "Secret"))
+
+	// Ready, but the Secret got deleted.
+	require.NoError(t, unstructured.SetNestedField(objs[0].Unstructured.Object, "missing-tls", "spec", "secretName"))
+	e.Reset()
+	os = e.Eval(ctx, objs[0])
+	assert.Equal(t, status.Error, os.Status().Result)
+	secret := status.GetCondition(os.Conditions, "Secret")
+	require.NotNil(t, secret)
+	assert.Equal(t, "Secret missing-tls not found", secret.Message)
+
+	// Not allowed to get the Secrets: the missing one can't be told.
+	l.RegisterUnavailable(schema.GroupResource{Resource: "secrets"},
+		apierrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "", errors.New("denied")))
+	e.Reset()
+	os = e.Eval(ctx, objs[0])
+	secret = status.GetCondition(os.Conditions, "Secret")
+	require.NotNil(t, secret)
+	assert.Equal(t, status.Unknown, secret.Status().Result)
+	assert.Equal(t, "Can't check Secret missing-tls", secret.Message)
+}
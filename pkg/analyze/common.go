@@ -52,6 +52,7 @@ func DefaultAnalyzerInit(e *eval.Evaluator) eval.Analyzer {
 	return &GenericAnalyzer{
 		e:                   e,
 		conditionsAnalyzers: DefaultConditionAnalyzers,
+		register:            Register,
 	}
 }
 
@@ -265,7 +266,7 @@ func SyntheticCondition(condType string, statusVal bool, reason, message string,
 		Status:             mStatus,
 		Reason:             reason,
 		Message:            message,
-		LastTransitionTime: metav1.Time{lastTransitionTime},
+		LastTransitionTime: metav1.Time{Time: lastTransitionTime},
 	}
 }
 
@@ -289,6 +290,12 @@ func SyntheticConditionError(condType, reason, message string) status.ConditionS
 		SyntheticCondition(condType, true, reason, message, time.Time{}))
 }
 
+func SyntheticConditionUnknown(condType, reason, message string, err error) status.ConditionStatus {
+	return ConditionStatusUnknownWithError(
+		SyntheticCondition(condType, false, reason, message, time.Time{}), err)
+}
+
 func init() {
 	Register.RegisterIgnoredKinds(ignoredGroupKinds...)
+	Register.RegisterKindConditions(DefaultKindConditions)
 }
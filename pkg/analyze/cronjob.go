@@ -0,0 +1,218 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"github.com/rhobs/kube-health/pkg/eval"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+var (
+	gkCronJob = batchv1.SchemeGroupVersion.WithKind("CronJob").GroupKind()
+)
+
+// cronJobStaleFactor defines how many schedule intervals can pass since
+// the last schedule time before the CronJob is considered stale.
+const cronJobStaleFactor = 2
+
+type CronJobAnalyzer struct {
+	e    *eval.Evaluator
+	opts Options
+}
+
+func (_ CronJobAnalyzer) Supports(obj *status.Object) bool {
+	return obj.GroupVersionKind().GroupKind() == gkCronJob
+}
+
+func (a CronJobAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	subStatuses, err := a.e.EvalQuery(ctx, eval.OwnerQuerySpec{
+		Object: obj,
+		GK:     eval.NewGroupKindMatcherSingle(gkJob),
+	}, JobAnalyzer{e: a.e, opts: a.opts})
+
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+
+	var cronJob batchv1.CronJob
+	err = FromUnstructured(obj.Unstructured.Object, &cronJob)
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+
+	conditions := cronJobSyntheticConditions(&cronJob, time.Now())
+	if history := cronJobHistoryCondition(subStatuses, a.opts.CronJobHistoryLimit); history != nil {
+		conditions = append(conditions, *history)
+	}
+
+	return AggregateResult(obj, subStatuses, conditions)
+}
+
+func cronJobSyntheticConditions(cronJob *batchv1.CronJob, now time.Time) []status.ConditionStatus {
+	if cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend {
+		return []status.ConditionStatus{
+			SyntheticConditionWarning("Suspended", "Suspended", "The CronJob is suspended"),
+		}
+	}
+
+	var lastSchedule time.Time
+	if cronJob.Status.LastScheduleTime != nil {
+		lastSchedule = cronJob.Status.LastScheduleTime.Time
+	}
+
+	message := fmt.Sprintf("Schedule: %s, active: %d", cronJob.Spec.Schedule, len(cronJob.Status.Active))
+	interval, err := scheduleInterval(cronJob.Spec.Schedule)
+	if err != nil {
+		return []status.ConditionStatus{ConditionStatusUnknownWithError(
+			SyntheticCondition("Scheduled", false, "InvalidSchedule", err.Error(), lastSchedule), err)}
+	}
+
+	if !lastSchedule.IsZero() && now.Sub(lastSchedule) > cronJobStaleFactor*interval {
+		return []status.ConditionStatus{ConditionStatusWarning(
+			SyntheticCondition("Scheduled", false, "Stale",
+				fmt.Sprintf("Last scheduled %s ago, expected at least every %s",
+					duration.HumanDuration(now.Sub(lastSchedule)), duration.HumanDuration(interval)),
+				lastSchedule))}
+	}
+
+	return []status.ConditionStatus{ConditionStatusOk(
+		SyntheticCondition("Scheduled", true, "", message, lastSchedule))}
+}
+
+// cronJobHistoryCondition summarizes the results of the last finished jobs,
+// to show the trend besides the status of the individual jobs.
+func cronJobHistoryCondition(jobs []status.ObjectStatus, limit int) *status.ConditionStatus {
+	type finishedJob struct {
+		name       string
+		failed     bool
+		finishedAt time.Time
+	}
+
+	var finished []finishedJob
+	for _, js := range jobs {
+		var job batchv1.Job
+		if err := FromUnstructured(js.Object.Unstructured.Object, &job); err != nil {
+			continue
+		}
+		for _, cond := range job.Status.Conditions {
+			if cond.Status != corev1.ConditionTrue ||
+				(cond.Type != batchv1.JobComplete && cond.Type != batchv1.JobFailed) {
+				continue
+			}
+			finished = append(finished, finishedJob{
+				name:       job.Name,
+				failed:     cond.Type == batchv1.JobFailed,
+				finishedAt: cond.LastTransitionTime.Time,
+			})
+			break
+		}
+	}
+	if len(finished) == 0 {
+		return nil
+	}
+
+	// The most recent first. The names of the jobs end with the scheduled
+	// time: use them to order the jobs finished at the same time.
+	slices.SortFunc(finished, func(a, b finishedJob) int {
+		if c := b.finishedAt.Compare(a.finishedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(b.name, a.name)
+	})
+	if limit > 0 && len(finished) > limit {
+		finished = finished[:limit]
+	}
+
+	failed := 0
+	for _, j := range finished {
+		if j.failed {
+			failed++
+		}
+	}
+
+	cond := ConditionStatusOk(SyntheticCondition("History", true, "",
+		fmt.Sprintf("Last %d: %d succeeded, %d failed", len(finished), len(finished)-failed, failed),
+		finished[0].finishedAt))
+	return &cond
+}
+
+// scheduleInterval estimates the longest interval between two runs
+// of the cron schedule. It's an upper bound approximation used to detect
+// CronJobs not being scheduled, not a complete cron implementation.
+func scheduleInterval(schedule string) (time.Duration, error) {
+	fields := strings.Fields(schedule)
+	// Skip the timezone specification.
+	if len(fields) > 0 && (strings.HasPrefix(fields[0], "CRON_TZ=") || strings.HasPrefix(fields[0], "TZ=")) {
+		fields = fields[1:]
+	}
+
+	if len(fields) == 1 {
+		switch fields[0] {
+		case "@yearly", "@annually":
+			return 366 * 24 * time.Hour, nil
+		case "@monthly":
+			return 31 * 24 * time.Hour, nil
+		case "@weekly":
+			return 7 * 24 * time.Hour, nil
+		case "@daily", "@midnight":
+			return 24 * time.Hour, nil
+		case "@hourly":
+			return time.Hour, nil
+		}
+	}
+
+	if len(fields) != 5 {
+		return 0, fmt.Errorf("unsupported schedule %q", schedule)
+	}
+
+	minute, hour, dom, month, dow := fields[0], fields[1], fields[2], fields[3], fields[4]
+	switch {
+	case month != "*":
+		return 366 * 24 * time.Hour, nil
+	case dom != "*":
+		return 31 * 24 * time.Hour, nil
+	case dow != "*":
+		return 7 * 24 * time.Hour, nil
+	}
+
+	if hour == "*" {
+		if step, ok := cronStep(minute); ok {
+			return time.Duration(step) * time.Minute, nil
+		}
+		return time.Hour, nil
+	}
+
+	if step, ok := cronStep(hour); ok {
+		return time.Duration(step) * time.Hour, nil
+	}
+	return 24 * time.Hour, nil
+}
+
+// cronStep returns the step of the cron field in the form of `*` or `*/n`.
+func cronStep(field string) (int, bool) {
+	if field == "*" {
+		return 1, true
+	}
+	if step, found := strings.CutPrefix(field, "*/"); found {
+		n, err := strconv.Atoi(step)
+		if err == nil && n > 0 {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+func init() {
+	Register.RegisterAware(func(e *eval.Evaluator, r *AnalyzerRegister) eval.Analyzer {
+		return CronJobAnalyzer{e: e, opts: r.Options()}
+	})
+}
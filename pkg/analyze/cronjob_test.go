@@ -0,0 +1,60 @@
+package analyze_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/analyze"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestCronJobAnalyzer(t *testing.T) {
+	var os status.ObjectStatus
+	e, _, objs := test.TestEvaluator("cronjobs.yaml")
+
+	os = e.Eval(t.Context(), objs[0])
+	assert.Equal(t, status.Ok, os.Status().Result)
+	test.AssertConditions(t, `
+Scheduled  Schedule: 0 3 * * *, active: 0 (Ok)
+History  Last 1: 1 succeeded, 0 failed (Ok)`, os.Conditions)
+	assert.Len(t, os.SubStatuses, 1)
+	assert.Equal(t, status.Ok, os.SubStatuses[0].Status().Result)
+
+	os = e.Eval(t.Context(), objs[1])
+	assert.Equal(t, status.Warning, os.Status().Result)
+	test.AssertConditions(t, `
+Suspended Suspended The CronJob is suspended (Warning)`, os.Conditions)
+
+	// Hourly job last scheduled a day ago, with a failed job.
+	os = e.Eval(t.Context(), objs[2])
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `
+Scheduled Stale Last scheduled 24h ago, expected at least every 60m (Warning)
+History  Last 1: 0 succeeded, 1 failed (Ok)`, os.Conditions)
+	assert.Len(t, os.SubStatuses, 1)
+	assert.Equal(t, status.Error, os.SubStatuses[0].Status().Result)
+}
+
+func TestCronJobAnalyzerHistory(t *testing.T) {
+	e, _, objs := test.TestEvaluator("cronjobs.yaml")
+
+	// Six finished jobs, two of them failed (including the oldest one, out of
+	// the history), and a running one.
+	os := e.Eval(t.Context(), objs[5])
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `
+Scheduled  Schedule: @daily, active: 1 (Ok)
+History  Last 5: 4 succeeded, 1 failed (Ok)`, os.Conditions)
+	assert.Len(t, os.SubStatuses, 7)
+
+	opts := analyze.DefaultOptions()
+	opts.CronJobHistoryLimit = 10
+	e, _, objs = test.TestEvaluatorWithOptions(opts, "cronjobs.yaml")
+
+	os = e.Eval(t.Context(), objs[5])
+	test.AssertConditions(t, `
+Scheduled  Schedule: @daily, active: 1 (Ok)
+History  Last 6: 4 succeeded, 2 failed (Ok)`, os.Conditions)
+}
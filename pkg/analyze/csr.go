@@ -0,0 +1,81 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"github.com/rhobs/kube-health/pkg/eval"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+var (
+	gkCSR = certificatesv1.SchemeGroupVersion.WithKind("CertificateSigningRequest").GroupKind()
+)
+
+// CSRAnalyzer evaluates the CertificateSigningRequests based on their
+// approval and on the issued certificate.
+type CSRAnalyzer struct {
+	opts Options
+}
+
+func (_ CSRAnalyzer) Supports(obj *status.Object) bool {
+	return obj.GroupVersionKind().GroupKind() == gkCSR
+}
+
+func (a CSRAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	var csr certificatesv1.CertificateSigningRequest
+	if err := FromUnstructured(obj.Unstructured.Object, &csr); err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+	return AggregateResult(obj, nil, csrConditions(&csr, time.Now(), a.opts.CSRApprovalTimeout))
+}
+
+func csrConditions(csr *certificatesv1.CertificateSigningRequest, now time.Time,
+	approvalTimeout time.Duration) []status.ConditionStatus {
+	var approved *certificatesv1.CertificateSigningRequestCondition
+	for i, c := range csr.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case certificatesv1.CertificateDenied, certificatesv1.CertificateFailed:
+			// Final: the request won't be issued.
+			return []status.ConditionStatus{ConditionStatusError(
+				SyntheticCondition(string(c.Type), true, c.Reason, c.Message, c.LastTransitionTime.Time))}
+		case certificatesv1.CertificateApproved:
+			approved = &csr.Status.Conditions[i]
+		}
+	}
+
+	condType := string(certificatesv1.CertificateApproved)
+	switch {
+	case approved != nil && len(csr.Status.Certificate) > 0:
+		return []status.ConditionStatus{ConditionStatusOk(
+			SyntheticCondition(condType, true, approved.Reason, "The certificate was issued",
+				approved.LastTransitionTime.Time))}
+	case approved != nil:
+		return []status.ConditionStatus{ConditionStatusProgressing(
+			SyntheticCondition(condType, true, "Issuing",
+				fmt.Sprintf("Waiting for the signer %s to issue the certificate", csr.Spec.SignerName),
+				approved.LastTransitionTime.Time))}
+	}
+
+	age := now.Sub(csr.CreationTimestamp.Time)
+	if age > approvalTimeout {
+		return []status.ConditionStatus{SyntheticConditionWarning(condType, "PendingApproval",
+			fmt.Sprintf("Waiting for the approval for %s", duration.HumanDuration(age)))}
+	}
+	return []status.ConditionStatus{SyntheticConditionProgressing(condType, "PendingApproval",
+		"Waiting for the approval")}
+}
+
+func init() {
+	Register.RegisterAware(func(_ *eval.Evaluator, r *AnalyzerRegister) eval.Analyzer {
+		return CSRAnalyzer{opts: r.Options()}
+	})
+}
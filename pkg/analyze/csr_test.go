@@ -0,0 +1,47 @@
+package analyze_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/analyze"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestCSRAnalyzer(t *testing.T) {
+	e, _, objs := test.TestEvaluator("csrs.yaml")
+
+	os := e.Eval(t.Context(), objs[0])
+	assert.Equal(t, status.Ok, os.Status().Result)
+	test.AssertConditions(t, `Approved AutoApproved The certificate was issued (Ok)`, os.Conditions)
+
+	os = e.Eval(t.Context(), objs[1])
+	assert.Equal(t, status.Warning, os.Status().Result)
+	test.AssertConditions(t, `Approved PendingApproval Waiting for the approval for 24h (Warning)`, os.Conditions)
+
+	os = e.Eval(t.Context(), objs[2])
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `Denied NotAllowed The node is not known to the cluster. (Error)`, os.Conditions)
+
+	os = e.Eval(t.Context(), objs[3])
+	assert.True(t, os.Status().Progressing)
+	test.AssertConditions(t, `Approved Issuing Waiting for the signer example.com/custom to issue the certificate (Unknown)`,
+		os.Conditions)
+
+	os = e.Eval(t.Context(), objs[4])
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `Failed SignerValidationFailure Invalid subject alternative names. (Error)`, os.Conditions)
+}
+
+func TestCSRAnalyzerApprovalTimeout(t *testing.T) {
+	opts := analyze.DefaultOptions()
+	opts.CSRApprovalTimeout = 48 * time.Hour
+
+	e, _, objs := test.TestEvaluatorWithOptions(opts, "csrs.yaml")
+	os := e.Eval(t.Context(), objs[1])
+	assert.True(t, os.Status().Progressing)
+	test.AssertConditions(t, `Approved PendingApproval Waiting for the approval (Unknown)`, os.Conditions)
+}
@@ -0,0 +1,86 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+
+	"github.com/rhobs/kube-health/pkg/eval"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+var gkDaemonSet = appsv1.SchemeGroupVersion.WithKind("DaemonSet").GroupKind()
+
+type DaemonSetAnalyzer struct {
+	e    *eval.Evaluator
+	opts Options
+}
+
+func (_ DaemonSetAnalyzer) Supports(obj *status.Object) bool {
+	return obj.GroupVersionKind().GroupKind() == gkDaemonSet
+}
+
+func (a DaemonSetAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	subStatuses, err := a.e.EvalQuery(ctx,
+		eval.NewSelectorLabelQuerySpec(obj, gkPod), PodAnalyzer{e: a.e, opts: a.opts})
+
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+
+	conditions, err := AnalyzeObjectConditions(obj, DefaultConditionAnalyzers)
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+
+	conditions = append(AnalyzeObservedGeneration(obj), conditions...)
+
+	synthConditions, err := daemonSetSyntheticConditions(obj)
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+	conditions = append(conditions, synthConditions...)
+
+	return AggregateResult(obj, subStatuses, conditions)
+}
+
+func daemonSetSyntheticConditions(obj *status.Object) ([]status.ConditionStatus, error) {
+	var ds appsv1.DaemonSet
+	var conditions []status.ConditionStatus
+
+	err := FromUnstructured(obj.Unstructured.Object, &ds)
+	if err != nil {
+		return nil, err
+	}
+
+	// No matching nodes means there is nothing to wait for: the zero desired
+	// count is treated as satisfied.
+	desired := ds.Status.DesiredNumberScheduled
+
+	if ds.Status.NumberReady < desired {
+		conditions = append(conditions, ConditionStatusError(
+			SyntheticCondition("NumberReady", false, "NotReady",
+				fmt.Sprintf("Ready: %d/%d, unavailable: %d",
+					ds.Status.NumberReady, desired, ds.Status.NumberUnavailable), time.Time{})))
+	} else {
+		conditions = append(conditions, ConditionStatusOk(
+			SyntheticCondition("NumberReady", true, "Ready",
+				fmt.Sprintf("Ready: %d/%d", ds.Status.NumberReady, desired), time.Time{})))
+	}
+
+	if ds.Status.UpdatedNumberScheduled < desired {
+		conditions = append(conditions, ConditionStatusProgressing(
+			SyntheticCondition("UpdateProgressing", true, "RollingUpdate",
+				fmt.Sprintf("Updated: %d/%d", ds.Status.UpdatedNumberScheduled, desired), time.Time{})))
+	}
+
+	return conditions, nil
+}
+
+func init() {
+	Register.RegisterAware(func(e *eval.Evaluator, r *AnalyzerRegister) eval.Analyzer {
+		return DaemonSetAnalyzer{e: e, opts: r.Options()}
+	})
+}
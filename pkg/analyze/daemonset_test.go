@@ -0,0 +1,37 @@
+package analyze_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestDaemonSetAnalyzer(t *testing.T) {
+	var os status.ObjectStatus
+	e, _, objs := test.TestEvaluator("daemonsets.yaml", "pods.yaml")
+
+	os = e.Eval(t.Context(), objs[0])
+	assert.False(t, os.Status().Progressing)
+	assert.Equal(t, status.Ok, os.Status().Result)
+	test.AssertConditions(t, `
+NumberReady Ready Ready: 1/1 (Ok)`, os.Conditions)
+	assert.Len(t, os.SubStatuses, 1)
+
+	os = e.Eval(t.Context(), objs[1])
+	assert.True(t, os.Status().Progressing)
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `
+NumberReady NotReady Ready: 2/3, unavailable: 1 (Error)
+UpdateProgressing RollingUpdate Updated: 1/3 (Unknown)`, os.Conditions)
+	assert.Len(t, os.SubStatuses, 1)
+
+	// No nodes matching the DaemonSet.
+	os = e.Eval(t.Context(), objs[2])
+	assert.False(t, os.Status().Progressing)
+	assert.Equal(t, status.Ok, os.Status().Result)
+	test.AssertConditions(t, `
+NumberReady Ready Ready: 0/0 (Ok)`, os.Conditions)
+}
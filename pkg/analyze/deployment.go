@@ -2,20 +2,28 @@ package analyze
 
 import (
 	"context"
+	"fmt"
 	"slices"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
 
 	"github.com/rhobs/kube-health/pkg/eval"
 	"github.com/rhobs/kube-health/pkg/status"
 )
 
-var gkDeployment = appsv1.SchemeGroupVersion.WithKind("Deployment").GroupKind()
+var (
+	gkDeployment = appsv1.SchemeGroupVersion.WithKind("Deployment").GroupKind()
+	gkHPA        = autoscalingv2.SchemeGroupVersion.WithKind("HorizontalPodAutoscaler").GroupKind()
+)
 
 type DeploymentAnalyzer struct {
-	e *eval.Evaluator
+	e    *eval.Evaluator
+	opts Options
 }
 
 func (_ DeploymentAnalyzer) Supports(obj *status.Object) bool {
@@ -24,14 +32,17 @@ func (_ DeploymentAnalyzer) Supports(obj *status.Object) bool {
 
 func (a DeploymentAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
 	subStatuses, err := a.e.EvalQuery(ctx,
-		eval.NewSelectorLabelQuerySpec(obj, gkReplicaSet), ReplicaSetAnalyzer{e: a.e})
+		eval.NewSelectorLabelQuerySpec(obj, gkReplicaSet), ReplicaSetAnalyzer{e: a.e, opts: a.opts})
 
 	if err != nil {
 		return status.UnknownStatusWithError(obj, err)
 	}
 
+	paused, _, _ := unstructured.NestedBool(obj.Unstructured.Object, "spec", "paused")
+	replicas, found, _ := unstructured.NestedInt64(obj.Unstructured.Object, "spec", "replicas")
+	scaledToZero := found && replicas == 0
 	conditions, err := AnalyzeObjectConditions(obj, append(
-		[]ConditionAnalyzer{deploymentConditionAnalyzer{}},
+		[]ConditionAnalyzer{deploymentConditionAnalyzer{paused: paused, scaledToZero: scaledToZero}},
 		DefaultConditionAnalyzers...))
 
 	// We don't care about ReplicaSets scaled down to 0.
@@ -43,13 +54,27 @@ func (a DeploymentAnalyzer) Analyze(ctx context.Context, obj *status.Object) sta
 	// More precise progress detection based on ReplicaSets status.
 	progressingCond := status.GetCondition(conditions, "Progressing")
 	if progressingCond != nil {
-		allDone := len(subStatuses) > 0
+		// The ReplicaSets of a deployment scaled to zero are all filtered out.
+		allDone := len(subStatuses) > 0 || scaledToZero
 		for _, subStatus := range subStatuses {
 			if subStatus.Status().Result != status.Ok || subStatus.Status().Progressing {
 				allDone = false
 				break
 			}
 		}
+		// The spec.replicas might lag behind the HPA decision: the rollout
+		// is done once the count desired by the HPA is ready.
+		if allDone {
+			msg, err := a.hpaReplicasPending(ctx, obj)
+			if err != nil {
+				// The HPA is only a refinement: don't fail the whole evaluation
+				// when it can't be loaded (e.g. due to missing permissions).
+				klog.V(2).ErrorS(err, "Failed to evaluate HorizontalPodAutoscaler for Deployment", "object", obj)
+			} else if msg != "" {
+				allDone = false
+				progressingCond.Message = msg
+			}
+		}
 		if allDone {
 			progressingCond.CondStatus.Progressing = false
 			progressingCond.CondStatus.Result = status.Ok
@@ -60,20 +85,116 @@ func (a DeploymentAnalyzer) Analyze(ctx context.Context, obj *status.Object) sta
 		return status.UnknownStatusWithError(obj, err)
 	}
 
+	conditions = append(AnalyzeObservedGeneration(obj), conditions...)
+
+	if paused {
+		// The rollout was paused deliberately (e.g. during a canary), it won't
+		// make any progress until resumed.
+		conditions = append(conditions, SyntheticConditionWarning("RolloutPaused", "DeploymentPaused",
+			"The rollout is paused until resumed"))
+	}
+
+	if scaledToZero {
+		conditions = append(conditions, scaledToZeroCondition(obj))
+	}
+
+	if a.opts.NetworkPolicyCoverage {
+		npCond, err := networkPolicyCoverageCondition(ctx, a.e, obj)
+		if err != nil {
+			klog.V(2).ErrorS(err, "Failed to evaluate NetworkPolicy coverage for Deployment", "object", obj)
+		} else if npCond != nil {
+			conditions = append(conditions, *npCond)
+		}
+	}
+
+	if a.opts.ReferenceChecks {
+		refConds, err := missingReferencesConditions(ctx, a.e, obj, "spec", "template", "spec")
+		if err != nil {
+			klog.V(2).ErrorS(err, "Failed to check ConfigMap and Secret references for Deployment", "object", obj)
+		}
+		conditions = append(conditions, refConds...)
+	}
+
 	return AggregateResult(obj, subStatuses, conditions)
 }
 
+// scaledToZeroCondition reports the deployment scaled to zero replicas
+// deliberately: there is nothing to be available. It's progressing until
+// the remaining pods terminate.
+func scaledToZeroCondition(obj *status.Object) status.ConditionStatus {
+	current, _, _ := unstructured.NestedInt64(obj.Unstructured.Object, "status", "replicas")
+	if current > 0 {
+		return SyntheticConditionProgressing("ScaledToZero", "ScalingDown",
+			fmt.Sprintf("Pending terminations: %d", current))
+	}
+	return SyntheticConditionOk("ScaledToZero", "Scaled to zero replicas")
+}
+
+// hpaReplicasPending describes the ready replicas of the deployment missing
+// to reach the count desired by the HorizontalPodAutoscaler targeting it.
+// It returns empty string when there are enough of them or there is no HPA.
+func (a DeploymentAnalyzer) hpaReplicasPending(ctx context.Context, obj *status.Object) (string, error) {
+	hpa, err := a.findHPA(ctx, obj)
+	if err != nil || hpa == nil {
+		return "", err
+	}
+
+	ready, _, _ := unstructured.NestedInt64(obj.Unstructured.Object, "status", "readyReplicas")
+	desired := hpa.Status.DesiredReplicas
+	if int32(ready) >= desired {
+		return "", nil
+	}
+	return fmt.Sprintf("Ready: %d/%d, desired by HPA %s", ready, desired, hpa.Name), nil
+}
+
+// findHPA returns the HorizontalPodAutoscaler targeting the deployment.
+func (a DeploymentAnalyzer) findHPA(ctx context.Context, obj *status.Object) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	hpas, err := a.e.Load(ctx, eval.KindQuerySpec{
+		GK: eval.NewGroupKindMatcherSingle(gkHPA),
+		Ns: obj.GetNamespace(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hpaObj := range hpas {
+		var hpa autoscalingv2.HorizontalPodAutoscaler
+		if err := FromUnstructured(hpaObj.Unstructured.Object, &hpa); err != nil {
+			return nil, err
+		}
+
+		ref := hpa.Spec.ScaleTargetRef
+		gv, _ := schema.ParseGroupVersion(ref.APIVersion)
+		if gv.Group == gkDeployment.Group && ref.Kind == gkDeployment.Kind && ref.Name == obj.GetName() {
+			return &hpa, nil
+		}
+	}
+	return nil, nil
+}
+
 // deploymentConditionAnalyzer implements ConditionAnalyzer for Deployment
-type deploymentConditionAnalyzer struct{}
+type deploymentConditionAnalyzer struct {
+	// paused deployments don't progress: neither the progress nor
+	// the progress deadline is to be interpreted.
+	paused bool
+	// scaledToZero deployments have nothing to be available.
+	scaledToZero bool
+}
 
 func (a deploymentConditionAnalyzer) Analyze(cond *metav1.Condition) status.ConditionStatus {
 	if cond.Type == "Progressing" {
+		if a.paused {
+			return ConditionStatusUnknown(cond)
+		}
 		if cond.Reason == "ProgressDeadlineExceeded" {
 			return ConditionStatusError(cond)
 		}
 	}
 
 	if cond.Type == "Available" {
+		if a.scaledToZero {
+			return ConditionStatusOk(cond)
+		}
 		if cond.Status == metav1.ConditionFalse {
 			return ConditionStatusError(cond)
 		}
@@ -83,7 +204,7 @@ func (a deploymentConditionAnalyzer) Analyze(cond *metav1.Condition) status.Cond
 }
 
 func init() {
-	Register.Register(func(e *eval.Evaluator) eval.Analyzer {
-		return DeploymentAnalyzer{e: e}
+	Register.RegisterAware(func(e *eval.Evaluator, r *AnalyzerRegister) eval.Analyzer {
+		return DeploymentAnalyzer{e: e, opts: r.Options()}
 	})
 }
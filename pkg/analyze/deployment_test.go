@@ -1,12 +1,16 @@
 package analyze_test
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/analyze"
 	"github.com/rhobs/kube-health/pkg/print"
 	"github.com/rhobs/kube-health/pkg/status"
 )
@@ -48,30 +52,158 @@ Ok default/Deployment/dp1
 	p.PrintStatuses([]status.ObjectStatus{os}, sb)
 
 	test.AssertStr(t, `
-OBJECT           CONDITION                       AGE    REASON
+OBJECT           CONDITION                        AGE    REASON
 Progressing default/Deployment/dp2
-│                Available=True                  24h    MinimumReplicasAvailable
-│                Progressing=True                24h    NewReplicaSetAvailable
+│                Available=True                   24h    MinimumReplicasAvailable
+│                Progressing=True                 24h    NewReplicaSetAvailable
 │                  zorg
 └─ Error ReplicaSet/rs2
-   │             (Error) ReplicasLabeled=False          Unlabeled
+   │             (Error) ReplicasLabeled=False           Unlabeled
    │               Labeled: 0/2
-   │             (Error) ReplicasAvailable=Fals         Unavailable
+   │             (Error) ReplicasAvailable=False         Unavailable
    │               Available: 0/2
-   │             (Error) ReplicasReady=False            NotReady
+   │             (Error) ReplicasReady=False             NotReady
    │               Ready: 0/2
    └─ Error Pod/p2
-      │          PodReadyToStartContainers=True  24h
-      │          Initialized=True                24h
-      │          (Error) Ready=False             24h    ContainersNotReady
+      │          PodReadyToStartContainers=True   24h
+      │          Initialized=True                 24h
+      │          (Error) Ready=False              24h    ContainersNotReady
       │            containers with unready status: [p2c]
-      │          ContainersReady=False           24h    ContainersNotReady
-      │          PodScheduled=True               24h
+      │          ContainersReady=False            24h    ContainersNotReady
+      │          PodScheduled=True                24h
       └─ Error Container/p2c
-                 (Error) Ready=True                     NotReady
+                 (Error) Ready=True                      NotReady
+
                    Logs:
                    Line 1
                    Line 2
                    Line 3
 `, sb.String())
 }
+
+func TestDeploymentAnalyzerHPA(t *testing.T) {
+	var os status.ObjectStatus
+	e, _, objs := test.TestEvaluator("hpas.yaml")
+
+	// spec.replicas lags behind the HPA: the HPA decision is the target.
+	os = e.Eval(t.Context(), objs[0])
+	assert.False(t, os.Status().Progressing)
+	assert.Equal(t, status.Ok, os.Status().Result)
+	test.AssertConditions(t, `Available MinimumReplicasAvailable Deployment has minimum availability. (Unknown)
+Progressing NewReplicaSetAvailable ReplicaSet has successfully progressed. (Ok)`, os.Conditions)
+
+	// The ReplicaSet is done, but the HPA desires more replicas.
+	os = e.Eval(t.Context(), objs[1])
+	assert.True(t, os.Status().Progressing)
+	test.AssertConditions(t, `Available MinimumReplicasAvailable Deployment has minimum availability. (Unknown)
+Progressing NewReplicaSetAvailable Ready: 3/5, desired by HPA hpa2 (Unknown)`, os.Conditions)
+
+	os = e.Eval(t.Context(), objs[2])
+	assert.Empty(t, os.Conditions)
+}
+
+func TestDeploymentAnalyzerNetworkPolicyCoverage(t *testing.T) {
+	var os status.ObjectStatus
+	e, _, objs := test.TestEvaluator("networkpolicies.yaml")
+
+	// The check is disabled by default.
+	os = e.Eval(t.Context(), objs[1])
+	assert.Empty(t, os.Conditions)
+
+	opts := analyze.DefaultOptions()
+	opts.NetworkPolicyCoverage = true
+	e, _, objs = test.TestEvaluatorWithOptions(opts, "networkpolicies.yaml")
+
+	os = e.Eval(t.Context(), objs[0])
+	assert.Equal(t, status.Ok, os.Status().Result)
+	test.AssertConditions(t, `NetworkPolicyCoverage  Pods selected by NetworkPolicy: allow-web, frontend-egress (Ok)`, os.Conditions)
+
+	os = e.Eval(t.Context(), objs[1])
+	assert.Equal(t, status.Warning, os.Status().Result)
+	test.AssertConditions(t, `NetworkPolicyCoverage NotCovered Pods not selected by any of the 2 NetworkPolicies in the namespace (Warning)`, os.Conditions)
+
+	// No NetworkPolicies in the namespace: nothing to report.
+	os = e.Eval(t.Context(), objs[2])
+	assert.Empty(t, os.Conditions)
+}
+
+func TestDeploymentAnalyzerReferences(t *testing.T) {
+	var os status.ObjectStatus
+	e, _, objs := test.TestEvaluator("references.yaml")
+
+	// The check is disabled by default.
+	os = e.Eval(t.Context(), objs[0])
+	assert.Empty(t, os.Conditions)
+
+	opts := analyze.DefaultOptions()
+	opts.ReferenceChecks = true
+	e, _, objs = test.TestEvaluatorWithOptions(opts, "references.yaml")
+
+	os = e.Eval(t.Context(), objs[0])
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `
+MissingReference SecretNotFound Secret app-tls referenced by volume tls not found (Error)
+MissingReference SecretNotFound Secret app-credentials referenced by container app not found (Error)`,
+		os.Conditions)
+	// The referenced objects are not part of the tree.
+	assert.Empty(t, os.SubStatuses)
+
+	os = e.Eval(t.Context(), objs[1])
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `MissingReference SecretNotFound Secret app-credentials referenced by container main not found (Error)`,
+		os.Conditions)
+
+	// Not allowed to list the Secrets: the missing ones can't be told.
+	e, loader, objs := test.TestEvaluatorWithOptions(opts, "references.yaml")
+	loader.RegisterUnavailable(schema.GroupResource{Resource: "secrets"},
+		apierrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "", errors.New("denied")))
+
+	os = e.Eval(t.Context(), objs[0])
+	assert.NotEqual(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `MissingReference SecretUnavailable Can't check the Secrets referenced by the pod spec (Unknown)`,
+		os.Conditions)
+}
+
+func TestDeploymentAnalyzerPaused(t *testing.T) {
+	var os status.ObjectStatus
+	e, _, objs := test.TestEvaluator("deployments.yaml")
+
+	os = e.Eval(t.Context(), objs[3])
+	assert.False(t, os.Status().Progressing)
+	assert.Equal(t, status.Warning, os.Status().Result)
+	test.AssertConditions(t, `
+Available MinimumReplicasAvailable Deployment has minimum availability. (Unknown)
+Progressing DeploymentPaused Deployment is paused (Unknown)
+RolloutPaused DeploymentPaused The rollout is paused until resumed (Warning)`,
+		os.Conditions)
+
+	// The progress deadline is not interpreted while paused.
+	os = e.Eval(t.Context(), objs[4])
+	assert.False(t, os.Status().Progressing)
+	assert.Equal(t, status.Warning, os.Status().Result)
+	test.AssertConditions(t, `
+Available MinimumReplicasAvailable Deployment has minimum availability. (Unknown)
+Progressing ProgressDeadlineExceeded ReplicaSet "dp5-7d4f8" has timed out progressing. (Unknown)
+RolloutPaused DeploymentPaused The rollout is paused until resumed (Warning)`,
+		os.Conditions)
+}
+
+func TestDeploymentAnalyzerScaledToZero(t *testing.T) {
+	var os status.ObjectStatus
+	e, _, objs := test.TestEvaluator("deployments.yaml")
+
+	// Nothing is expected to be available.
+	os = e.Eval(t.Context(), objs[5])
+	assert.False(t, os.Status().Progressing)
+	assert.Equal(t, status.Ok, os.Status().Result)
+	test.AssertConditions(t, `
+Available MinimumReplicasUnavailable Deployment does not have minimum availability. (Ok)
+Progressing NewReplicaSetAvailable ReplicaSet "dp6-5b9c7" has successfully progressed. (Ok)
+ScaledToZero  Scaled to zero replicas (Ok)`,
+		os.Conditions)
+
+	// The pods are still terminating.
+	os = e.Eval(t.Context(), objs[2])
+	assert.True(t, os.Status().Progressing)
+	test.AssertConditions(t, `ScaledToZero ScalingDown Pending terminations: 1 (Unknown)`, os.Conditions)
+}
@@ -0,0 +1,180 @@
+package flux
+
+// flux.go implements analyzers for the resources reconciled by Flux
+// (https://fluxcd.io/): the Kustomizations and the HelmReleases.
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	"github.com/rhobs/kube-health/pkg/analyze"
+	"github.com/rhobs/kube-health/pkg/eval"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+var (
+	gkKustomization = schema.GroupKind{Group: "kustomize.toolkit.fluxcd.io", Kind: "Kustomization"}
+	gkHelmRelease   = schema.GroupKind{Group: "helm.toolkit.fluxcd.io", Kind: "HelmRelease"}
+
+	// fluxConditionsAnalyzer follows the kstatus conventions used by Flux:
+	// Stalled=True means the reconciliation failed and won't be retried
+	// without a change, Reconciling=True means it's in progress.
+	fluxConditionsAnalyzer = analyze.GenericConditionAnalyzer{
+		Conditions:                 analyze.NewStringMatchers("Ready"),
+		ReversedPolarityConditions: analyze.NewStringMatchers("Stalled", "Reconciling"),
+		ProgressingConditions:      analyze.NewStringMatchers("Reconciling"),
+	}
+)
+
+// analyzeFluxConditions analyzes the conditions of a Flux resource.
+func analyzeFluxConditions(obj *status.Object) ([]status.ConditionStatus, error) {
+	conditions, err := analyze.AnalyzeObjectConditions(obj, append(
+		[]analyze.ConditionAnalyzer{fluxConditionsAnalyzer},
+		analyze.DefaultConditionAnalyzers...))
+	if err != nil {
+		return nil, err
+	}
+	notReadyWhileReconciling(conditions)
+	return conditions, nil
+}
+
+// notReadyWhileReconciling reports the Ready=False condition as progressing
+// while the reconciliation is in progress: it's not a failure unless
+// the resource gets stalled.
+func notReadyWhileReconciling(conditions []status.ConditionStatus) {
+	reconciling := status.GetCondition(conditions, "Reconciling")
+	if reconciling == nil || reconciling.Condition.Status != metav1.ConditionTrue {
+		return
+	}
+	if stalled := status.GetCondition(conditions, "Stalled"); stalled != nil &&
+		stalled.Condition.Status == metav1.ConditionTrue {
+		return
+	}
+
+	for _, cond := range conditions {
+		if cond.Type == "Ready" && cond.CondStatus.Result == status.Error {
+			cond.CondStatus.Result = status.Unknown
+			cond.CondStatus.Progressing = true
+		}
+	}
+}
+
+// KustomizationAnalyzer evaluates the Flux Kustomizations, including
+// the objects in their inventory.
+type KustomizationAnalyzer struct {
+	e        *eval.Evaluator
+	register *analyze.AnalyzerRegister // source of the ignored kinds
+}
+
+func (_ KustomizationAnalyzer) Supports(obj *status.Object) bool {
+	return obj.GroupVersionKind().GroupKind() == gkKustomization
+}
+
+func (a KustomizationAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	conditions, err := analyzeFluxConditions(obj)
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+
+	entries, _, err := unstructured.NestedSlice(obj.Unstructured.Object, "status", "inventory", "entries")
+	if err != nil {
+		// do not add any substatuses in case of error
+		klog.V(5).ErrorS(err, "Failed to get inventory from Kustomization", "object", obj)
+		return analyze.AggregateResult(obj, nil, conditions)
+	}
+
+	subStatuses := a.evaluateInventory(ctx, obj, parseInventory(entries))
+	return analyze.AggregateResult(obj, subStatuses, conditions)
+}
+
+func (a KustomizationAnalyzer) evaluateInventory(ctx context.Context, obj *status.Object,
+	refs []inventoryRef) []status.ObjectStatus {
+	// The objects are independent of each other: evaluate them concurrently
+	// when the evaluator allows it.
+	return a.e.RunParallel(len(refs), func(i int) []status.ObjectStatus {
+		ref := refs[i]
+		if a.register.IsIgnoredKind(ref.gvk.GroupKind()) {
+			return nil
+		}
+		apiVersion, kind := ref.gvk.ToAPIVersionAndKind()
+		statuses, err := a.e.EvalQuery(ctx, eval.RefQuerySpec{
+			Object: obj,
+			RefObject: corev1.ObjectReference{
+				APIVersion: apiVersion,
+				Kind:       kind,
+				Name:       ref.name,
+			},
+			NamespaceOverride: &ref.namespace,
+		}, nil)
+		if err != nil {
+			klog.V(5).ErrorS(err, "Failed to evaluate inventory object of Kustomization",
+				"object", obj, "kind", kind, "namespace", ref.namespace, "name", ref.name)
+			return nil
+		}
+		return statuses
+	})
+}
+
+// inventoryRef is an object managed by a Kustomization.
+type inventoryRef struct {
+	gvk             schema.GroupVersionKind
+	namespace, name string
+}
+
+// parseInventory reads the inventory entries. Their ids have the format
+// <namespace>_<name>_<group>_<kind>, with an empty namespace for
+// the cluster-scoped objects and an empty group for the core ones.
+// The version is stored separately.
+func parseInventory(entries []interface{}) []inventoryRef {
+	var ret []inventoryRef
+	for _, entry := range entries {
+		entryMap, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, _, _ := unstructured.NestedString(entryMap, "id")
+		version, _, _ := unstructured.NestedString(entryMap, "v")
+
+		parts := strings.Split(id, "_")
+		if len(parts) != 4 {
+			klog.V(5).Infof("Unexpected inventory entry %s", id)
+			continue
+		}
+		ret = append(ret, inventoryRef{
+			gvk:       schema.GroupVersionKind{Group: parts[2], Version: version, Kind: parts[3]},
+			namespace: parts[0],
+			name:      parts[1],
+		})
+	}
+	return ret
+}
+
+// HelmReleaseAnalyzer evaluates the Flux HelmReleases. Unlike
+// the Kustomizations, they don't keep the inventory of the managed
+// objects in the status: only the conditions are analyzed.
+type HelmReleaseAnalyzer struct{}
+
+func (_ HelmReleaseAnalyzer) Supports(obj *status.Object) bool {
+	return obj.GroupVersionKind().GroupKind() == gkHelmRelease
+}
+
+func (_ HelmReleaseAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	conditions, err := analyzeFluxConditions(obj)
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+	return analyze.AggregateResult(obj, nil, conditions)
+}
+
+func init() {
+	analyze.Register.RegisterAware(func(e *eval.Evaluator, r *analyze.AnalyzerRegister) eval.Analyzer {
+		return KustomizationAnalyzer{e: e, register: r}
+	})
+	analyze.Register.RegisterSimple(HelmReleaseAnalyzer{})
+}
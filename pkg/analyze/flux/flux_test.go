@@ -0,0 +1,64 @@
+package flux_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhobs/kube-health/internal/test"
+	_ "github.com/rhobs/kube-health/pkg/analyze/flux"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestKustomizationAnalyzer(t *testing.T) {
+	var os status.ObjectStatus
+	ctx := context.Background()
+	e, _, objs := test.TestEvaluator("kustomizations.yaml", "helmreleases.yaml")
+
+	// The objects in the inventory are evaluated as the sub-objects,
+	// except for the ignored kinds, such as ConfigMaps.
+	os = e.Eval(ctx, objs[0])
+	assert.False(t, os.Status().Progressing)
+	assert.Equal(t, status.Ok, os.Status().Result)
+	test.AssertConditions(t, `Ready ReconciliationSucceeded Applied revision: main@sha1:5f2c1b0 (Ok)`, os.Conditions)
+	require.Len(t, os.SubStatuses, 1)
+	assert.Equal(t, "HelmRelease", os.SubStatuses[0].Object.Kind)
+	assert.Equal(t, "podinfo", os.SubStatuses[0].Object.Name)
+
+	os = e.Eval(ctx, objs[1])
+	assert.False(t, os.Status().Progressing)
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `
+Ready ArtifactFailed kustomization path not found: stat /tmp/kustomization-1/infra: no such file or directory (Error)
+Stalled ArtifactFailed kustomization path not found: stat /tmp/kustomization-1/infra: no such file or directory (Error)
+`, os.Conditions)
+
+	// Not ready while reconciling is not a failure yet.
+	os = e.Eval(ctx, objs[2])
+	assert.True(t, os.Status().Progressing)
+	assert.Equal(t, status.Unknown, os.Status().Result)
+	test.AssertConditions(t, `
+Reconciling ProgressingWithRetry Detecting drift for revision main@sha1:5f2c1b0 with a timeout of 5m0s (Unknown)
+Ready HealthCheckFailed Health check failed after 30s: timeout waiting for: [Deployment/monitoring/grafana status: InProgress] (Unknown)
+`, os.Conditions)
+}
+
+func TestHelmReleaseAnalyzer(t *testing.T) {
+	var os status.ObjectStatus
+	ctx := context.Background()
+	e, _, objs := test.TestEvaluator("helmreleases.yaml")
+
+	os = e.Eval(ctx, objs[0])
+	assert.False(t, os.Status().Progressing)
+	assert.Equal(t, status.Ok, os.Status().Result)
+
+	os = e.Eval(ctx, objs[1])
+	assert.False(t, os.Status().Progressing)
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `
+Stalled RetriesExceeded Failed to upgrade after 3 attempt(s) (Error)
+Ready UpgradeFailed Helm upgrade failed for release default/redis with chart redis@19.0.1: context deadline exceeded (Error)
+`, os.Conditions)
+}
@@ -3,6 +3,7 @@ package analyze
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -17,6 +18,7 @@ import (
 type GenericAnalyzer struct {
 	e                   *eval.Evaluator
 	conditionsAnalyzers []ConditionAnalyzer
+	register            *AnalyzerRegister // source of the ignored kinds
 }
 
 func (a *GenericAnalyzer) Supports(obj *status.Object) bool {
@@ -24,7 +26,7 @@ func (a *GenericAnalyzer) Supports(obj *status.Object) bool {
 }
 
 func (a *GenericAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
-	subStatuses, err := a.e.EvalQuery(ctx, GenericOwnerQuerySpec(obj), nil)
+	subStatuses, err := a.e.EvalQuery(ctx, a.register.OwnerQuerySpec(obj), nil)
 	if err != nil {
 		return status.UnknownStatusWithError(obj, err)
 	}
@@ -37,7 +39,11 @@ func (a *GenericAnalyzer) Analyze(ctx context.Context, obj *status.Object) statu
 
 	conditions := AnalyzeObservedGeneration(obj)
 
-	conds, err := AnalyzeObjectConditions(obj, a.conditionsAnalyzers)
+	// The user-supplied rules and the authoritative conditions of the kind
+	// take precedence.
+	conditionsAnalyzers := slices.Concat(a.register.conditionRuleAnalyzers(obj),
+		a.register.kindConditions.kindConditionAnalyzers(obj), a.conditionsAnalyzers)
+	conds, err := AnalyzeObjectConditions(obj, conditionsAnalyzers)
 	if err != nil {
 		err = fmt.Errorf("Error analyzing conditions: %w", err)
 		return status.UnknownStatusWithError(obj, err)
@@ -45,23 +51,44 @@ func (a *GenericAnalyzer) Analyze(ctx context.Context, obj *status.Object) statu
 
 	conditions = append(conditions, conds...)
 
+	// Many kinds report their health via status.phase instead of the conditions.
+	if !conditionsTellHealth(conds) {
+		if phaseCond := AnalyzeObjectPhase(obj, []ConditionAnalyzer{DefaultPhaseAnalyzer}); phaseCond != nil {
+			conditions = append(conditions, *phaseCond)
+		}
+	}
+
 	return AggregateResult(obj, subStatuses, conditions)
 }
 
-func GenericOwnerQuerySpec(obj *status.Object) eval.OwnerQuerySpec {
-	return eval.OwnerQuerySpec{
-		Object: obj,
-		GK: eval.GroupKindMatcher{
-			IncludeAll:    true,
-			ExcludedKinds: Register.ignored,
-		},
+// conditionsTellHealth tells whether any of the conditions has a known result.
+func conditionsTellHealth(conditions []status.ConditionStatus) bool {
+	for _, c := range conditions {
+		if st := c.Status(); st.Result != status.Unknown || st.Progressing {
+			return true
+		}
 	}
+	return false
 }
 
-// GenericConditionAnalyzers adds additional conditions based on values
-// of generation and observedGeneration fields.
-func AnalyzeObservedGeneration(obj *status.Object) []status.ConditionStatus {
-	observedGeneration, found, err := unstructured.NestedInt64(obj.Unstructured.Object, "status", "observedGeneration")
+// GenericOwnerQuerySpec returns a query for the objects owned by obj, skipping
+// the kinds ignored by the global Register. The analyzers registered with
+// RegisterAware should use the OwnerQuerySpec of their register instead,
+// to honor the kinds ignored by the plugins.
+func GenericOwnerQuerySpec(obj *status.Object) eval.OwnerQuerySpec {
+	return Register.OwnerQuerySpec(obj)
+}
+
+// AnalyzeObservedGeneration reports the object as progressing while its
+// controller hasn't observed the latest spec yet, i.e. the observed generation
+// is behind metadata.generation. The observed generation is read from
+// status.observedGeneration, unless another path is given for the controllers
+// storing it elsewhere.
+func AnalyzeObservedGeneration(obj *status.Object, path ...string) []status.ConditionStatus {
+	if len(path) == 0 {
+		path = []string{"status", "observedGeneration"}
+	}
+	observedGeneration, found, err := unstructured.NestedInt64(obj.Unstructured.Object, path...)
 	if err != nil {
 		return []status.ConditionStatus{ConditionStatusUnknownWithError(
 			SyntheticCondition("ObservedGeneration", false, "", "", time.Time{}), err)}
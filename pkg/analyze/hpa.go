@@ -0,0 +1,87 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/rhobs/kube-health/pkg/eval"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+var (
+	// grPodMetrics is the resource metrics API the HPA uses for the CPU and
+	// memory based scaling.
+	grPodMetrics = schema.GroupResource{Group: "metrics.k8s.io", Resource: "pods"}
+)
+
+type HPAAnalyzer struct {
+	e *eval.Evaluator
+}
+
+func (_ HPAAnalyzer) Supports(obj *status.Object) bool {
+	return obj.GroupVersionKind().GroupKind() == gkHPA
+}
+
+func (a HPAAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	var hpa autoscalingv2.HorizontalPodAutoscaler
+	if err := FromUnstructured(obj.Unstructured.Object, &hpa); err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+
+	// Failing to get the resource metrics is usually caused by the metrics API
+	// being down, rather than by the HPA itself: check it to tell the difference.
+	condAnalyzer := hpaConditionAnalyzer{}
+	for _, cond := range hpa.Status.Conditions {
+		if cond.Type == autoscalingv2.ScalingActive && cond.Status == "False" &&
+			cond.Reason == "FailedGetResourceMetric" {
+			condAnalyzer.metricsErr = a.e.CheckAvailable(ctx, grPodMetrics, obj.GetNamespace())
+		}
+	}
+
+	conditions := AnalyzeObservedGeneration(obj)
+	conds, err := AnalyzeObjectConditions(obj, append(
+		[]ConditionAnalyzer{condAnalyzer},
+		DefaultConditionAnalyzers...))
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+	conditions = append(conditions, conds...)
+
+	return AggregateResult(obj, nil, conditions)
+}
+
+// hpaConditionAnalyzer implements ConditionAnalyzer for HorizontalPodAutoscaler.
+type hpaConditionAnalyzer struct {
+	// metricsErr is set when the metrics API is not available.
+	metricsErr error
+}
+
+func (a hpaConditionAnalyzer) Analyze(cond *metav1.Condition) status.ConditionStatus {
+	if cond.Type == string(autoscalingv2.ScalingActive) && cond.Status == metav1.ConditionFalse {
+		if cond.Reason == "ScalingDisabled" {
+			// Scaled to zero on purpose.
+			return ConditionStatusOk(cond)
+		}
+		if cond.Reason == "FailedGetResourceMetric" && a.metricsErr != nil {
+			cond.Message = fmt.Sprintf("metrics API unavailable (%s): %s; %s",
+				grPodMetrics.Group, a.metricsErr, cond.Message)
+		}
+		return ConditionStatusError(cond)
+	}
+
+	if cond.Type == string(autoscalingv2.AbleToScale) && cond.Status == metav1.ConditionFalse {
+		return ConditionStatusError(cond)
+	}
+
+	return ConditionStatusNoMatch
+}
+
+func init() {
+	Register.Register(func(e *eval.Evaluator) eval.Analyzer {
+		return HPAAnalyzer{e: e}
+	})
+}
@@ -0,0 +1,32 @@
+package analyze_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestHPAAnalyzerMetricsUnavailable(t *testing.T) {
+	e, l, objs := test.TestEvaluator("hpas.yaml")
+
+	os := e.Eval(t.Context(), objs[5])
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `AbleToScale ReadyForNewScale recommended size matches current size (Unknown)
+ScalingActive FailedGetResourceMetric the HPA was unable to compute the replica count: failed to get cpu utilization (Error)`,
+		os.Conditions)
+
+	e, l, objs = test.TestEvaluator("hpas.yaml")
+	l.RegisterUnavailable(schema.GroupResource{Group: "metrics.k8s.io", Resource: "pods"},
+		fmt.Errorf("the server is currently unable to handle the request"))
+
+	os = e.Eval(t.Context(), objs[5])
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `AbleToScale ReadyForNewScale recommended size matches current size (Unknown)
+ScalingActive FailedGetResourceMetric metrics API unavailable (metrics.k8s.io): the server is currently unable to handle the request; the HPA was unable to compute the replica count: failed to get cpu utilization (Error)`,
+		os.Conditions)
+}
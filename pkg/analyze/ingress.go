@@ -0,0 +1,116 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+
+	"github.com/rhobs/kube-health/pkg/eval"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+var gkIngress = networkingv1.SchemeGroupVersion.WithKind("Ingress").GroupKind()
+
+type IngressAnalyzer struct {
+	e *eval.Evaluator
+}
+
+func (_ IngressAnalyzer) Supports(obj *status.Object) bool {
+	return obj.GroupVersionKind().GroupKind() == gkIngress
+}
+
+func (a IngressAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	var ingress networkingv1.Ingress
+	err := FromUnstructured(obj.Unstructured.Object, &ingress)
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+
+	var subStatuses []status.ObjectStatus
+	var conditions []status.ConditionStatus
+
+	backends := ingressBackendServices(&ingress)
+	if len(backends) > 0 {
+		// The Services that can't be listed (e.g. due to missing permissions)
+		// are not necessarily missing.
+		if err := a.e.CheckAvailable(ctx, grService, obj.GetNamespace()); err != nil {
+			conditions = append(conditions, SyntheticConditionUnknown("BackendService", "ServiceUnavailable",
+				"Can't check the backend services", err))
+			backends = nil
+		}
+	}
+	for _, name := range backends {
+		services, err := a.e.EvalQuery(ctx, eval.RefQuerySpec{
+			Object: obj,
+			RefObject: corev1.ObjectReference{
+				APIVersion: "v1",
+				Kind:       "Service",
+				Name:       name,
+				Namespace:  obj.GetNamespace(),
+			},
+		}, ServiceAnalyzer{e: a.e})
+		if err != nil {
+			return status.UnknownStatusWithError(obj, err)
+		}
+
+		if len(services) == 0 {
+			conditions = append(conditions, SyntheticConditionError("BackendService", "ServiceNotFound",
+				fmt.Sprintf("Backend service %s not found", name)))
+		}
+		subStatuses = append(subStatuses, services...)
+	}
+
+	var addresses []string
+	for _, lb := range ingress.Status.LoadBalancer.Ingress {
+		if lb.Hostname != "" {
+			addresses = append(addresses, lb.Hostname)
+		} else if lb.IP != "" {
+			addresses = append(addresses, lb.IP)
+		}
+	}
+
+	if len(addresses) == 0 {
+		conditions = append(conditions, SyntheticConditionProgressing("LoadBalancerReady", "Pending",
+			"Load balancer address not assigned yet"))
+	} else {
+		conditions = append(conditions, SyntheticConditionOk("LoadBalancerReady",
+			fmt.Sprintf("Address: %s", strings.Join(addresses, ", "))))
+	}
+
+	return AggregateResult(obj, subStatuses, conditions)
+}
+
+// ingressBackendServices returns unique names of the services referenced
+// by the ingress, in the order of appearance.
+func ingressBackendServices(ingress *networkingv1.Ingress) []string {
+	var names []string
+	add := func(backend *networkingv1.IngressBackend) {
+		if backend == nil || backend.Service == nil || backend.Service.Name == "" {
+			return
+		}
+		if !slices.Contains(names, backend.Service.Name) {
+			names = append(names, backend.Service.Name)
+		}
+	}
+
+	add(ingress.Spec.DefaultBackend)
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			add(&path.Backend)
+		}
+	}
+	return names
+}
+
+func init() {
+	Register.Register(func(e *eval.Evaluator) eval.Analyzer {
+		return IngressAnalyzer{e: e}
+	})
+}
@@ -0,0 +1,44 @@
+package analyze_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestIngressAnalyzer(t *testing.T) {
+	var os status.ObjectStatus
+	e, _, objs := test.TestEvaluator("ingresses.yaml", "services.yaml", "pods.yaml")
+
+	os = e.Eval(t.Context(), objs[0])
+	assert.False(t, os.Status().Progressing)
+	assert.Equal(t, status.Ok, os.Status().Result)
+	test.AssertConditions(t, `
+LoadBalancerReady  Address: 192.0.2.10 (Ok)`, os.Conditions)
+	assert.Len(t, os.SubStatuses, 1)
+	assert.Equal(t, "s1", os.SubStatuses[0].Object.GetName())
+
+	os = e.Eval(t.Context(), objs[1])
+	assert.True(t, os.Status().Progressing)
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `
+BackendService ServiceNotFound Backend service s1-typo not found (Error)
+LoadBalancerReady Pending Load balancer address not assigned yet (Unknown)`, os.Conditions)
+	assert.Len(t, os.SubStatuses, 1)
+
+	// Not allowed to list the Services: the missing ones can't be told.
+	e, l, objs := test.TestEvaluator("ingresses.yaml", "services.yaml", "pods.yaml")
+	l.RegisterUnavailable(schema.GroupResource{Resource: "services"},
+		apierrors.NewForbidden(schema.GroupResource{Resource: "services"}, "", errors.New("denied")))
+	os = e.Eval(t.Context(), objs[1])
+	assert.NotEqual(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `
+BackendService ServiceUnavailable Can't check the backend services (Unknown)
+LoadBalancerReady Pending Load balancer address not assigned yet (Unknown)`, os.Conditions)
+}
@@ -0,0 +1,190 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/rhobs/kube-health/pkg/eval"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+var gkJob = batchv1.SchemeGroupVersion.WithKind("Job").GroupKind()
+
+// defaultBackoffLimit is used by the Job controller when spec.backoffLimit
+// is not set.
+const defaultBackoffLimit = 6
+
+type JobAnalyzer struct {
+	e    *eval.Evaluator
+	opts Options
+}
+
+func (_ JobAnalyzer) Supports(obj *status.Object) bool {
+	return obj.GroupVersionKind().GroupKind() == gkJob
+}
+
+func (a JobAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	subStatuses, err := a.e.EvalQuery(ctx,
+		eval.NewSelectorLabelQuerySpec(obj, gkPod), PodAnalyzer{e: a.e, opts: a.opts})
+
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+
+	conditions, err := AnalyzeObjectConditions(obj, append(
+		[]ConditionAnalyzer{jobConditionAnalyzer{}},
+		DefaultConditionAnalyzers...))
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+
+	var job batchv1.Job
+	err = FromUnstructured(obj.Unstructured.Object, &job)
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+	conditions = append(conditions, jobSyntheticConditions(&job))
+
+	return AggregateResult(obj, subStatuses, conditions)
+}
+
+// jobSyntheticConditions summarizes the progress of the job into
+// a single Completions condition.
+func jobSyntheticConditions(job *batchv1.Job) status.ConditionStatus {
+	backoffLimit := int32(defaultBackoffLimit)
+	if job.Spec.BackoffLimitPerIndex != nil {
+		// The failures are limited per index: the controller defaults
+		// the overall limit to unlimited.
+		backoffLimit = math.MaxInt32
+	}
+	if job.Spec.BackoffLimit != nil {
+		backoffLimit = *job.Spec.BackoffLimit
+	}
+
+	failedCond, completeCond := false, false
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == "True" {
+			failedCond = true
+		}
+		if c.Type == batchv1.JobComplete && c.Status == "True" {
+			completeCond = true
+		}
+	}
+
+	if failedCond || job.Status.Failed > backoffLimit {
+		return SyntheticConditionError("Completions", "Failed",
+			fmt.Sprintf("Failed: %d, backoff limit: %d", job.Status.Failed, backoffLimit))
+	}
+
+	// With spec.backoffLimitPerIndex, the job keeps running the other indexes,
+	// but it's going to fail in the end.
+	if job.Status.FailedIndexes != nil && *job.Status.FailedIndexes != "" {
+		return SyntheticConditionError("Completions", "FailedIndexes",
+			fmt.Sprintf("Failed indexes: %s", *job.Status.FailedIndexes))
+	}
+
+	var progress string
+	var done bool
+	switch {
+	case isIndexedJob(job) && job.Spec.Completions != nil:
+		// The pods of the same index might succeed multiple times:
+		// only the completed indexes tell the progress.
+		completions := *job.Spec.Completions
+		completed := countIndexes(job.Status.CompletedIndexes)
+		progress = fmt.Sprintf("Completed indexes: %d/%d", completed, completions)
+		if completed > 0 {
+			progress += fmt.Sprintf(" (%s)", job.Status.CompletedIndexes)
+		}
+		done = completed >= completions
+	case job.Spec.Completions != nil:
+		completions := *job.Spec.Completions
+		progress = fmt.Sprintf("Succeeded: %d/%d", job.Status.Succeeded, completions)
+		done = job.Status.Succeeded >= completions
+	default:
+		// Work queue jobs: completed once any pod succeeded and the rest
+		// has terminated.
+		progress = fmt.Sprintf("Succeeded: %d", job.Status.Succeeded)
+		done = job.Status.Succeeded > 0 && job.Status.Active == 0
+	}
+
+	// The success policy might complete the job earlier.
+	if done || completeCond {
+		return SyntheticConditionOk("Completions", progress)
+	}
+
+	if job.Spec.Completions == nil && job.Status.Succeeded > 0 {
+		// No new pods are started after the first success: the job
+		// waits for the active ones to finish.
+		return SyntheticConditionProgressing("Completions", "Finishing",
+			fmt.Sprintf("%s, active: %d", progress, job.Status.Active))
+	}
+
+	if job.Status.Active > 0 {
+		return SyntheticConditionProgressing("Completions", "Active",
+			fmt.Sprintf("%s, active: %d", progress, job.Status.Active))
+	}
+
+	return SyntheticConditionProgressing("Completions", "Pending", progress)
+}
+
+func isIndexedJob(job *batchv1.Job) bool {
+	return job.Spec.CompletionMode != nil && *job.Spec.CompletionMode == batchv1.IndexedCompletion
+}
+
+// countIndexes returns the number of the indexes in the format used by
+// status.completedIndexes of the indexed jobs, e.g. "1,3-5,7".
+// The malformed parts are skipped.
+func countIndexes(indexes string) int32 {
+	var count int32
+	for part := range strings.SplitSeq(indexes, ",") {
+		if part == "" {
+			continue
+		}
+		first, last, isRange := strings.Cut(part, "-")
+		from, err := strconv.ParseInt(first, 10, 32)
+		if err != nil {
+			continue
+		}
+		to := from
+		if isRange {
+			to, err = strconv.ParseInt(last, 10, 32)
+			if err != nil || to < from {
+				continue
+			}
+		}
+		count += int32(to - from + 1)
+	}
+	return count
+}
+
+// jobConditionAnalyzer implements ConditionAnalyzer for Job
+type jobConditionAnalyzer struct{}
+
+func (a jobConditionAnalyzer) Analyze(cond *metav1.Condition) status.ConditionStatus {
+	switch cond.Type {
+	case string(batchv1.JobFailed), string(batchv1.JobFailureTarget):
+		if cond.Status == metav1.ConditionTrue {
+			return ConditionStatusError(cond)
+		}
+		return ConditionStatusOk(cond)
+	case string(batchv1.JobComplete), string(batchv1.JobSuccessCriteriaMet):
+		if cond.Status == metav1.ConditionTrue {
+			return ConditionStatusOk(cond)
+		}
+		return ConditionStatusUnknown(cond)
+	}
+
+	return ConditionStatusNoMatch
+}
+
+func init() {
+	Register.RegisterAware(func(e *eval.Evaluator, r *AnalyzerRegister) eval.Analyzer {
+		return JobAnalyzer{e: e, opts: r.Options()}
+	})
+}
@@ -0,0 +1,85 @@
+package analyze_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestJobAnalyzer(t *testing.T) {
+	var os status.ObjectStatus
+	e, _, objs := test.TestEvaluator("jobs.yaml")
+
+	os = e.Eval(t.Context(), objs[0])
+	assert.False(t, os.Status().Progressing)
+	assert.Equal(t, status.Ok, os.Status().Result)
+	test.AssertConditions(t, `
+SuccessCriteriaMet   (Ok)
+Complete   (Ok)
+Completions  Succeeded: 1/1 (Ok)`, os.Conditions)
+
+	os = e.Eval(t.Context(), objs[1])
+	assert.False(t, os.Status().Progressing)
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `
+FailureTarget BackoffLimitExceeded Job has reached the specified backoff limit (Error)
+Failed BackoffLimitExceeded Job has reached the specified backoff limit (Error)
+Completions Failed Failed: 2, backoff limit: 1 (Error)`, os.Conditions)
+	assert.Len(t, os.SubStatuses, 1)
+	assert.Equal(t, "job2-x7k2p", os.SubStatuses[0].Object.GetName())
+	assert.Equal(t, status.Error, os.SubStatuses[0].Status().Result)
+
+	// Parallel job with no fixed completion count.
+	os = e.Eval(t.Context(), objs[2])
+	assert.True(t, os.Status().Progressing)
+	test.AssertConditions(t, `
+Completions Active Succeeded: 0, active: 2 (Unknown)`, os.Conditions)
+}
+
+func TestJobAnalyzerParallel(t *testing.T) {
+	var os status.ObjectStatus
+	e, _, objs := test.TestEvaluator("jobs.yaml")
+
+	// Indexed job: the progress is based on the completed indexes.
+	os = e.Eval(t.Context(), objs[4])
+	assert.True(t, os.Status().Progressing)
+	test.AssertConditions(t, `
+Completions Active Completed indexes: 3/5 (0,2-3), active: 2 (Unknown)`, os.Conditions)
+
+	os = e.Eval(t.Context(), objs[5])
+	assert.False(t, os.Status().Progressing)
+	assert.Equal(t, status.Ok, os.Status().Result)
+	test.AssertConditions(t, `
+Complete   (Ok)
+Completions  Completed indexes: 3/3 (0-2) (Ok)`, os.Conditions)
+
+	// Indexed job with a per-index backoff limit: the failed index fails the job
+	// even though the other indexes are still running.
+	os = e.Eval(t.Context(), objs[6])
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `
+Completions FailedIndexes Failed indexes: 1 (Error)`, os.Conditions)
+
+	// The failures spread over the indexes don't count against the default
+	// backoff limit of the job.
+	os = e.Eval(t.Context(), objs[9])
+	assert.True(t, os.Status().Progressing)
+	assert.NotEqual(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `
+Completions Active Completed indexes: 2/10 (0-1), active: 5 (Unknown)`, os.Conditions)
+
+	// Non-indexed parallel job.
+	os = e.Eval(t.Context(), objs[7])
+	assert.True(t, os.Status().Progressing)
+	test.AssertConditions(t, `
+Completions Active Succeeded: 2/4, active: 2 (Unknown)`, os.Conditions)
+
+	// Run to the first success: waiting for the rest of the pods to finish.
+	os = e.Eval(t.Context(), objs[8])
+	assert.True(t, os.Status().Progressing)
+	test.AssertConditions(t, `
+Completions Finishing Succeeded: 1, active: 1 (Unknown)`, os.Conditions)
+}
@@ -0,0 +1,179 @@
+package analyze
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// ConditionPolarity tells which value of a condition is the healthy one.
+type ConditionPolarity string
+
+const (
+	// PolarityNormal conditions are healthy when True, e.g. Ready.
+	PolarityNormal ConditionPolarity = "normal"
+	// PolarityReversed conditions are healthy when False, e.g. Degraded.
+	PolarityReversed ConditionPolarity = "reversed"
+)
+
+// KindCondition describes the semantics of an authoritative condition
+// of a kind.
+type KindCondition struct {
+	Type     string            `yaml:"type"`
+	Polarity ConditionPolarity `yaml:"polarity"`
+	// Severity is the result of the condition in the unhealthy state:
+	// error (the default), warning or unknown.
+	Severity string `yaml:"severity"`
+	// Progressing marks the unhealthy state as a transient one,
+	// e.g. Reconciling=True.
+	Progressing bool `yaml:"progressing"`
+}
+
+// Analyzer returns the condition analyzer matching the condition type.
+func (c KindCondition) Analyzer() (GenericConditionAnalyzer, error) {
+	return newConditionAnalyzer(c.Type, NewStringMatchers(c.Type), c.Polarity, c.Severity, c.Progressing)
+}
+
+// newConditionAnalyzer returns the analyzer of the conditions matching
+// the matchers. The name identifies the conditions in the errors.
+func newConditionAnalyzer(name string, matchers []Matcher, polarity ConditionPolarity,
+	severity string, progressing bool) (GenericConditionAnalyzer, error) {
+	var a GenericConditionAnalyzer
+
+	switch polarity {
+	case PolarityNormal, "":
+		a.Conditions = matchers
+	case PolarityReversed:
+		a.ReversedPolarityConditions = matchers
+	default:
+		return a, fmt.Errorf("unknown polarity %q of condition %s, expected one of: normal, reversed", polarity, name)
+	}
+
+	if progressing {
+		a.ProgressingConditions = matchers
+	}
+
+	switch strings.ToLower(severity) {
+	case "error", "":
+	case "warning":
+		a.WarningConditions = matchers
+	case "unknown":
+		a.UnknownConditions = matchers
+	default:
+		return a, fmt.Errorf("unknown severity %q of condition %s, expected one of: error, warning, unknown", severity, name)
+	}
+	return a, nil
+}
+
+// KindConditionTable maps the kinds to their authoritative conditions.
+// The GenericAnalyzer consults it before the DefaultConditionAnalyzers,
+// so that the kinds without dedicated analyzers (typically CRDs) get
+// an accurate analysis.
+type KindConditionTable map[schema.GroupKind][]KindCondition
+
+// DefaultKindConditions is the built-in table of well-known kinds.
+var DefaultKindConditions = KindConditionTable{
+	{Group: "apiregistration.k8s.io", Kind: "APIService"}: {
+		{Type: "Available"},
+	},
+	{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}: {
+		{Type: "Established"},
+		{Type: "NamesAccepted"},
+		{Type: "NonStructuralSchema", Polarity: PolarityReversed, Severity: "warning"},
+	},
+	{Group: "policy", Kind: "PodDisruptionBudget"}: {
+		{Type: "DisruptionAllowed", Severity: "warning"},
+	},
+	{Group: "cert-manager.io", Kind: "Certificate"}: {
+		{Type: "Ready"},
+		{Type: "Issuing", Polarity: PolarityReversed, Progressing: true},
+	},
+	{Group: "cert-manager.io", Kind: "Issuer"}: {
+		{Type: "Ready"},
+	},
+	{Group: "cert-manager.io", Kind: "ClusterIssuer"}: {
+		{Type: "Ready"},
+	},
+	{Group: "gateway.networking.k8s.io", Kind: "Gateway"}: {
+		{Type: "Accepted"},
+		{Type: "Programmed"},
+	},
+	{Group: "monitoring.coreos.com", Kind: "Prometheus"}: {
+		{Type: "Available"},
+		{Type: "Reconciled", Severity: "warning"},
+	},
+	{Group: "monitoring.coreos.com", Kind: "Alertmanager"}: {
+		{Type: "Available"},
+		{Type: "Reconciled", Severity: "warning"},
+	},
+}
+
+// kindConditionsConfig is the format of the file read by ReadKindConditions.
+type kindConditionsConfig struct {
+	Kinds []struct {
+		// Kind in the KIND.GROUP format, e.g. Widget.example.com.
+		Kind       string          `yaml:"kind"`
+		Conditions []KindCondition `yaml:"conditions"`
+	} `yaml:"kinds"`
+}
+
+// ReadKindConditions reads the conditions of the kinds from a YAML file, such as:
+//
+//	kinds:
+//	- kind: Widget.example.com
+//	  conditions:
+//	  - type: Healthy
+//	  - type: Degraded
+//	    polarity: reversed
+//	    severity: warning
+func ReadKindConditions(path string) (KindConditionTable, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadKindConditions(f)
+}
+
+// LoadKindConditions is like ReadKindConditions, reading the YAML from r.
+func LoadKindConditions(r io.Reader) (KindConditionTable, error) {
+	var cfg kindConditionsConfig
+	if err := yaml.NewDecoder(r).Decode(&cfg); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	ret := make(KindConditionTable)
+	for _, k := range cfg.Kinds {
+		gk := schema.ParseGroupKind(k.Kind)
+		for _, c := range k.Conditions {
+			if _, err := c.Analyzer(); err != nil {
+				return nil, fmt.Errorf("kind %s: %w", k.Kind, err)
+			}
+		}
+		ret[gk] = append(ret[gk], k.Conditions...)
+	}
+	return ret, nil
+}
+
+// kindConditionAnalyzers returns the condition analyzers for the object
+// based on the table.
+func (t KindConditionTable) kindConditionAnalyzers(obj *status.Object) []ConditionAnalyzer {
+	conds := t[obj.GroupVersionKind().GroupKind()]
+	ret := make([]ConditionAnalyzer, 0, len(conds))
+	for _, c := range conds {
+		a, err := c.Analyzer()
+		if err != nil {
+			klog.V(2).ErrorS(err, "Skipping invalid kind condition", "object", obj)
+			continue
+		}
+		ret = append(ret, a)
+	}
+	return ret
+}
@@ -0,0 +1,75 @@
+package analyze_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/analyze"
+	"github.com/rhobs/kube-health/pkg/eval"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestDefaultKindConditions(t *testing.T) {
+	for gk, conds := range analyze.DefaultKindConditions {
+		for _, c := range conds {
+			_, err := c.Analyzer()
+			assert.NoError(t, err, "%s: %s", gk, c.Type)
+		}
+	}
+
+	var os status.ObjectStatus
+	e, _, objs := test.TestEvaluator("kindconditions.yaml")
+
+	os = e.Eval(t.Context(), objs[0])
+	assert.True(t, os.Status().Progressing)
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `Ready DoesNotExist Issuing certificate as Secret does not exist (Error)
+Issuing DoesNotExist Issuing certificate as Secret does not exist (Unknown)`, os.Conditions)
+
+	os = e.Eval(t.Context(), objs[1])
+	assert.Equal(t, status.Warning, os.Status().Result)
+	test.AssertConditions(t, `DisruptionAllowed InsufficientPods  (Warning)`, os.Conditions)
+
+	// Not in the table: the common conditions analyzer doesn't know Healthy.
+	os = e.Eval(t.Context(), objs[2])
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `Healthy AsExpected All good (Unknown)
+Degraded Lagging One replica is lagging behind (Error)`, os.Conditions)
+}
+
+func TestLoadKindConditions(t *testing.T) {
+	table, err := analyze.LoadKindConditions(strings.NewReader(`
+kinds:
+- kind: Widget.example.com
+  conditions:
+  - type: Healthy
+  - type: Degraded
+    polarity: reversed
+    severity: warning
+`))
+	require.NoError(t, err)
+
+	loader := eval.NewFakeLoader()
+	objs := test.RegisterTestData(loader, "kindconditions.yaml")
+	e := eval.NewEvaluator(analyze.AnalyzersWithPlugins(func(r *analyze.AnalyzerRegister) {
+		r.RegisterKindConditions(table)
+	}), loader)
+
+	os := e.Eval(t.Context(), objs[2])
+	assert.Equal(t, status.Warning, os.Status().Result)
+	test.AssertConditions(t, `Healthy AsExpected All good (Ok)
+Degraded Lagging One replica is lagging behind (Warning)`, os.Conditions)
+
+	_, err = analyze.LoadKindConditions(strings.NewReader(`
+kinds:
+- kind: Widget.example.com
+  conditions:
+  - type: Healthy
+    polarity: upside-down
+`))
+	assert.ErrorContains(t, err, `kind Widget.example.com: unknown polarity "upside-down" of condition Healthy`)
+}
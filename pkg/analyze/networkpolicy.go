@@ -0,0 +1,62 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/rhobs/kube-health/pkg/eval"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+var (
+	gkNetworkPolicy = networkingv1.SchemeGroupVersion.WithKind("NetworkPolicy").GroupKind()
+)
+
+// networkPolicyCoverageCondition checks whether the pods of the workload
+// (based on spec.template.metadata.labels) are selected by a NetworkPolicy.
+// It returns nil when the namespace doesn't use NetworkPolicies at all.
+func networkPolicyCoverageCondition(ctx context.Context, e *eval.Evaluator, obj *status.Object) (*status.ConditionStatus, error) {
+	policies, err := e.Load(ctx, eval.KindQuerySpec{
+		GK: eval.NewGroupKindMatcherSingle(gkNetworkPolicy),
+		Ns: obj.GetNamespace(),
+	})
+	if err != nil || len(policies) == 0 {
+		return nil, err
+	}
+
+	podLabels, _, err := unstructured.NestedStringMap(obj.Unstructured.Object, "spec", "template", "metadata", "labels")
+	if err != nil {
+		return nil, err
+	}
+
+	var selecting []string
+	for _, policyObj := range policies {
+		var policy networkingv1.NetworkPolicy
+		if err := FromUnstructured(policyObj.Unstructured.Object, &policy); err != nil {
+			return nil, err
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&policy.Spec.PodSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid podSelector of NetworkPolicy %s: %w", policy.Name, err)
+		}
+		if selector.Matches(labels.Set(podLabels)) {
+			selecting = append(selecting, policy.Name)
+		}
+	}
+
+	var cond status.ConditionStatus
+	if len(selecting) > 0 {
+		cond = SyntheticConditionOk("NetworkPolicyCoverage",
+			"Pods selected by NetworkPolicy: "+strings.Join(selecting, ", "))
+	} else {
+		cond = SyntheticConditionWarning("NetworkPolicyCoverage", "NotCovered",
+			fmt.Sprintf("Pods not selected by any of the %d NetworkPolicies in the namespace", len(policies)))
+	}
+	return &cond, nil
+}
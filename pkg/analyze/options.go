@@ -0,0 +1,121 @@
+package analyze
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/rhobs/kube-health/pkg/eval"
+)
+
+const (
+	// DefaultProgressingTimeout is the time since the last termination of
+	// a waiting container after which the container is no longer considered
+	// progressing.
+	DefaultProgressingTimeout = 3 * time.Minute
+
+	// DefaultRestartsThreshold is the number of restarts of a running container
+	// from which it's reported with a warning.
+	DefaultRestartsThreshold int32 = 5
+
+	// DefaultLogFilterLines is the number of the last matching log lines
+	// shown when filtering the logs.
+	DefaultLogFilterLines = 5
+
+	// DefaultCronJobHistoryLimit is the number of the most recently finished
+	// jobs summarized in the history of the CronJob.
+	DefaultCronJobHistoryLimit = 5
+
+	// DefaultCertificateRenewalWindow is how long before the expiry
+	// the certificates are reported with a warning.
+	DefaultCertificateRenewalWindow = 7 * 24 * time.Hour
+
+	// DefaultCSRApprovalTimeout is the age after which a CertificateSigningRequest
+	// still waiting for the approval is reported with a warning.
+	DefaultCSRApprovalTimeout = 10 * time.Minute
+)
+
+// Options configures the built-in analyzers. The analyzers read them from
+// the register they are built from, so that evaluators built from different
+// registers don't affect each other. Use DefaultOptions as the starting point,
+// as the zero values don't match the defaults.
+type Options struct {
+	// ProgressingTimeout is the time since the last termination of a waiting
+	// container after which the container is no longer considered progressing.
+	ProgressingTimeout time.Duration
+	// RestartsThreshold is the number of restarts of a running container
+	// from which it's reported with a warning. Zero disables the check.
+	RestartsThreshold int32
+	// LogFilter limits the container logs shown in the conditions to the lines
+	// matching the expression. Nil shows the logs unfiltered.
+	LogFilter *regexp.Regexp
+	// LogFilterLines is the number of the last matching lines shown when
+	// LogFilter is set. Zero shows all the matching lines.
+	LogFilterLines int
+	// LogTailLines is the number of lines loaded from the end of the logs
+	// of the failing containers.
+	LogTailLines int64
+	// PreviousLogs makes the analyzer show the logs of the previous instance
+	// of the restarted containers, as the current logs are often empty right
+	// after a restart.
+	PreviousLogs bool
+	// IgnoreReplacedEvictedPods makes the controller analyzers skip the evicted
+	// pods once the other pods cover the desired replicas: the evicted pods
+	// linger until garbage-collected, even though they were already replaced.
+	IgnoreReplacedEvictedPods bool
+	// CronJobHistoryLimit is the number of the most recently finished jobs
+	// summarized in the history of the CronJob.
+	CronJobHistoryLimit int
+	// NetworkPolicyCoverage enables the NetworkPolicyCoverage check of
+	// the workloads: a warning for the pods not selected by any NetworkPolicy
+	// in a namespace using NetworkPolicies. It's a security-posture hint rather
+	// than a liveness check, hence disabled by default.
+	NetworkPolicyCoverage bool
+	// ReferenceChecks enables checking that the ConfigMaps and Secrets
+	// referenced by the pods (and the pod templates of the Deployments) exist.
+	// It loads the ConfigMaps and Secrets of the namespace, hence disabled
+	// by default. The referenced objects are not added to the tree either way.
+	ReferenceChecks bool
+	// WebhookChecks enables checking the Services called by the admission
+	// webhooks. It loads the Services and their endpoints, hence disabled
+	// by default.
+	WebhookChecks bool
+	// CertificateRenewalWindow is how long before the expiry the cert-manager
+	// certificates are reported with a warning. cert-manager renews them well
+	// ahead (a third of the duration before the expiry by default):
+	// a certificate this close to the expiry is likely failing to renew.
+	CertificateRenewalWindow time.Duration
+	// CSRApprovalTimeout is the age after which a CertificateSigningRequest
+	// still waiting for the approval is reported with a warning. The node
+	// bootstrap requests are usually approved within seconds: a request
+	// pending longer suggests the approver is down.
+	CSRApprovalTimeout time.Duration
+}
+
+// DefaultOptions returns the options used unless configured otherwise.
+func DefaultOptions() Options {
+	return Options{
+		ProgressingTimeout:        DefaultProgressingTimeout,
+		RestartsThreshold:         DefaultRestartsThreshold,
+		LogFilterLines:            DefaultLogFilterLines,
+		LogTailLines:              eval.DefaultLogTailLines,
+		IgnoreReplacedEvictedPods: true,
+		CronJobHistoryLimit:       DefaultCronJobHistoryLimit,
+		CertificateRenewalWindow:  DefaultCertificateRenewalWindow,
+		CSRApprovalTimeout:        DefaultCSRApprovalTimeout,
+	}
+}
+
+// WithOptions returns a plugin configuring the built-in analyzers.
+func WithOptions(o Options) Plugin {
+	return func(r *AnalyzerRegister) {
+		r.options = &o
+	}
+}
+
+// Options returns the options of the analyzers built from the register.
+func (r *AnalyzerRegister) Options() Options {
+	if r.options == nil {
+		return DefaultOptions()
+	}
+	return *r.options
+}
@@ -0,0 +1,103 @@
+package analyze
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// PhaseConditionType is the type of the synthetic condition describing
+// the status.phase of an object (see PhaseCondition).
+const PhaseConditionType = "Phase"
+
+// DefaultPhaseAnalyzer maps the common values of status.phase to the results.
+// The GenericAnalyzer uses it for the objects whose conditions don't tell
+// their health (or that have no conditions at all).
+var DefaultPhaseAnalyzer = PhaseAnalyzer{
+	OkPhases: NewStringMatchers("Running", "Active", "Bound", "Available", "Ready",
+		"Succeeded", "Complete", "Completed", "Healthy", "Established"),
+	WarningPhases: NewStringMatchers("Degraded", "Released"),
+	ErrorPhases:   NewStringMatchers("Failed", "Error", "Lost", "Unhealthy"),
+	ProgressingPhases: NewStringMatchers("Pending", "Creating", "Provisioning", "Initializing",
+		"Installing", "Updating", "Upgrading", "Deploying"),
+}
+
+// PhaseAnalyzer is a condition analyzer mapping the phase condition
+// (see PhaseCondition) to the result based on the phase value.
+// The phases not matched by any of the matchers are not analyzed.
+type PhaseAnalyzer struct {
+	OkPhases          []Matcher
+	WarningPhases     []Matcher
+	ErrorPhases       []Matcher
+	ProgressingPhases []Matcher
+}
+
+func (a PhaseAnalyzer) Analyze(cond *metav1.Condition) status.ConditionStatus {
+	if cond.Type != PhaseConditionType {
+		return ConditionStatusNoMatch
+	}
+
+	phase := string(cond.Status)
+	switch {
+	case matchAny(a.OkPhases, phase):
+		return ConditionStatusOk(cond)
+	case matchAny(a.WarningPhases, phase):
+		return ConditionStatusWarning(cond)
+	case matchAny(a.ErrorPhases, phase):
+		return ConditionStatusError(cond)
+	case matchAny(a.ProgressingPhases, phase):
+		return ConditionStatusProgressing(cond)
+	}
+	return ConditionStatusNoMatch
+}
+
+func matchAny(matchers []Matcher, s string) bool {
+	for _, m := range matchers {
+		if m.Match(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// PhaseCondition returns the status.phase of the object as a synthetic
+// condition of the PhaseConditionType, with the phase as its status. The reason,
+// message and lastTransitionTime are taken from the status too, if present.
+// It returns nil when the object has no phase.
+func PhaseCondition(obj *status.Object) *metav1.Condition {
+	statusData, _, _ := unstructured.NestedMap(obj.Unstructured.Object, "status")
+	phase, _, _ := unstructured.NestedString(statusData, "phase")
+	if phase == "" {
+		return nil
+	}
+
+	reason, _, _ := unstructured.NestedString(statusData, "reason")
+	message, _, _ := unstructured.NestedString(statusData, "message")
+	var lastTransitionTime time.Time
+	if ts, _, _ := unstructured.NestedString(statusData, "lastTransitionTime"); ts != "" {
+		lastTransitionTime, _ = time.Parse(time.RFC3339, ts)
+	}
+
+	cond := SyntheticCondition(PhaseConditionType, false, reason, message, lastTransitionTime)
+	cond.Status = metav1.ConditionStatus(phase)
+	return cond
+}
+
+// AnalyzeObjectPhase analyzes the status.phase of the object by the analyzers.
+// It returns nil when the object has no phase or the phase is not matched
+// by any of the analyzers.
+func AnalyzeObjectPhase(obj *status.Object, analyzers []ConditionAnalyzer) *status.ConditionStatus {
+	cond := PhaseCondition(obj)
+	if cond == nil {
+		return nil
+	}
+	for _, a := range analyzers {
+		if cs := a.Analyze(cond); cs != ConditionStatusNoMatch {
+			return &cs
+		}
+	}
+	return nil
+}
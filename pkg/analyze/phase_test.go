@@ -0,0 +1,47 @@
+package analyze_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/analyze"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestGenericAnalyzerPhase(t *testing.T) {
+	var os status.ObjectStatus
+	e, _, objs := test.TestEvaluator("phases.yaml")
+
+	os = e.Eval(t.Context(), objs[0])
+	assert.Equal(t, status.Ok, os.Status().Result)
+	test.AssertConditions(t, `Phase   (Ok)`, os.Conditions)
+
+	os = e.Eval(t.Context(), objs[1])
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `Phase BackendUnreachable Backend example.com is unreachable (Error)`, os.Conditions)
+	assert.Equal(t, "Failed", string(os.Conditions[0].Condition.Status))
+
+	os = e.Eval(t.Context(), objs[2])
+	assert.True(t, os.Status().Progressing)
+	test.AssertConditions(t, `Phase   (Unknown)`, os.Conditions)
+
+	// The conditions take precedence over the phase.
+	os = e.Eval(t.Context(), objs[3])
+	assert.Equal(t, status.Ok, os.Status().Result)
+	test.AssertConditions(t, `Ready AsExpected  (Ok)`, os.Conditions)
+
+	// The phases unknown to the mapping are not reported.
+	os = e.Eval(t.Context(), objs[4])
+	assert.Empty(t, os.Conditions)
+
+	defaultAnalyzer := analyze.DefaultPhaseAnalyzer
+	t.Cleanup(func() { analyze.DefaultPhaseAnalyzer = defaultAnalyzer })
+	analyze.DefaultPhaseAnalyzer.WarningPhases = analyze.NewRegexpMatchers("^frob")
+	e.Reset()
+
+	os = e.Eval(t.Context(), objs[4])
+	assert.Equal(t, status.Warning, os.Status().Result)
+	test.AssertConditions(t, `Phase   (Warning)`, os.Conditions)
+}
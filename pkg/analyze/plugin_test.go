@@ -0,0 +1,171 @@
+package analyze_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/analyze"
+	"github.com/rhobs/kube-health/pkg/eval"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+var (
+	gkMyResource = schema.GroupKind{Group: "mygroup.example.org", Kind: "MyResource"}
+	gkWidget     = schema.GroupKind{Group: "mygroup.example.org", Kind: "Widget"}
+)
+
+// myAnalyzer demonstrates an analyzer maintained outside of this repository.
+type myAnalyzer struct{}
+
+func (myAnalyzer) Supports(obj *status.Object) bool {
+	return obj.GroupVersionKind().GroupKind() == gkMyResource
+}
+
+func (myAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	myresult, found, err := unstructured.NestedString(obj.Unstructured.Object, "status", "myresult")
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+	if !found {
+		return status.UnknownStatus(obj)
+	}
+	if myresult != "ok" {
+		return analyze.AggregateResult(obj, nil, []status.ConditionStatus{
+			analyze.SyntheticConditionError("MyResultFailed", myresult, "MyResult is not ok")})
+	}
+	return status.OkStatus(obj, nil)
+}
+
+// myPlugin is the entrypoint the downstream project passes to kube-health.
+func myPlugin(r *analyze.AnalyzerRegister) {
+	r.RegisterSimple(myAnalyzer{})
+	r.RegisterIgnoredKinds(gkWidget)
+}
+
+func pluginTestObject(apiVersion, kind, name, uid string, owner string) unstructured.Unstructured {
+	obj := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "default",
+			"uid":       uid,
+		},
+		"status": map[string]interface{}{
+			"myresult": "failing",
+		},
+	}}
+	if owner != "" {
+		unstructured.SetNestedSlice(obj.Object, []interface{}{
+			map[string]interface{}{"uid": owner, "name": owner, "kind": "Gadget", "apiVersion": apiVersion},
+		}, "metadata", "ownerReferences")
+	}
+	return obj
+}
+
+func TestAnalyzersWithPlugins(t *testing.T) {
+	loader := eval.NewFakeLoader()
+	objs, err := loader.Register(
+		pluginTestObject("mygroup.example.org/v1", "MyResource", "mine", "uid-mine", ""),
+		pluginTestObject("mygroup.example.org/v1", "Gadget", "gadget", "uid-gadget", ""),
+		pluginTestObject("mygroup.example.org/v1", "Widget", "widget", "uid-widget", "uid-gadget"),
+	)
+	require.NoError(t, err)
+
+	e := eval.NewEvaluator(analyze.AnalyzersWithPlugins(myPlugin), loader)
+
+	os := e.Eval(t.Context(), objs[0])
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `MyResultFailed failing MyResult is not ok (Error)`, os.Conditions)
+
+	// The kinds ignored by the plugin are not expanded by the generic analyzer.
+	os = e.Eval(t.Context(), objs[1])
+	assert.Empty(t, os.SubStatuses)
+
+	// The global register stays untouched by the plugin.
+	e = eval.NewEvaluator(analyze.DefaultAnalyzers(), loader)
+
+	os = e.Eval(t.Context(), objs[0])
+	assert.Equal(t, status.Unknown, os.Status().Result)
+	assert.Empty(t, os.Conditions)
+
+	os = e.Eval(t.Context(), objs[1])
+	assert.Len(t, os.SubStatuses, 1)
+}
+
+func TestAnalyzersIgnoredKinds(t *testing.T) {
+	loader := eval.NewFakeLoader()
+	objs, err := loader.Register(
+		pluginTestObject("mygroup.example.org/v1", "Gadget", "gadget", "uid-gadget", ""),
+		pluginTestObject("mygroup.example.org/v1", "Widget", "widget", "uid-widget", "uid-gadget"),
+		pluginTestObject("v1", "ConfigMap", "config", "uid-config", "uid-gadget"),
+	)
+	require.NoError(t, err)
+
+	// ConfigMaps are ignored by default.
+	e := eval.NewEvaluator(analyze.DefaultAnalyzers(), loader)
+	os := e.Eval(t.Context(), objs[0])
+	require.Len(t, os.SubStatuses, 1)
+	assert.Equal(t, "widget", os.SubStatuses[0].Object.GetName())
+
+	e = eval.NewEvaluator(analyze.AnalyzersWithPlugins(
+		analyze.IgnoreKinds(gkWidget),
+		analyze.IncludeKinds(schema.GroupKind{Kind: "ConfigMap"})), loader)
+	os = e.Eval(t.Context(), objs[0])
+	require.Len(t, os.SubStatuses, 1)
+	assert.Equal(t, "config", os.SubStatuses[0].Object.GetName())
+
+	// The included kinds take precedence over the ignored ones.
+	r := analyze.NewRegister()
+	r.RegisterIncludedKinds(gkWidget)
+	r.RegisterIgnoredKinds(gkWidget, gkMyResource)
+	assert.False(t, r.IsIgnoredKind(gkWidget))
+	assert.Equal(t, []schema.GroupKind{gkMyResource}, r.IgnoredKinds())
+}
+
+func TestPluginOptions(t *testing.T) {
+	r := analyze.NewRegister()
+	assert.Equal(t, analyze.DefaultOptions(), r.Options())
+
+	opts := analyze.DefaultOptions()
+	opts.ProgressingTimeout = time.Hour
+	analyze.WithOptions(opts)(r)
+	assert.Equal(t, time.Hour, r.Options().ProgressingTimeout)
+
+	// The options of an isolated register don't leak to the global one.
+	assert.Equal(t, analyze.DefaultProgressingTimeout, analyze.Register.Options().ProgressingTimeout)
+}
+
+func TestExplainAnalyzer(t *testing.T) {
+	e, _, objs := test.TestEvaluator("deployments.yaml")
+
+	x := e.ExplainAnalyzer(objs[0])
+	assert.Equal(t, "analyze.DeploymentAnalyzer", x.Chosen)
+	assert.Equal(t, []string{"analyze.DeploymentAnalyzer", "*analyze.GenericAnalyzer"}, x.Supporting)
+	assert.Contains(t, x.String(), "apps/Deployment default/dp1: analyze.DeploymentAnalyzer (analyzer ")
+	assert.Contains(t, x.String(), "; also supported by: *analyze.GenericAnalyzer)")
+
+	// The analyzers from plugins take precedence over the built-in ones.
+	loader := eval.NewFakeLoader()
+	objs, err := loader.Register(pluginTestObject("mygroup.example.org/v1", "MyResource", "mine", "uid-mine", ""))
+	require.NoError(t, err)
+
+	x = eval.NewEvaluator(analyze.AnalyzersWithPlugins(myPlugin), loader).ExplainAnalyzer(objs[0])
+	assert.Equal(t, "analyze_test.myAnalyzer", x.Chosen)
+	assert.Equal(t, 0, x.Position)
+
+	sb := &strings.Builder{}
+	e = eval.NewEvaluator(analyze.DefaultAnalyzers(), loader).WithAnalyzerDebug(sb)
+	e.Eval(t.Context(), objs[0])
+	e.Eval(t.Context(), objs[0])
+	assert.Equal(t, 1, strings.Count(sb.String(), "\n"), "explained once per object")
+	assert.Contains(t, sb.String(), "mygroup.example.org/MyResource default/mine: *analyze.GenericAnalyzer")
+}
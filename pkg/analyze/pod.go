@@ -2,24 +2,40 @@ package analyze
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
 
 	"github.com/rhobs/kube-health/pkg/eval"
 	"github.com/rhobs/kube-health/pkg/status"
 )
 
+const (
+	// DefaultRestartsWindow limits the restarts warning to the containers
+	// that were last terminated within the window.
+	DefaultRestartsWindow = time.Hour
+
+	// DefaultLogFilterTailLines is the number of lines loaded from the end
+	// of the logs to look for the lines matching Options.LogFilter.
+	DefaultLogFilterTailLines int64 = 200
+)
+
 var (
-	gkPod              = schema.GroupKind{Group: "", Kind: "Pod"}
-	progressingTimeout = 3 * time.Minute
+	gkPod = schema.GroupKind{Group: "", Kind: "Pod"}
 )
 
 type PodAnalyzer struct {
-	e *eval.Evaluator
+	e    *eval.Evaluator
+	opts Options
 }
 
 func (_ PodAnalyzer) Supports(obj *status.Object) bool {
@@ -39,6 +55,28 @@ func (a PodAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.Obj
 	}
 	conditions = append(conditions, podSyntheticConditions(&pod)...)
 
+	if a.opts.ReferenceChecks {
+		refConds, err := missingReferencesConditions(ctx, a.e, obj, "spec")
+		if err != nil {
+			klog.V(2).ErrorS(err, "Failed to check ConfigMap and Secret references for Pod", "object", obj)
+		}
+		conditions = append(conditions, refConds...)
+	}
+
+	if failsImagePull(&pod) {
+		pullConds, err := pullSecretsConditions(ctx, a.e, obj, &pod)
+		if err != nil {
+			klog.V(2).ErrorS(err, "Failed to check image pull secrets for Pod", "object", obj)
+		}
+		conditions = append(conditions, pullConds...)
+	}
+
+	if isEvicted(&pod) {
+		// The containers of an evicted pod are gone: their statuses
+		// would only repeat the eviction.
+		return AggregateResult(obj, nil, conditions)
+	}
+
 	// We treat the containers as sub-objects of the pod, even though technically
 	// they are just fields of the pod object. This makes it easier to report
 	// details of each container separately.
@@ -54,17 +92,71 @@ func podSyntheticConditions(pod *corev1.Pod) []status.ConditionStatus {
 	case corev1.PodSucceeded:
 		conditions = append(conditions, SyntheticConditionOk("Succeeded", ""))
 	case corev1.PodFailed:
-		conditions = append(conditions, SyntheticConditionError("Failed", "Failed", ""))
+		if isEvicted(pod) {
+			// The message tells the reason, e.g. the node running low on memory.
+			conditions = append(conditions, SyntheticConditionWarning("Evicted", pod.Status.Reason, pod.Status.Message))
+		} else {
+			conditions = append(conditions, SyntheticConditionError("Failed", "Failed", ""))
+		}
 	}
 
 	return conditions
 }
 
+func isEvicted(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodFailed && pod.Status.Reason == "Evicted"
+}
+
+// withoutReplacedEvictedPods drops the evicted pods from the statuses of
+// the pods of a controller, if the remaining pods cover the desired replicas.
+// Otherwise, the evicted pods are kept, as they explain the missing replicas.
+func withoutReplacedEvictedPods(pods []status.ObjectStatus, replicas int32) []status.ObjectStatus {
+	ret := slices.DeleteFunc(slices.Clone(pods), func(s status.ObjectStatus) bool {
+		phase, _, _ := unstructured.NestedString(s.Object.Unstructured.Object, "status", "phase")
+		reason, _, _ := unstructured.NestedString(s.Object.Unstructured.Object, "status", "reason")
+		return phase == string(corev1.PodFailed) && reason == "Evicted"
+	})
+	if int32(len(ret)) < replicas {
+		return pods
+	}
+	return ret
+}
+
+// specReplicas returns the desired replicas of a controller.
+func specReplicas(obj *status.Object) int32 {
+	replicas, found, _ := unstructured.NestedInt64(obj.Unstructured.Object, "spec", "replicas")
+	if !found {
+		// Controllers use 1 as default if not specified.
+		return 1
+	}
+	return int32(replicas)
+}
+
 func (a PodAnalyzer) analyzePodContainers(ctx context.Context, obj *status.Object, pod *corev1.Pod) []status.ObjectStatus {
 	var ret []status.ObjectStatus
 
+	// Init containers go first, as they run before the regular ones.
+	for _, cs := range pod.Status.InitContainerStatuses {
+		containerObjStatus := a.analyzeContainer(ctx, obj, "InitContainer", cs,
+			findContainer(pod.Spec.InitContainers, cs.Name))
+		if containerObjStatus.Object != nil {
+			ret = append(ret, containerObjStatus)
+		}
+	}
+
 	for _, cs := range pod.Status.ContainerStatuses {
-		containerObjStatus := a.analyzeContainer(ctx, obj, cs)
+		containerObjStatus := a.analyzeContainer(ctx, obj, "Container", cs,
+			findContainer(pod.Spec.Containers, cs.Name))
+		if containerObjStatus.Object != nil {
+			ret = append(ret, containerObjStatus)
+		}
+	}
+
+	// The ephemeral containers are added to a running pod for debugging
+	// (e.g. by kubectl debug). They are listed last, as they don't take part
+	// in serving the pod.
+	for _, cs := range pod.Status.EphemeralContainerStatuses {
+		containerObjStatus := a.analyzeContainer(ctx, obj, "EphemeralContainer", cs, nil)
 		if containerObjStatus.Object != nil {
 			ret = append(ret, containerObjStatus)
 		}
@@ -73,12 +165,23 @@ func (a PodAnalyzer) analyzePodContainers(ctx context.Context, obj *status.Objec
 	return ret
 }
 
+func findContainer(containers []corev1.Container, name string) *corev1.Container {
+	for i := range containers {
+		if containers[i].Name == name {
+			return &containers[i]
+		}
+	}
+	return nil
+}
+
 // analyzeContainer analyzes the status of a container, treating it as a separate
-// sub-object of the pod.
-func (a PodAnalyzer) analyzeContainer(ctx context.Context, obj *status.Object, cs corev1.ContainerStatus) status.ObjectStatus {
+// sub-object of the pod. The kind distinguishes the regular containers
+// from the init and ephemeral ones. The spec might be nil if not found in the pod.
+func (a PodAnalyzer) analyzeContainer(ctx context.Context, obj *status.Object, kind string,
+	cs corev1.ContainerStatus, spec *corev1.Container) status.ObjectStatus {
 	containerObj := &status.Object{
 		TypeMeta: metav1.TypeMeta{
-			Kind: "Container",
+			Kind: kind,
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Name: cs.Name,
@@ -94,66 +197,204 @@ func (a PodAnalyzer) analyzeContainer(ctx context.Context, obj *status.Object, c
 			lastTransitionTime = lastState.FinishedAt.Time
 		}
 
-		if !lastTransitionTime.IsZero() && time.Since(lastTransitionTime) > progressingTimeout {
+		if !lastTransitionTime.IsZero() && time.Since(lastTransitionTime) > a.opts.ProgressingTimeout {
 			progressing = false
 		}
 		reason := cs.State.Waiting.Reason
-		cond = SyntheticConditionError("Waiting", reason, "")
-		cond.LastTransitionTime = metav1.NewTime(lastTransitionTime)
-		cond.CondStatus.Progressing = progressing
+		if reason == "PodInitializing" {
+			// Waiting for the init containers: their status tells the problems.
+			cond = SyntheticConditionProgressing("Waiting", reason, "")
+		} else {
+			cond = SyntheticConditionError("Waiting", reason, "")
+			cond.LastTransitionTime = metav1.NewTime(lastTransitionTime)
+			cond.CondStatus.Progressing = progressing
+		}
 	}
 
+	// The regular init containers run to completion and never become ready,
+	// unlike the sidecars (restartPolicy: Always) running along the containers.
+	initContainer := kind == "InitContainer" && !isSidecar(spec)
+	// Both the init containers and the ones waiting for them are not ready
+	// yet while the pod is initializing.
+	initializing := initContainer ||
+		(cs.State.Waiting != nil && cs.State.Waiting.Reason == "PodInitializing")
+
 	if cs.State.Running != nil {
-		cond = SyntheticConditionOk("Running", "")
+		if initContainer {
+			cond = SyntheticConditionProgressing("Running", "Initializing", "")
+		} else {
+			cond = SyntheticConditionOk("Running", "")
+		}
 		cond.LastTransitionTime = cs.State.Running.StartedAt
 	}
 
-	if !cs.Ready {
+	// The ephemeral containers have no probes: they are never ready.
+	if !cs.Ready && kind != "EphemeralContainer" && !initializing {
 		cond = SyntheticConditionError("Ready", "NotReady", "")
+		if cs.State.Running != nil {
+			// Running but not ready: the probes are failing, not the container.
+			cond.Message = probeFailureMessage(cs, spec)
+		}
 	}
 
-	if cs.State.Terminated != nil {
-		reason := cs.State.Terminated.Reason
-		cond = SyntheticConditionError("Terminated", reason, "")
+	if terminated := cs.State.Terminated; terminated != nil {
+		if kind == "InitContainer" && terminated.ExitCode == 0 {
+			// Init containers are expected to finish before the pod starts.
+			cond = ConditionStatusOk(SyntheticCondition("Terminated", true,
+				terminated.Reason, "", terminated.FinishedAt.Time))
+		} else if kind == "EphemeralContainer" {
+			// A finished debugging session, whatever its exit code,
+			// doesn't affect the health of the pod.
+			cond = ConditionStatusOk(SyntheticCondition("Terminated", true, terminated.Reason,
+				fmt.Sprintf("Exited with code %d", terminated.ExitCode), terminated.FinishedAt.Time))
+		} else {
+			cond = SyntheticConditionError("Terminated", terminated.Reason, "")
+		}
 	}
 
 	if (cond == status.ConditionStatus{}) {
 		return status.ObjectStatus{}
 	}
 
+	conditions = append(conditions, cond)
+
+	if cs.State.Running != nil {
+		if restartsCond := a.restartsCondition(cs); restartsCond != nil {
+			conditions = append(conditions, *restartsCond)
+		}
+	}
+
+	containerStatus := AggregateResult(containerObj, nil, conditions)
 	if cond.Status().Result > status.Ok {
-		a.expandWithLogs(ctx, obj, cs.Name, &cond)
+		containerStatus.Logs = a.containerLogs(ctx, obj, cs)
 	}
+	return containerStatus
+}
 
-	conditions = append(conditions, cond)
+// isSidecar returns true for the init containers kept running along
+// the regular ones. The spec might be nil if not found in the pod.
+func isSidecar(spec *corev1.Container) bool {
+	return spec != nil && spec.RestartPolicy != nil &&
+		*spec.RestartPolicy == corev1.ContainerRestartPolicyAlways
+}
 
-	return AggregateResult(containerObj, nil, conditions)
+// probeFailureMessage describes the probe keeping the running container
+// from being ready. It returns empty string when the container has no probe.
+func probeFailureMessage(cs corev1.ContainerStatus, spec *corev1.Container) string {
+	if spec == nil {
+		return ""
+	}
+	// The readiness probe runs only after the startup one succeeds.
+	if spec.StartupProbe != nil && cs.Started != nil && !*cs.Started {
+		return "Startup probe failing: " + formatProbe(spec.StartupProbe)
+	}
+	if spec.ReadinessProbe != nil {
+		return "Readiness probe failing: " + formatProbe(spec.ReadinessProbe)
+	}
+	return ""
 }
 
-// expandWithLogs loads container logs and appends them to the condition message.
-func (a PodAnalyzer) expandWithLogs(ctx context.Context, obj *status.Object, container string, cond *status.ConditionStatus) {
-	logs, err := a.loadContainerLogs(ctx, obj, container)
-	if err != nil {
-		logs = "Error loading logs: " + err.Error() + "\n"
+// formatProbe summarizes the probe handler, similarly to kubectl describe.
+func formatProbe(probe *corev1.Probe) string {
+	switch {
+	case probe.HTTPGet != nil:
+		scheme := strings.ToLower(string(probe.HTTPGet.Scheme))
+		if scheme == "" {
+			scheme = "http"
+		}
+		return fmt.Sprintf("http-get %s://%s:%s%s", scheme, probe.HTTPGet.Host,
+			probe.HTTPGet.Port.String(), probe.HTTPGet.Path)
+	case probe.TCPSocket != nil:
+		return fmt.Sprintf("tcp-socket %s:%s", probe.TCPSocket.Host, probe.TCPSocket.Port.String())
+	case probe.GRPC != nil:
+		return fmt.Sprintf("grpc :%d", probe.GRPC.Port)
+	case probe.Exec != nil:
+		return fmt.Sprintf("exec %v", probe.Exec.Command)
+	default:
+		return "unknown"
 	}
+}
 
-	if logs == "" {
-		return
+// restartsCondition reports a running container that was restarted too many
+// times recently: it's likely flapping, even though it looks healthy now.
+func (a PodAnalyzer) restartsCondition(cs corev1.ContainerStatus) *status.ConditionStatus {
+	threshold := a.opts.RestartsThreshold
+	if threshold <= 0 || cs.RestartCount < threshold {
+		return nil
 	}
 
-	if cond.Message != "" {
-		cond.Message = "\n"
+	// Without the last termination, we can't tell when the restarts happened.
+	lastState := cs.LastTerminationState.Terminated
+	if lastState == nil {
+		return nil
+	}
+	if !lastState.FinishedAt.IsZero() && time.Since(lastState.FinishedAt.Time) > DefaultRestartsWindow {
+		return nil
 	}
 
-	cond.Message += "Logs:\n"
-	cond.Message += logs
+	cond := ConditionStatusWarning(&metav1.Condition{
+		Type:               "Restarts",
+		Status:             metav1.ConditionStatus(strconv.Itoa(int(cs.RestartCount))),
+		Reason:             lastState.Reason,
+		Message:            fmt.Sprintf("Last terminated with exit code %d", lastState.ExitCode),
+		LastTransitionTime: lastState.FinishedAt,
+	})
+	return &cond
 }
 
-func (a PodAnalyzer) loadContainerLogs(ctx context.Context, obj *status.Object, container string) (string, error) {
-	logobjs, err := a.e.Load(ctx, eval.PodLogQuerySpec{
+// containerLogs loads the tail of the container logs, or returns nil if
+// there are none. When Options.LogFilter is set, only the matching lines
+// are included. The logs of the previous instance are used for the containers
+// that crashed recently (or for all the restarted ones with Options.PreviousLogs),
+// falling back to the current logs when the previous ones are not available.
+func (a PodAnalyzer) containerLogs(ctx context.Context, obj *status.Object, cs corev1.ContainerStatus) *status.Logs {
+	previous := cs.RestartCount > 0 && cs.LastTerminationState.Terminated != nil &&
+		(a.opts.PreviousLogs || crashedRecently(cs))
+
+	var logs string
+	var err error
+	if previous {
+		logs, err = a.loadContainerLogs(ctx, obj, cs.Name, true)
+		if err != nil || logs == "" {
+			previous = false
+		}
+	}
+	if !previous {
+		logs, err = a.loadContainerLogs(ctx, obj, cs.Name, false)
+	}
+
+	if err != nil {
+		return &status.Logs{Err: err}
+	}
+	if logs == "" {
+		return nil
+	}
+	return &status.Logs{Previous: previous, Text: logs}
+}
+
+// crashedRecently tells whether the container is waiting for a restart after
+// failing within DefaultRestartsWindow, e.g. in CrashLoopBackOff. Its current
+// instance has no logs yet: the useful output is in the previous one.
+func crashedRecently(cs corev1.ContainerStatus) bool {
+	lastState := cs.LastTerminationState.Terminated
+	if cs.State.Waiting == nil || lastState == nil || lastState.ExitCode == 0 {
+		return false
+	}
+	return lastState.FinishedAt.IsZero() || time.Since(lastState.FinishedAt.Time) <= DefaultRestartsWindow
+}
+
+func (a PodAnalyzer) loadContainerLogs(ctx context.Context, obj *status.Object, container string, previous bool) (string, error) {
+	qs := eval.PodLogQuerySpec{
 		Object:    obj,
 		Container: container,
-	})
+		TailLines: a.opts.LogTailLines,
+		Previous:  previous,
+	}
+	if a.opts.LogFilter != nil {
+		// Look further back in the logs for the interesting lines.
+		qs.TailLines = DefaultLogFilterTailLines
+	}
+	logobjs, err := a.e.Load(ctx, qs)
 	if err != nil {
 		return "", err
 	}
@@ -163,11 +404,32 @@ func (a PodAnalyzer) loadContainerLogs(ctx context.Context, obj *status.Object,
 	}
 
 	logs, _, _ := unstructured.NestedString(logobjs[0].Unstructured.Object, "log")
+	if a.opts.LogFilter != nil {
+		logs = filterLogs(logs, a.opts.LogFilter, a.opts.LogFilterLines)
+	}
 	return logs, nil
 }
 
+// filterLogs keeps the last n lines matching the expression, or all
+// the matching lines if n is not positive.
+func filterLogs(logs string, re *regexp.Regexp, n int) string {
+	var matching []string
+	for _, line := range strings.Split(strings.TrimSuffix(logs, "\n"), "\n") {
+		if re.MatchString(line) {
+			matching = append(matching, line)
+		}
+	}
+	if len(matching) == 0 {
+		return ""
+	}
+	if n > 0 && len(matching) > n {
+		matching = matching[len(matching)-n:]
+	}
+	return strings.Join(matching, "\n") + "\n"
+}
+
 func init() {
-	Register.Register(func(e *eval.Evaluator) eval.Analyzer {
-		return PodAnalyzer{e: e}
+	Register.RegisterAware(func(e *eval.Evaluator, r *AnalyzerRegister) eval.Analyzer {
+		return PodAnalyzer{e: e, opts: r.Options()}
 	})
 }
@@ -1,12 +1,19 @@
 package analyze_test
 
 import (
+	"errors"
+	"regexp"
 	"testing"
 
 	"github.com/rhobs/kube-health/pkg/status"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/analyze"
+	"github.com/rhobs/kube-health/pkg/eval"
 )
 
 func TestPodAnalyzer(t *testing.T) {
@@ -28,9 +35,208 @@ Ready ContainersNotReady containers with unready status: [p2c] (Error)
 ContainersReady ContainersNotReady containers with unready status: [p2c] (Unknown)
 PodScheduled   (Unknown)`, os.Conditions)
 
-	test.AssertConditions(t, `Ready NotReady Logs:
-Line 1
-Line 2
-Line 3
- (Error)`, os.SubStatuses[0].Conditions)
+	test.AssertConditions(t, `Ready NotReady  (Error)`, os.SubStatuses[0].Conditions)
+	assert.Equal(t, &status.Logs{Text: "Line 1\nLine 2\nLine 3\n"}, os.SubStatuses[0].Logs)
+}
+
+func TestPodAnalyzerInitContainers(t *testing.T) {
+	e, _, objs := test.TestEvaluator("pods.yaml")
+
+	os := e.Eval(t.Context(), objs[5])
+	assert.Equal(t, status.Error, os.Status().Result)
+
+	require.Len(t, os.SubStatuses, 3)
+	assert.Equal(t, "InitContainer", os.SubStatuses[0].Object.Kind)
+	assert.Equal(t, "ic1", os.SubStatuses[0].Object.Name)
+	assert.Equal(t, status.Ok, os.SubStatuses[0].Status().Result)
+	test.AssertConditions(t, `Terminated Completed  (Ok)`, os.SubStatuses[0].Conditions)
+
+	assert.Equal(t, "InitContainer", os.SubStatuses[1].Object.Kind)
+	assert.Equal(t, status.Error, os.SubStatuses[1].Status().Result)
+
+	assert.Equal(t, "Container", os.SubStatuses[2].Object.Kind)
+	assert.Equal(t, "c1", os.SubStatuses[2].Object.Name)
+}
+
+func TestPodAnalyzerRunningInitContainer(t *testing.T) {
+	e, _, objs := test.TestEvaluator("pods.yaml")
+
+	// The pod is still initializing: the init container is not expected
+	// to become ready, unlike the sidecar.
+	os := e.Eval(t.Context(), objs[13])
+	assert.Equal(t, status.Ok, os.Status().Result)
+	assert.True(t, os.Status().Progressing)
+
+	require.Len(t, os.SubStatuses, 3)
+	assert.Equal(t, "proxy", os.SubStatuses[0].Object.Name)
+	test.AssertConditions(t, `Running   (Ok)`, os.SubStatuses[0].Conditions)
+
+	assert.Equal(t, "migrate", os.SubStatuses[1].Object.Name)
+	assert.True(t, os.SubStatuses[1].Status().Progressing)
+	test.AssertConditions(t, `Running Initializing  (Unknown)`, os.SubStatuses[1].Conditions)
+
+	assert.Equal(t, "c1", os.SubStatuses[2].Object.Name)
+	test.AssertConditions(t, `Waiting PodInitializing  (Unknown)`, os.SubStatuses[2].Conditions)
+}
+
+func TestPodAnalyzerRestarts(t *testing.T) {
+	e, _, objs := test.TestEvaluator("pods.yaml")
+
+	os := e.Eval(t.Context(), objs[6])
+	assert.Equal(t, status.Warning, os.Status().Result)
+
+	require.Len(t, os.SubStatuses, 2)
+	test.AssertConditions(t, `Running   (Ok)
+Restarts OOMKilled Last terminated with exit code 137 (Warning)`, os.SubStatuses[0].Conditions)
+
+	// The last restart happened out of the observed window.
+	test.AssertConditions(t, `Running   (Ok)`, os.SubStatuses[1].Conditions)
+
+	opts := analyze.DefaultOptions()
+	opts.RestartsThreshold = 0
+	e, _, objs = test.TestEvaluatorWithOptions(opts, "pods.yaml")
+
+	os = e.Eval(t.Context(), objs[6])
+	assert.Equal(t, status.Ok, os.Status().Result)
+	test.AssertConditions(t, `Running   (Ok)`, os.SubStatuses[0].Conditions)
+}
+
+func TestPodAnalyzerLogFilter(t *testing.T) {
+	opts := analyze.DefaultOptions()
+	opts.LogFilter = regexp.MustCompile(`(?i)error|fatal`)
+	opts.LogFilterLines = 2
+
+	e, l, objs := test.TestEvaluatorWithOptions(opts, "pods.yaml")
+	l.RegisterPodLogs("default", "p2", "p2c", `Starting
+ERROR first failure
+Retrying
+error: second failure
+Still retrying
+FATAL giving up
+Exiting
+`)
+
+	os := e.Eval(t.Context(), objs[1])
+	test.AssertConditions(t, `Ready NotReady  (Error)`, os.SubStatuses[0].Conditions)
+	assert.Equal(t, &status.Logs{Text: "error: second failure\nFATAL giving up\n"}, os.SubStatuses[0].Logs)
+}
+
+func TestPodAnalyzerProbeFailure(t *testing.T) {
+	e, l, objs := test.TestEvaluator("pods.yaml")
+	l.RegisterPodLogs("default", "p8", "c1", "GET /healthz 503\n")
+
+	os := e.Eval(t.Context(), objs[7])
+	assert.Equal(t, status.Error, os.Status().Result)
+
+	require.Len(t, os.SubStatuses, 1)
+	test.AssertConditions(t, `Ready NotReady Readiness probe failing: http-get http://:8080/healthz (Error)`, os.SubStatuses[0].Conditions)
+	assert.Equal(t, &status.Logs{Text: "GET /healthz 503\n"}, os.SubStatuses[0].Logs)
+}
+
+func TestPodAnalyzerPreviousLogs(t *testing.T) {
+	e, l, objs := test.TestEvaluator("pods.yaml")
+	l.RegisterPodLogs("default", "p9", "c1", "")
+	l.RegisterPreviousPodLogs("default", "p9", "c1", "panic: missing config\n")
+
+	os := e.Eval(t.Context(), objs[8])
+	test.AssertConditions(t, `Ready NotReady  (Error)`, os.SubStatuses[0].Conditions)
+
+	opts := analyze.DefaultOptions()
+	opts.PreviousLogs = true
+	e = eval.NewEvaluator(analyze.AnalyzersWithPlugins(analyze.WithOptions(opts)), l)
+
+	os = e.Eval(t.Context(), objs[8])
+	test.AssertConditions(t, `Ready NotReady  (Error)`, os.SubStatuses[0].Conditions)
+	assert.Equal(t, &status.Logs{Previous: true, Text: "panic: missing config\n"}, os.SubStatuses[0].Logs)
+}
+
+func TestPodAnalyzerLogLines(t *testing.T) {
+	e, l, objs := test.TestEvaluator("pods.yaml")
+	l.RegisterPodLogs("default", "p2", "p2c", "Line 1\nLine 2\nLine 3\nLine 4\nLine 5\nLine 6\n")
+
+	os := e.Eval(t.Context(), objs[1])
+	test.AssertConditions(t, `Ready NotReady  (Error)`, os.SubStatuses[0].Conditions)
+	assert.Equal(t, &status.Logs{Text: "Line 2\nLine 3\nLine 4\nLine 5\nLine 6\n"}, os.SubStatuses[0].Logs)
+
+	opts := analyze.DefaultOptions()
+	opts.LogTailLines = 2
+	e = eval.NewEvaluator(analyze.AnalyzersWithPlugins(analyze.WithOptions(opts)), l)
+
+	os = e.Eval(t.Context(), objs[1])
+	test.AssertConditions(t, `Ready NotReady  (Error)`, os.SubStatuses[0].Conditions)
+	assert.Equal(t, &status.Logs{Text: "Line 5\nLine 6\n"}, os.SubStatuses[0].Logs)
+}
+
+func TestPodAnalyzerEvicted(t *testing.T) {
+	e, _, objs := test.TestEvaluator("pods.yaml")
+
+	os := e.Eval(t.Context(), objs[9])
+	assert.Equal(t, status.Warning, os.Status().Result)
+	assert.Empty(t, os.SubStatuses)
+	test.AssertConditions(t, `Evicted Evicted The node was low on resource: memory. Threshold quantity: 100Mi, available: 80Mi. (Warning)`,
+		os.Conditions)
+}
+
+func TestPodAnalyzerCrashLoopLogs(t *testing.T) {
+	e, l, objs := test.TestEvaluator("pods.yaml")
+	l.RegisterPodLogs("default", "p11", "c1", "")
+	l.RegisterPreviousPodLogs("default", "p11", "c1", "panic: invalid flag\n")
+
+	os := e.Eval(t.Context(), objs[11])
+	test.AssertConditions(t, `Ready NotReady  (Error)`, os.SubStatuses[0].Conditions)
+	assert.Equal(t, &status.Logs{Previous: true, Text: "panic: invalid flag\n"}, os.SubStatuses[0].Logs)
+
+	// The previous logs are not available: fall back to the current ones.
+	l.RegisterPodLogs("default", "p11", "c1", "starting\n")
+	l.RegisterPreviousPodLogs("default", "p11", "c1", "")
+
+	e.Reset()
+	os = e.Eval(t.Context(), objs[11])
+	test.AssertConditions(t, `Ready NotReady  (Error)`, os.SubStatuses[0].Conditions)
+	assert.Equal(t, &status.Logs{Text: "starting\n"}, os.SubStatuses[0].Logs)
+}
+
+func TestPodAnalyzerEphemeralContainers(t *testing.T) {
+	e, _, objs := test.TestEvaluator("pods.yaml")
+
+	// The debugging sessions don't affect the health of the pod.
+	os := e.Eval(t.Context(), objs[12])
+	assert.Equal(t, status.Ok, os.Status().Result)
+
+	require.Len(t, os.SubStatuses, 3)
+	assert.Equal(t, "Container", os.SubStatuses[0].Object.Kind)
+
+	assert.Equal(t, "EphemeralContainer", os.SubStatuses[1].Object.Kind)
+	assert.Equal(t, "debugger-1", os.SubStatuses[1].Object.Name)
+	test.AssertConditions(t, `Terminated Error Exited with code 130 (Ok)`, os.SubStatuses[1].Conditions)
+
+	assert.Equal(t, "EphemeralContainer", os.SubStatuses[2].Object.Kind)
+	test.AssertConditions(t, `Running   (Ok)`, os.SubStatuses[2].Conditions)
+}
+
+func TestPodAnalyzerPullSecrets(t *testing.T) {
+	e, _, objs := test.TestEvaluator("pullsecrets.yaml")
+
+	os := e.Eval(t.Context(), objs[0])
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `
+MissingPullSecret SecretNotFound Image pull secret registry-missing not found (Error)
+MissingPullSecret InvalidSecretType Image pull secret registry-opaque has type Opaque, expected kubernetes.io/dockerconfigjson (Error)`,
+		os.Conditions)
+
+	// The secrets are checked only on the image pull failures.
+	os = e.Eval(t.Context(), objs[1])
+	assert.Equal(t, status.Ok, os.Status().Result)
+	assert.Empty(t, os.Conditions)
+
+	// Not allowed to get the Secrets: the missing ones can't be told.
+	e, l, objs := test.TestEvaluator("pullsecrets.yaml")
+	l.RegisterUnavailable(schema.GroupResource{Resource: "secrets"},
+		apierrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "", errors.New("denied")))
+	os = e.Eval(t.Context(), objs[0])
+	test.AssertConditions(t, `
+MissingPullSecret SecretUnavailable Can't check image pull secret registry-missing (Unknown)
+MissingPullSecret SecretUnavailable Can't check image pull secret registry-opaque (Unknown)
+MissingPullSecret SecretUnavailable Can't check image pull secret registry-ok (Unknown)`,
+		os.Conditions)
 }
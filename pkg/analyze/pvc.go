@@ -2,7 +2,11 @@ package analyze
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
@@ -11,7 +15,14 @@ import (
 )
 
 var (
-	gkPvc = schema.GroupKind{Group: "", Kind: "PersistentVolumeClaim"}
+	gkPvc          = schema.GroupKind{Group: "", Kind: "PersistentVolumeClaim"}
+	gkPv           = schema.GroupKind{Group: "", Kind: "PersistentVolume"}
+	gkStorageClass = schema.GroupKind{Group: "storage.k8s.io", Kind: "StorageClass"}
+	grPv           = schema.GroupResource{Group: "", Resource: "persistentvolumes"}
+
+	// annSelectedNode is set on a PVC with delayed binding once the pod using
+	// it gets scheduled.
+	annSelectedNode = "volume.kubernetes.io/selected-node"
 )
 
 type PVCAnalyzer struct {
@@ -25,19 +36,118 @@ func (_ PVCAnalyzer) Supports(obj *status.Object) bool {
 func (a PVCAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
 	phase, _, _ := unstructured.NestedString(obj.Unstructured.Object, "status", "phase")
 	var conditions []status.ConditionStatus
+	var subStatuses []status.ObjectStatus
 	if phase != "Bound" {
-		conditions = append(conditions,
-			SyntheticConditionProgressing("NotBound", phase, "PVC is not bound."))
-	} else {
-		conditions = append(conditions,
-			SyntheticConditionOk("Bound", "PVC is bound."))
+		if a.waitsForFirstConsumer(ctx, obj) {
+			conditions = append(conditions,
+				SyntheticConditionProgressing("NotBound", "WaitForFirstConsumer",
+					"PVC is waiting for the first consumer to be scheduled."))
+		} else {
+			conditions = append(conditions,
+				SyntheticConditionProgressing("NotBound", phase, "PVC is not bound."))
+		}
+		return AggregateResult(obj, nil, conditions)
+	}
+
+	conditions = append(conditions,
+		SyntheticConditionOk("Bound", "PVC is bound."))
+
+	volumeName, _, _ := unstructured.NestedString(obj.Unstructured.Object, "spec", "volumeName")
+	// Without access to the PVs (they are cluster-scoped), we can't tell
+	// whether the volume is missing.
+	if volumeName != "" && a.e.CheckAvailable(ctx, grPv, eval.NamespaceNone) == nil {
+		ns := eval.NamespaceNone
+		var err error
+		subStatuses, err = a.e.EvalQuery(ctx, eval.RefQuerySpec{
+			Object: obj,
+			RefObject: corev1.ObjectReference{
+				APIVersion: "v1",
+				Kind:       gkPv.Kind,
+				Name:       volumeName,
+			},
+			NamespaceOverride: &ns,
+		}, PVAnalyzer{})
+		if err != nil {
+			return status.UnknownStatusWithError(obj, err)
+		}
+		if len(subStatuses) == 0 {
+			conditions = append(conditions, SyntheticConditionError("VolumeMissing", "NotFound",
+				fmt.Sprintf("PersistentVolume %s not found.", volumeName)))
+		}
+	}
+
+	return AggregateResult(obj, subStatuses, conditions)
+}
+
+// waitsForFirstConsumer tells whether the PVC is pending because of
+// the delayed binding of its storage class, i.e. until a pod using it
+// gets scheduled. It's expected, not a sign of a problem.
+func (a PVCAnalyzer) waitsForFirstConsumer(ctx context.Context, obj *status.Object) bool {
+	if _, found := obj.GetAnnotations()[annSelectedNode]; found {
+		// The pod got scheduled: the provisioning is in progress.
+		return false
+	}
+	className, _, _ := unstructured.NestedString(obj.Unstructured.Object, "spec", "storageClassName")
+	if className == "" {
+		return false
+	}
+
+	ns := eval.NamespaceNone
+	classes, err := a.e.Load(ctx, eval.RefQuerySpec{
+		Object: obj,
+		RefObject: corev1.ObjectReference{
+			APIVersion: "storage.k8s.io/v1",
+			Kind:       gkStorageClass.Kind,
+			Name:       className,
+		},
+		NamespaceOverride: &ns,
+	})
+	if err != nil || len(classes) == 0 {
+		return false
+	}
+
+	var class storagev1.StorageClass
+	if err := FromUnstructured(classes[0].Unstructured.Object, &class); err != nil {
+		return false
+	}
+	return class.VolumeBindingMode != nil && *class.VolumeBindingMode == storagev1.VolumeBindingWaitForFirstConsumer
+}
+
+// PVAnalyzer evaluates the PersistentVolumes based on their phase.
+type PVAnalyzer struct{}
+
+func (_ PVAnalyzer) Supports(obj *status.Object) bool {
+	return obj.GroupVersionKind().GroupKind() == gkPv
+}
+
+func (_ PVAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	var pv corev1.PersistentVolume
+	if err := FromUnstructured(obj.Unstructured.Object, &pv); err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+
+	phase := string(pv.Status.Phase)
+	var cond status.ConditionStatus
+	switch pv.Status.Phase {
+	case corev1.VolumeBound, corev1.VolumeAvailable:
+		cond = SyntheticConditionOk(phase, "")
+	case corev1.VolumePending:
+		cond = SyntheticConditionProgressing(phase, pv.Status.Reason, pv.Status.Message)
+	case corev1.VolumeReleased:
+		// The claim was deleted, but the volume was not reclaimed yet.
+		cond = SyntheticConditionWarning(phase, pv.Status.Reason, pv.Status.Message)
+	case corev1.VolumeFailed:
+		cond = SyntheticConditionError(phase, pv.Status.Reason, pv.Status.Message)
+	default:
+		cond = ConditionStatusUnknown(SyntheticCondition("Phase", true, phase, pv.Status.Message, time.Time{}))
 	}
 
-	return AggregateResult(obj, nil, conditions)
+	return AggregateResult(obj, nil, []status.ConditionStatus{cond})
 }
 
 func init() {
 	Register.Register(func(e *eval.Evaluator) eval.Analyzer {
 		return PVCAnalyzer{e: e}
 	})
+	Register.RegisterSimple(PVAnalyzer{})
 }
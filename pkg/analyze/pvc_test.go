@@ -1,10 +1,13 @@
 package analyze_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/rhobs/kube-health/pkg/status"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/rhobs/kube-health/internal/test"
 )
@@ -24,3 +27,33 @@ func TestPvcAnalyzer(t *testing.T) {
 
 	test.AssertConditions(t, `NotBound Available PVC is not bound. (Unknown)`, os.Conditions)
 }
+
+func TestPvcAnalyzerVolume(t *testing.T) {
+	e, l, objs := test.TestEvaluator("pvcs.yaml")
+
+	os := e.Eval(t.Context(), objs[0])
+	assert.Equal(t, status.Ok, os.Status().Result)
+	require.Len(t, os.SubStatuses, 1)
+	test.AssertConditions(t, `Bound   (Ok)`, os.SubStatuses[0].Conditions)
+
+	os = e.Eval(t.Context(), objs[3])
+	assert.Equal(t, status.Warning, os.Status().Result)
+	require.Len(t, os.SubStatuses, 1)
+	assert.Equal(t, "pv-released", os.SubStatuses[0].Object.GetName())
+	test.AssertConditions(t, `Released   (Warning)`, os.SubStatuses[0].Conditions)
+
+	os = e.Eval(t.Context(), objs[5])
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `Bound  PVC is bound. (Ok)
+VolumeMissing NotFound PersistentVolume pv-missing not found. (Error)`, os.Conditions)
+
+	os = e.Eval(t.Context(), objs[6])
+	assert.True(t, os.Status().Progressing)
+	test.AssertConditions(t, `NotBound WaitForFirstConsumer PVC is waiting for the first consumer to be scheduled. (Unknown)`, os.Conditions)
+
+	// Without access to the PVs, the missing volume can't be detected.
+	l.RegisterUnavailable(schema.GroupResource{Resource: "persistentvolumes"}, errors.New("forbidden"))
+	e.Reset()
+	os = e.Eval(t.Context(), objs[5])
+	assert.Equal(t, status.Ok, os.Status().Result)
+}
@@ -2,7 +2,9 @@ package redhat
 
 import (
 	"context"
+	"strings"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/klog/v2"
@@ -27,6 +29,7 @@ var (
 
 type ClusterOperatorAnalyzer struct {
 	evaluator *eval.Evaluator
+	register  *analyze.AnalyzerRegister // source of the ignored kinds
 }
 
 func (_ ClusterOperatorAnalyzer) Supports(obj *status.Object) bool {
@@ -46,6 +49,7 @@ func (c *ClusterOperatorAnalyzer) Analyze(ctx context.Context, obj *status.Objec
 	if err != nil {
 		return status.UnknownStatusWithError(obj, err)
 	}
+	degradedWhileAvailable(conditions)
 
 	relatedObjects, _, err := unstructured.NestedSlice(obj.Unstructured.Object, "status", "relatedObjects")
 	if err != nil {
@@ -60,22 +64,40 @@ func (c *ClusterOperatorAnalyzer) Analyze(ctx context.Context, obj *status.Objec
 }
 
 func (c *ClusterOperatorAnalyzer) evaluateRelatedObjects(ctx context.Context, objectInfos []objectInfo) []status.ObjectStatus {
-	var statuses []status.ObjectStatus
-	for _, objInfo := range objectInfos {
+	// The related objects are independent of each other: evaluate them concurrently
+	// when the evaluator allows it.
+	return c.evaluator.RunParallel(len(objectInfos), func(i int) []status.ObjectStatus {
+		objInfo := objectInfos[i]
 		gk := c.evaluator.ResourceToKind(objInfo.groupResource).GroupKind()
-		if analyze.Register.IsIgnoredKind(gk) {
+		if c.register.IsIgnoredKind(gk) {
 			klog.V(7).Infof("%s kind (in group %s) is registered as ignored", gk.Kind, gk.Group)
-			continue
+			return nil
 		}
 		relObjectsStatuses, err := c.evaluator.EvalResource(ctx, objInfo.groupResource, objInfo.namespace, objInfo.name)
 		if err != nil {
 			klog.V(5).Infof("Failed to evaluate %s with name %s in the namespac %s: %v",
 				objInfo.groupResource, objInfo.namespace, objInfo.name, err)
-			continue
+			return nil
+		}
+		return relObjectsStatuses
+	})
+}
+
+// degradedWhileAvailable reports the Degraded condition as a warning when
+// the operator is still Available: the operator keeps providing its service,
+// unlike when it's not available at all.
+func degradedWhileAvailable(conditions []status.ConditionStatus) {
+	available := status.GetCondition(conditions, "Available")
+	if available == nil || available.Condition.Status != metav1.ConditionTrue {
+		return
+	}
+
+	for _, cond := range conditions {
+		if cond.Type == "Degraded" && cond.CondStatus.Result == status.Error {
+			cond.CondStatus.Result = status.Warning
+			cond.Message = strings.TrimSuffix("Available but degraded: "+cond.Message, ": ")
 		}
-		statuses = append(statuses, relObjectsStatuses...)
 	}
-	return statuses
 }
 
 type objectInfo struct {
@@ -118,9 +140,10 @@ func adaptRelatedObjects(parent *status.Object, relatedObjects []interface{}) []
 }
 
 func init() {
-	analyze.Register.Register(func(e *eval.Evaluator) eval.Analyzer {
+	analyze.Register.RegisterAware(func(e *eval.Evaluator, r *analyze.AnalyzerRegister) eval.Analyzer {
 		return &ClusterOperatorAnalyzer{
 			evaluator: e,
+			register:  r,
 		}
 	})
 
@@ -45,3 +45,16 @@ APIServerDeploymentAvailable AsExpected  (Unknown)
 APIServerDeploymentDegraded AsExpected  (Ok)
 	`, os.SubStatuses[0].Conditions)
 }
+
+func TestClusterOperatorAnalyzerAvailableButDegraded(t *testing.T) {
+	e, _, objs := test.TestEvaluator("clusteroperators.yaml")
+
+	os := e.Eval(context.Background(), objs[2])
+	assert.False(t, os.Status().Progressing)
+	assert.Equal(t, status.Warning, os.Status().Result)
+	test.AssertConditions(t, `
+Degraded IngressDegraded Available but degraded: The "default" ingress controller reports Degraded=True: one of the router pods is not ready (Warning)
+Progressing AsExpected desired and current number of IngressControllers are equal (Ok)
+Available IngressAvailable The "default" ingress controller reports Available=True. (Ok)
+`, os.Conditions)
+}
@@ -18,7 +18,8 @@ var (
 )
 
 type MCOAnalyzer struct {
-	e *eval.Evaluator
+	e        *eval.Evaluator
+	register *analyze.AnalyzerRegister // source of the ignored kinds
 }
 
 func (_ MCOAnalyzer) Supports(obj *status.Object) bool {
@@ -28,7 +29,7 @@ func (_ MCOAnalyzer) Supports(obj *status.Object) bool {
 func (a MCOAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
 	// We need to specify the namespace explicitly, as the MCO object
 	// is namespace-less.
-	ds := analyze.GenericOwnerQuerySpec(obj)
+	ds := a.register.OwnerQuerySpec(obj)
 	ds.NamespaceOverride = &mcoNs
 	subStatuses, err := a.e.EvalQuery(ctx, ds, nil)
 
@@ -42,7 +43,7 @@ func (a MCOAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.Obj
 }
 
 func init() {
-	analyze.Register.Register(func(e *eval.Evaluator) eval.Analyzer {
-		return MCOAnalyzer{e: e}
+	analyze.Register.RegisterAware(func(e *eval.Evaluator, r *analyze.AnalyzerRegister) eval.Analyzer {
+		return MCOAnalyzer{e: e, register: r}
 	})
 }
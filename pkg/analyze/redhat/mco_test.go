@@ -6,8 +6,13 @@ import (
 
 	"github.com/rhobs/kube-health/pkg/status"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/analyze"
+	"github.com/rhobs/kube-health/pkg/eval"
 )
 
 func TestMcoAnalyzer(t *testing.T) {
@@ -38,3 +43,39 @@ Degraded ErrPoolDegraded Pool failed updating (Error)
 Updated  All nodes are updated (Unknown)
 `, os.Conditions)
 }
+
+func TestMCOAnalyzerIgnoredKinds(t *testing.T) {
+	gkWidget := schema.GroupKind{Group: "example.org", Kind: "Widget"}
+	loader := eval.NewFakeLoader()
+	objs, err := loader.Register(
+		unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "observability.open-cluster-management.io/v1beta2",
+			"kind":       "MultiClusterObservability",
+			"metadata":   map[string]interface{}{"name": "observability", "uid": "uid-mco"},
+		}},
+		unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "example.org/v1",
+			"kind":       "Widget",
+			"metadata": map[string]interface{}{
+				"name":      "widget",
+				"namespace": "open-cluster-management-observability",
+				"uid":       "uid-widget",
+				"ownerReferences": []interface{}{map[string]interface{}{
+					"apiVersion": "observability.open-cluster-management.io/v1beta2",
+					"kind":       "MultiClusterObservability",
+					"name":       "observability",
+					"uid":        "uid-mco",
+				}},
+			},
+		}},
+	)
+	require.NoError(t, err)
+
+	os := eval.NewEvaluator(analyze.DefaultAnalyzers(), loader).Eval(t.Context(), objs[0])
+	assert.Len(t, os.SubStatuses, 1)
+
+	// The kinds ignored by the plugins are not evaluated.
+	e := eval.NewEvaluator(analyze.AnalyzersWithPlugins(analyze.IgnoreKinds(gkWidget)), loader)
+	os = e.Eval(t.Context(), objs[0])
+	assert.Empty(t, os.SubStatuses)
+}
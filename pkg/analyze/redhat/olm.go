@@ -6,6 +6,7 @@ package redhat
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -29,6 +30,13 @@ var (
 	}
 
 	olmAlwaysGreenAnalyzer = analyze.AlwaysGreenAnalyzer{Kinds: []schema.GroupKind{gkOLMOperatorGroup}}
+
+	// olmCSVPhaseAnalyzer maps the phases of the ClusterServiceVersions.
+	olmCSVPhaseAnalyzer = analyze.PhaseAnalyzer{
+		OkPhases:          analyze.NewStringMatchers("Succeeded"),
+		ErrorPhases:       analyze.NewStringMatchers("Failed"),
+		ProgressingPhases: analyze.NewStringMatchers("Pending", "InstallReady", "Installing", "Replacing", "Deleting"),
+	}
 )
 
 type OLMSubscriptionAnalyzer struct {
@@ -56,11 +64,61 @@ func (a OLMSubscriptionAnalyzer) Analyze(ctx context.Context, obj *status.Object
 			analyze.SyntheticCondition("InstallPlan", false, "InstallPlanMissing", "Install plan not found", time.Time{})))
 	}
 
+	if upgradeCond := subscriptionUpgradeCondition(obj, installPlanStatuses, csvStatuses); upgradeCond != nil {
+		conditions = append(conditions, *upgradeCond)
+	}
+
 	subStatuses := append(installPlanStatuses, csvStatuses...)
 
 	return analyze.AggregateResult(obj, subStatuses, conditions)
 }
 
+// subscriptionUpgradeCondition reports the installation of the operator
+// (or its upgrade, when a previous version is installed) that is stuck waiting
+// for the manual approval of the InstallPlan, or failing to bring up the new
+// CSV. An upgrade in progress is reported as progressing. It returns nil
+// when there is nothing to report.
+func subscriptionUpgradeCondition(obj *status.Object, installPlans, csvs []status.ObjectStatus) *status.ConditionStatus {
+	state, _, _ := unstructured.NestedString(obj.Unstructured.Object, "status", "state")
+	installed, _, _ := unstructured.NestedString(obj.Unstructured.Object, "status", "installedCSV")
+	current, _, _ := unstructured.NestedString(obj.Unstructured.Object, "status", "currentCSV")
+
+	condType, desc := "Upgrade", fmt.Sprintf("Upgrade from %s to %s", installed, current)
+	if installed == "" {
+		condType, desc = "Install", fmt.Sprintf("Installation of %s", current)
+	}
+	upgrading := installed != "" && (state == "UpgradePending" || (current != "" && current != installed))
+
+	for _, ip := range installPlans {
+		if installPlanRequiresApproval(ip.Object) {
+			cond := analyze.SyntheticConditionWarning(condType, "RequiresApproval",
+				fmt.Sprintf("%s is waiting for the approval of InstallPlan %s", desc, ip.Object.GetName()))
+			return &cond
+		}
+	}
+
+	for _, csv := range csvs {
+		if csv.Object.GetName() == current && csv.Status().Result == status.Error {
+			cond := analyze.SyntheticConditionError(condType, condType+"Failed", desc+" failed")
+			return &cond
+		}
+	}
+
+	if upgrading {
+		cond := analyze.SyntheticConditionProgressing(condType, "UpgradeInProgress", desc+" in progress")
+		return &cond
+	}
+	return nil
+}
+
+// installPlanRequiresApproval tells whether the InstallPlan waits for
+// the manual approval.
+func installPlanRequiresApproval(obj *status.Object) bool {
+	approval, _, _ := unstructured.NestedString(obj.Unstructured.Object, "spec", "approval")
+	approved, _, _ := unstructured.NestedBool(obj.Unstructured.Object, "spec", "approved")
+	return approval == "Manual" && !approved
+}
+
 func (a OLMSubscriptionAnalyzer) AnalyzeInstallPlans(ctx context.Context, obj *status.Object) []status.ObjectStatus {
 	var objRef corev1.ObjectReference
 	refData, found, err := unstructured.NestedMap(obj.Unstructured.Object, "status", "installPlanRef")
@@ -138,6 +196,11 @@ func (_ OLMInstallPlanAnalyzer) Analyze(ctx context.Context, obj *status.Object)
 		return status.UnknownStatusWithError(obj, err)
 	}
 
+	if installPlanRequiresApproval(obj) {
+		conditions = append(conditions, analyze.SyntheticConditionWarning("Approved", "RequiresApproval",
+			"The InstallPlan is waiting for the manual approval"))
+	}
+
 	return analyze.AggregateResult(obj, nil, conditions)
 }
 
@@ -149,41 +212,14 @@ func (_ OLMCSVAnalyzer) Supports(obj *status.Object) bool {
 }
 
 func (_ OLMCSVAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
-	statusData, found, err := unstructured.NestedMap(obj.Unstructured.Object, "status")
-	if err != nil {
-		return status.UnknownStatusWithError(obj, err)
-	}
-	if !found {
+	phaseCond := analyze.PhaseCondition(obj)
+	if phaseCond == nil {
 		return status.UnknownStatus(obj)
 	}
 
-	condition := metav1.Condition{}
-	err = analyze.FromUnstructured(statusData, &condition)
-	if err != nil {
-		return status.UnknownStatusWithError(obj, err)
-	}
-	phase, found, _ := unstructured.NestedString(statusData, "phase")
-	if found {
-		condition.Type = phase
-	}
-
-	conditionsStatuses := analyze.AnalyzeConditions([]*metav1.Condition{&condition},
-		[]analyze.ConditionAnalyzer{olmCSVConditionAnalyzer{}})
-	if err != nil {
-		return status.UnknownStatusWithError(obj, err)
-	}
-
-	return analyze.AggregateResult(obj, nil, conditionsStatuses)
-}
-
-type olmCSVConditionAnalyzer struct{}
-
-func (a olmCSVConditionAnalyzer) Analyze(cond *metav1.Condition) status.ConditionStatus {
-	if cond.Type == "Failed" {
-		return analyze.ConditionStatusError(cond)
-	}
-
-	return analyze.ConditionStatusNoMatch
+	conditions := analyze.AnalyzeConditions([]*metav1.Condition{phaseCond},
+		[]analyze.ConditionAnalyzer{olmCSVPhaseAnalyzer})
+	return analyze.AggregateResult(obj, nil, conditions)
 }
 
 func init() {
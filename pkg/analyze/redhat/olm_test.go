@@ -7,6 +7,7 @@ import (
 
 	"github.com/rhobs/kube-health/pkg/status"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/rhobs/kube-health/internal/test"
 	"github.com/rhobs/kube-health/pkg/print"
@@ -34,11 +35,13 @@ CatalogSourcesUnhealthy AllCatalogSourcesHealthy all available catalogsources ar
 InstallPlan InstallPlanMissing Install plan not found (Unknown)
 `, os.Conditions)
 
+	// The new CSV of the upgrade is failing.
 	os = e.Eval(ctx, objs[2])
 	assert.False(t, os.Status().Progressing)
 	assert.Equal(t, os.Status().Result, status.Error)
 	test.AssertConditions(t, `
 CatalogSourcesUnhealthy AllCatalogSourcesHealthy all available catalogsources are healthy (Ok)
+Upgrade UpgradeFailed Upgrade from op3.0.0.1 to op3.0.4.1 failed (Error)
 `, os.Conditions)
 
 	sb := &strings.Builder{}
@@ -47,10 +50,31 @@ CatalogSourcesUnhealthy AllCatalogSourcesHealthy all available catalogsources ar
 OBJECT           CONDITION                       AGE    REASON
 Error openshift-operators/Subscription/op3
 │                CatalogSourcesUnhealthy=False   24h    AllCatalogSourcesHealthy
+│                (Error) Upgrade=True                   UpgradeFailed
+│                  Upgrade from op3.0.0.1 to op3.0.4.1 failed
 ├─ Error ClusterServiceVersion/op3.0.4.1
-│                (Error) Failed=                 24h    ComponentUnhealthy
+│                (Error) Phase=Failed            24h    ComponentUnhealthy
 │                  installing: waiting for deployment to become ready
 └─ Ok InstallPlan/install-zvmlq
                  Installed=True                  24h
 `, sb.String())
 }
+
+func TestOlmAnalyzerUpgrade(t *testing.T) {
+	ctx := context.Background()
+	e, _, objs := test.TestEvaluator("olm_subscriptions.yaml", "olm_install_plans.yaml", "olm_csvs.yaml")
+
+	// The upgrade waits for the manual approval of the InstallPlan.
+	os := e.Eval(ctx, objs[3])
+	assert.False(t, os.Status().Progressing)
+	assert.Equal(t, status.Warning, os.Status().Result)
+	test.AssertConditions(t, `
+CatalogSourcesUnhealthy AllCatalogSourcesHealthy all available catalogsources are healthy (Ok)
+InstallPlanPending RequiresApproval  (Unknown)
+Upgrade RequiresApproval Upgrade from op4.1.0.0 to op4.1.1.0 is waiting for the approval of InstallPlan install-op4 (Warning)
+`, os.Conditions)
+
+	require.Len(t, os.SubStatuses, 1)
+	test.AssertConditions(t, `Approved RequiresApproval The InstallPlan is waiting for the manual approval (Warning)`,
+		os.SubStatuses[0].Conditions)
+}
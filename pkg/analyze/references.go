@@ -0,0 +1,197 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/rhobs/kube-health/pkg/eval"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+var (
+	gkConfigMap = schema.GroupKind{Group: "", Kind: "ConfigMap"}
+	gkSecret    = schema.GroupKind{Group: "", Kind: "Secret"}
+	grConfigMap = schema.GroupResource{Group: "", Resource: "configmaps"}
+	grSecret    = schema.GroupResource{Group: "", Resource: "secrets"}
+)
+
+// referenceResources maps the kinds of the pod references to their resources.
+var referenceResources = map[string]schema.GroupResource{
+	gkConfigMap.Kind: grConfigMap,
+	gkSecret.Kind:    grSecret,
+}
+
+// podReference is a ConfigMap or Secret referenced by a pod spec.
+type podReference struct {
+	kind string
+	name string
+	// from describes where the reference appears, e.g. "volume config".
+	from string
+}
+
+// podReferences lists the required ConfigMaps and Secrets referenced
+// by the volumes and the environment of the containers. The optional
+// references are skipped: the pod starts without them.
+func podReferences(spec *corev1.PodSpec) []podReference {
+	var ret []podReference
+	seen := make(map[podReference]bool)
+	add := func(kind, name string, optional *bool, from string) {
+		if name == "" || (optional != nil && *optional) {
+			return
+		}
+		// Report each object only once, under its first occurrence.
+		key := podReference{kind: kind, name: name}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		ret = append(ret, podReference{kind: kind, name: name, from: from})
+	}
+
+	for _, v := range spec.Volumes {
+		from := "volume " + v.Name
+		if v.ConfigMap != nil {
+			add(gkConfigMap.Kind, v.ConfigMap.Name, v.ConfigMap.Optional, from)
+		}
+		if v.Secret != nil {
+			add(gkSecret.Kind, v.Secret.SecretName, v.Secret.Optional, from)
+		}
+		if v.Projected != nil {
+			for _, src := range v.Projected.Sources {
+				if src.ConfigMap != nil {
+					add(gkConfigMap.Kind, src.ConfigMap.Name, src.ConfigMap.Optional, from)
+				}
+				if src.Secret != nil {
+					add(gkSecret.Kind, src.Secret.Name, src.Secret.Optional, from)
+				}
+			}
+		}
+	}
+
+	for _, c := range slices.Concat(spec.InitContainers, spec.Containers) {
+		from := "container " + c.Name
+		for _, env := range c.EnvFrom {
+			if env.ConfigMapRef != nil {
+				add(gkConfigMap.Kind, env.ConfigMapRef.Name, env.ConfigMapRef.Optional, from)
+			}
+			if env.SecretRef != nil {
+				add(gkSecret.Kind, env.SecretRef.Name, env.SecretRef.Optional, from)
+			}
+		}
+		for _, env := range c.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+				add(gkConfigMap.Kind, ref.Name, ref.Optional, from)
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+				add(gkSecret.Kind, ref.Name, ref.Optional, from)
+			}
+		}
+	}
+	return ret
+}
+
+// missingReferencesConditions reports the ConfigMaps and Secrets referenced
+// by the pod spec that don't exist. The pod spec is read from the path
+// in the object, e.g. spec.template.spec for the workloads. When the objects
+// of a kind can't be listed (e.g. due to missing permissions), their absence
+// can't be told: the references are reported as Unknown instead.
+func missingReferencesConditions(ctx context.Context, e *eval.Evaluator, obj *status.Object,
+	path ...string) ([]status.ConditionStatus, error) {
+	specData, found, err := unstructured.NestedMap(obj.Unstructured.Object, path...)
+	if !found || err != nil {
+		return nil, err
+	}
+	var spec corev1.PodSpec
+	if err := FromUnstructured(specData, &spec); err != nil {
+		return nil, err
+	}
+
+	var conditions []status.ConditionStatus
+	unavailable := make(map[string]bool)
+	for _, ref := range podReferences(&spec) {
+		if unavailable[ref.kind] {
+			continue
+		}
+		if err := e.CheckAvailable(ctx, referenceResources[ref.kind], obj.GetNamespace()); err != nil {
+			// Report the kind only once: all its references are unknown.
+			unavailable[ref.kind] = true
+			conditions = append(conditions, SyntheticConditionUnknown("MissingReference", ref.kind+"Unavailable",
+				fmt.Sprintf("Can't check the %ss referenced by the pod spec", ref.kind), err))
+			continue
+		}
+
+		objs, err := e.Load(ctx, eval.RefQuerySpec{
+			Object: obj,
+			RefObject: corev1.ObjectReference{
+				APIVersion: "v1",
+				Kind:       ref.kind,
+				Name:       ref.name,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(objs) == 0 {
+			conditions = append(conditions, SyntheticConditionError("MissingReference", ref.kind+"NotFound",
+				fmt.Sprintf("%s %s referenced by %s not found", ref.kind, ref.name, ref.from)))
+		}
+	}
+	return conditions, nil
+}
+
+// pullSecretsConditions reports the image pull secrets of the pod that
+// don't exist or are not of a registry credentials type. It's meant to
+// explain the image pull failures only, as it loads the Secrets. The secrets
+// that can't be loaded (e.g. due to missing permissions) are reported as Unknown.
+func pullSecretsConditions(ctx context.Context, e *eval.Evaluator, obj *status.Object,
+	pod *corev1.Pod) ([]status.ConditionStatus, error) {
+	var conditions []status.ConditionStatus
+	for _, ref := range pod.Spec.ImagePullSecrets {
+		if ref.Name == "" {
+			continue
+		}
+		secret, err := e.GetResource(ctx, grSecret, obj.GetNamespace(), ref.Name)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			conditions = append(conditions, SyntheticConditionUnknown("MissingPullSecret", "SecretUnavailable",
+				fmt.Sprintf("Can't check image pull secret %s", ref.Name), err))
+			continue
+		}
+		if secret == nil {
+			conditions = append(conditions, SyntheticConditionError("MissingPullSecret", "SecretNotFound",
+				fmt.Sprintf("Image pull secret %s not found", ref.Name)))
+			continue
+		}
+
+		secretType, _, _ := unstructured.NestedString(secret.Unstructured.Object, "type")
+		switch corev1.SecretType(secretType) {
+		case corev1.SecretTypeDockerConfigJson, corev1.SecretTypeDockercfg:
+		default:
+			conditions = append(conditions, SyntheticConditionError("MissingPullSecret", "InvalidSecretType",
+				fmt.Sprintf("Image pull secret %s has type %s, expected %s",
+					ref.Name, secretType, corev1.SecretTypeDockerConfigJson)))
+		}
+	}
+	return conditions, nil
+}
+
+// failsImagePull tells whether any of the containers of the pod is waiting
+// for its image to be pulled after a failure.
+func failsImagePull(pod *corev1.Pod) bool {
+	for _, cs := range slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses) {
+		if w := cs.State.Waiting; w != nil && (w.Reason == "ImagePullBackOff" || w.Reason == "ErrImagePull") {
+			return true
+		}
+	}
+	return false
+}
@@ -17,7 +17,8 @@ var (
 )
 
 type ReplicaSetAnalyzer struct {
-	e *eval.Evaluator
+	e    *eval.Evaluator
+	opts Options
 }
 
 func (_ ReplicaSetAnalyzer) Supports(obj *status.Object) bool {
@@ -26,11 +27,14 @@ func (_ ReplicaSetAnalyzer) Supports(obj *status.Object) bool {
 
 func (a ReplicaSetAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
 	subStatuses, err := a.e.EvalQuery(ctx,
-		eval.NewSelectorLabelQuerySpec(obj, gkPod), PodAnalyzer{e: a.e})
+		eval.NewSelectorLabelQuerySpec(obj, gkPod), PodAnalyzer{e: a.e, opts: a.opts})
 
 	if err != nil {
 		return status.UnknownStatusWithError(obj, err)
 	}
+	if a.opts.IgnoreReplacedEvictedPods {
+		subStatuses = withoutReplacedEvictedPods(subStatuses, specReplicas(obj))
+	}
 
 	conditions, err := AnalyzeObjectConditions(obj, append(
 		[]ConditionAnalyzer{replicaSetConditionAnalyzer{}},
@@ -40,6 +44,8 @@ func (a ReplicaSetAnalyzer) Analyze(ctx context.Context, obj *status.Object) sta
 		return status.UnknownStatusWithError(obj, err)
 	}
 
+	conditions = append(AnalyzeObservedGeneration(obj), conditions...)
+
 	synthConditions, err := replicaSetSyntehticConditions(obj)
 	if err != nil {
 		return status.UnknownStatusWithError(obj, err)
@@ -104,7 +110,7 @@ func (a replicaSetConditionAnalyzer) Analyze(cond *metav1.Condition) status.Cond
 }
 
 func init() {
-	Register.Register(func(e *eval.Evaluator) eval.Analyzer {
-		return ReplicaSetAnalyzer{e: e}
+	Register.RegisterAware(func(e *eval.Evaluator, r *AnalyzerRegister) eval.Analyzer {
+		return ReplicaSetAnalyzer{e: e, opts: r.Options()}
 	})
 }
@@ -5,8 +5,10 @@ import (
 
 	"github.com/rhobs/kube-health/pkg/status"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/analyze"
 )
 
 func TestReplicaSetAnalyzer(t *testing.T) {
@@ -22,3 +24,21 @@ ReplicasLabeled Unlabeled Labeled: 0/2 (Error)
 ReplicasAvailable Unavailable Available: 0/2 (Error)
 ReplicasReady NotReady Ready: 0/2 (Error)`, os.Conditions)
 }
+
+func TestReplicaSetAnalyzerEvictedPods(t *testing.T) {
+	e, _, objs := test.TestEvaluator("replicasets.yaml", "pods.yaml")
+
+	// The evicted pod was already replaced.
+	os := e.Eval(t.Context(), objs[2])
+	assert.Equal(t, status.Ok, os.Status().Result)
+	require.Len(t, os.SubStatuses, 1)
+	assert.Equal(t, "p10", os.SubStatuses[0].Object.Name)
+
+	opts := analyze.DefaultOptions()
+	opts.IgnoreReplacedEvictedPods = false
+	e, _, objs = test.TestEvaluatorWithOptions(opts, "replicasets.yaml", "pods.yaml")
+
+	os = e.Eval(t.Context(), objs[2])
+	assert.Equal(t, status.Warning, os.Status().Result)
+	assert.Len(t, os.SubStatuses, 2)
+}
@@ -0,0 +1,134 @@
+package analyze
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// ConditionRule describes how to evaluate the conditions matching a type
+// or a pattern. Unlike the KindConditionTable, the rules can apply to any
+// kind, e.g. to all the CRDs of an operator following the same conventions.
+type ConditionRule struct {
+	// Kinds limits the rule to the kinds in the KIND.GROUP format,
+	// e.g. Widget.example.com. The rule applies to all kinds when empty.
+	Kinds []string `yaml:"kinds"`
+	// Type matches the condition type exactly (case-insensitive).
+	Type string `yaml:"type"`
+	// Pattern matches the condition type by a regular expression
+	// (case-insensitive). Either Type or Pattern is to be set.
+	Pattern     string            `yaml:"pattern"`
+	Polarity    ConditionPolarity `yaml:"polarity"`
+	Severity    string            `yaml:"severity"`
+	Progressing bool              `yaml:"progressing"`
+}
+
+// Analyzer returns the condition analyzer matching the condition type
+// or pattern of the rule.
+func (c ConditionRule) Analyzer() (GenericConditionAnalyzer, error) {
+	switch {
+	case c.Type != "" && c.Pattern != "":
+		return GenericConditionAnalyzer{}, fmt.Errorf("rule with both type %s and pattern %s, expected only one of them", c.Type, c.Pattern)
+	case c.Type != "":
+		return newConditionAnalyzer(c.Type, NewStringMatchers(c.Type), c.Polarity, c.Severity, c.Progressing)
+	case c.Pattern != "":
+		if _, err := regexp.Compile(c.Pattern); err != nil {
+			return GenericConditionAnalyzer{}, fmt.Errorf("invalid pattern %s: %w", c.Pattern, err)
+		}
+		return newConditionAnalyzer(c.Pattern, NewRegexpMatchers(c.Pattern), c.Polarity, c.Severity, c.Progressing)
+	default:
+		return GenericConditionAnalyzer{}, fmt.Errorf("rule without type or pattern")
+	}
+}
+
+// conditionRule is a ConditionRule prepared for the evaluation.
+type conditionRule struct {
+	kinds    []schema.GroupKind
+	analyzer GenericConditionAnalyzer
+}
+
+func (r conditionRule) supports(obj *status.Object) bool {
+	return len(r.kinds) == 0 || slices.Contains(r.kinds, obj.GroupVersionKind().GroupKind())
+}
+
+// conditionRulesConfig is the format of the file read by ReadConditionRules.
+type conditionRulesConfig struct {
+	Rules []ConditionRule `yaml:"rules"`
+}
+
+// ReadConditionRules reads the condition rules from a YAML file, such as:
+//
+//	rules:
+//	- kinds: [Widget.example.com, Gadget.example.com]
+//	  type: Healthy
+//	- pattern: Degraded$
+//	  polarity: reversed
+//	  severity: warning
+func ReadConditionRules(path string) ([]ConditionRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadConditionRules(f)
+}
+
+// LoadConditionRules is like ReadConditionRules, reading the YAML from r.
+func LoadConditionRules(r io.Reader) ([]ConditionRule, error) {
+	var cfg conditionRulesConfig
+	if err := yaml.NewDecoder(r).Decode(&cfg); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	for i, rule := range cfg.Rules {
+		if _, err := rule.Analyzer(); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+	}
+	return cfg.Rules, nil
+}
+
+// RegisterConditionRules registers the condition rules used by the generic
+// analyzer. They take precedence over the authoritative conditions of the kinds
+// and over the DefaultConditionAnalyzers. The invalid rules are skipped.
+func (r *AnalyzerRegister) RegisterConditionRules(rules ...ConditionRule) {
+	for _, rule := range rules {
+		a, err := rule.Analyzer()
+		if err != nil {
+			klog.V(2).ErrorS(err, "Skipping invalid condition rule")
+			continue
+		}
+		cr := conditionRule{analyzer: a}
+		for _, k := range rule.Kinds {
+			cr.kinds = append(cr.kinds, schema.ParseGroupKind(k))
+		}
+		r.conditionRules = append(r.conditionRules, cr)
+	}
+}
+
+// AddConditionRules returns a plugin registering the condition rules.
+func AddConditionRules(rules ...ConditionRule) Plugin {
+	return func(r *AnalyzerRegister) {
+		r.RegisterConditionRules(rules...)
+	}
+}
+
+// conditionRuleAnalyzers returns the condition analyzers of the rules
+// applying to the object.
+func (r *AnalyzerRegister) conditionRuleAnalyzers(obj *status.Object) []ConditionAnalyzer {
+	var ret []ConditionAnalyzer
+	for _, rule := range r.conditionRules {
+		if rule.supports(obj) {
+			ret = append(ret, rule.analyzer)
+		}
+	}
+	return ret
+}
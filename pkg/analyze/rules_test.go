@@ -0,0 +1,62 @@
+package analyze_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/analyze"
+	"github.com/rhobs/kube-health/pkg/eval"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestConditionRules(t *testing.T) {
+	rules, err := analyze.LoadConditionRules(strings.NewReader(`
+rules:
+- kinds: [Widget.example.com]
+  type: Healthy
+- pattern: ^degr
+  polarity: reversed
+  severity: warning
+- kinds: [PodDisruptionBudget.policy]
+  pattern: Disruption
+  severity: unknown
+- kinds: [Gadget.example.com]
+  type: Healthy
+  polarity: reversed
+`))
+	require.NoError(t, err)
+	require.Len(t, rules, 4)
+
+	loader := eval.NewFakeLoader()
+	objs := test.RegisterTestData(loader, "kindconditions.yaml")
+	e := eval.NewEvaluator(analyze.AnalyzersWithPlugins(analyze.AddConditionRules(rules...)), loader)
+
+	// The rule for Gadgets doesn't apply to Widgets.
+	os := e.Eval(t.Context(), objs[2])
+	assert.Equal(t, status.Warning, os.Status().Result)
+	test.AssertConditions(t, `Healthy AsExpected All good (Ok)
+Degraded Lagging One replica is lagging behind (Warning)`, os.Conditions)
+
+	// The rules take precedence over the built-in kind conditions.
+	os = e.Eval(t.Context(), objs[1])
+	assert.Equal(t, status.Unknown, os.Status().Result)
+	test.AssertConditions(t, `DisruptionAllowed InsufficientPods  (Unknown)`, os.Conditions)
+
+	// The global register stays untouched.
+	os = eval.NewEvaluator(analyze.DefaultAnalyzers(), loader).Eval(t.Context(), objs[1])
+	assert.Equal(t, status.Warning, os.Status().Result)
+
+	for rule, msg := range map[string]string{
+		"- type: Healthy\n  pattern: Healthy": "rule 1: rule with both type Healthy and pattern Healthy",
+		"- pattern: (":                        "rule 1: invalid pattern (",
+		"- severity: warning":                 "rule 1: rule without type or pattern",
+		"- type: Healthy\n  severity: fatal":  `rule 1: unknown severity "fatal" of condition Healthy`,
+	} {
+		_, err = analyze.LoadConditionRules(strings.NewReader("rules:\n" + rule))
+		assert.ErrorContains(t, err, msg)
+	}
+}
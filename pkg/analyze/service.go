@@ -2,7 +2,14 @@ package analyze
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"strings"
 
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/rhobs/kube-health/pkg/eval"
@@ -10,11 +17,15 @@ import (
 )
 
 var (
-	gkService = schema.GroupKind{Group: "", Kind: "Service"}
+	gkService       = schema.GroupKind{Group: "", Kind: "Service"}
+	gkEndpoints     = schema.GroupKind{Group: "", Kind: "Endpoints"}
+	gkEndpointSlice = schema.GroupKind{Group: "discovery.k8s.io", Kind: "EndpointSlice"}
+	grService       = schema.GroupResource{Group: "", Resource: "services"}
 )
 
 type ServiceAnalyzer struct {
-	e *eval.Evaluator
+	e    *eval.Evaluator
+	opts Options
 }
 
 func (_ ServiceAnalyzer) Supports(obj *status.Object) bool {
@@ -23,17 +34,154 @@ func (_ ServiceAnalyzer) Supports(obj *status.Object) bool {
 
 func (a ServiceAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
 	subStatuses, err := a.e.EvalQuery(ctx,
-		eval.NewSelectorLabelEqualityQuerySpec(obj, gkPod), PodAnalyzer{e: a.e})
+		eval.NewSelectorLabelEqualityQuerySpec(obj, gkPod), PodAnalyzer{e: a.e, opts: a.opts})
 
 	if err != nil {
 		return status.UnknownStatusWithError(obj, err)
 	}
 
-	return AggregateResult(obj, subStatuses, nil)
+	var svc corev1.Service
+	if err := FromUnstructured(obj.Unstructured.Object, &svc); err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+	if svc.Spec.Type == corev1.ServiceTypeExternalName {
+		// Just a DNS alias: there are no endpoints.
+		return AggregateResult(obj, subStatuses, nil)
+	}
+
+	endpoints, err := a.loadEndpoints(ctx, obj)
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+
+	var conditions []status.ConditionStatus
+	if len(svc.Spec.Selector) == 0 {
+		// The endpoints are managed manually: they are the only source
+		// of truth about the backends.
+		conditions = append(conditions, endpoints.condition())
+	} else if endpoints.found {
+		if endpoints.ready == 0 {
+			conditions = append(conditions, endpoints.condition())
+		}
+		if cond := endpoints.missingPodsCondition(subStatuses); cond != nil {
+			conditions = append(conditions, *cond)
+		}
+	}
+
+	return AggregateResult(obj, subStatuses, conditions)
+}
+
+// serviceEndpoints summarizes the EndpointSlices (or the legacy Endpoints)
+// of a service.
+type serviceEndpoints struct {
+	found     bool            // any EndpointSlice or Endpoints object exists
+	ready     int             // number of the ready addresses
+	readyPods map[string]bool // names of the pods behind the ready addresses
+}
+
+// loadEndpoints loads the EndpointSlices of the service, falling back to
+// the Endpoints object of the same name, e.g. when managed by hand.
+func (a ServiceAnalyzer) loadEndpoints(ctx context.Context, obj *status.Object) (serviceEndpoints, error) {
+	ret := serviceEndpoints{readyPods: make(map[string]bool)}
+
+	sliceObjs, err := a.e.Load(ctx, eval.LabelQuerySpec{
+		Object:   obj,
+		GK:       eval.NewGroupKindMatcherSingle(gkEndpointSlice),
+		Selector: labels.SelectorFromSet(labels.Set{discoveryv1.LabelServiceName: obj.GetName()}),
+	})
+	if err != nil {
+		return ret, err
+	}
+	for _, sliceObj := range sliceObjs {
+		var slice discoveryv1.EndpointSlice
+		if err := FromUnstructured(sliceObj.Unstructured.Object, &slice); err != nil {
+			return ret, err
+		}
+		ret.found = true
+		for _, ep := range slice.Endpoints {
+			// Nil readiness is to be interpreted as ready.
+			if ep.Conditions.Ready != nil && !*ep.Conditions.Ready {
+				continue
+			}
+			ret.addReady(len(ep.Addresses), ep.TargetRef)
+		}
+	}
+	if ret.found {
+		return ret, nil
+	}
+
+	endpointsObjs, err := a.e.Load(ctx, eval.RefQuerySpec{
+		Object: obj,
+		RefObject: corev1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       gkEndpoints.Kind,
+			Name:       obj.GetName(),
+		},
+	})
+	if err != nil {
+		return ret, err
+	}
+	for _, endpointsObj := range endpointsObjs {
+		var endpoints corev1.Endpoints
+		if err := FromUnstructured(endpointsObj.Unstructured.Object, &endpoints); err != nil {
+			return ret, err
+		}
+		ret.found = true
+		for _, subset := range endpoints.Subsets {
+			for _, addr := range subset.Addresses {
+				ret.addReady(1, addr.TargetRef)
+			}
+		}
+	}
+	return ret, nil
+}
+
+func (e *serviceEndpoints) addReady(n int, ref *corev1.ObjectReference) {
+	e.ready += n
+	if ref != nil && ref.Kind == gkPod.Kind {
+		e.readyPods[ref.Name] = true
+	}
+}
+
+// condition reports the number of the ready endpoints.
+func (e serviceEndpoints) condition() status.ConditionStatus {
+	switch {
+	case !e.found:
+		return SyntheticConditionWarning("Endpoints", "NoEndpoints",
+			"No EndpointSlices or Endpoints found for the service")
+	case e.ready == 0:
+		return SyntheticConditionWarning("Endpoints", "NoReadyEndpoints",
+			"The service has no ready endpoints")
+	default:
+		return SyntheticConditionOk("Endpoints", fmt.Sprintf("Ready endpoints: %d", e.ready))
+	}
+}
+
+// missingPodsCondition reports the ready pods selected by the service
+// that don't appear among its ready endpoints, or nil if there are none.
+func (e serviceEndpoints) missingPodsCondition(pods []status.ObjectStatus) *status.ConditionStatus {
+	var missing []string
+	for _, pod := range pods {
+		ready := status.GetCondition(pod.Conditions, "Ready")
+		if ready == nil || ready.Condition.Status != metav1.ConditionTrue {
+			continue
+		}
+		if !e.readyPods[pod.Object.GetName()] {
+			missing = append(missing, pod.Object.GetName())
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	slices.Sort(missing)
+	cond := SyntheticConditionWarning("Endpoints", "PodsNotInEndpoints",
+		"Ready pods missing from the endpoints: "+strings.Join(missing, ", "))
+	return &cond
 }
 
 func init() {
-	Register.Register(func(e *eval.Evaluator) eval.Analyzer {
-		return ServiceAnalyzer{e: e}
+	Register.RegisterAware(func(e *eval.Evaluator, r *AnalyzerRegister) eval.Analyzer {
+		return ServiceAnalyzer{e: e, opts: r.Options()}
 	})
 }
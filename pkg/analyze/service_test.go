@@ -55,3 +55,26 @@ Error default/Service/s2
                  (Error) Ready=True                     NotReady
 `, sb.String())
 }
+
+func TestServiceAnalyzerEndpoints(t *testing.T) {
+	e, _, objs := test.TestEvaluator("services.yaml", "pods.yaml")
+
+	// Without selector, with manually managed Endpoints.
+	os := e.Eval(t.Context(), objs[2])
+	assert.Equal(t, status.Warning, os.Status().Result)
+	test.AssertConditions(t, `Endpoints NoReadyEndpoints The service has no ready endpoints (Warning)`, os.Conditions)
+
+	// The selected pod is ready, but it's not among the endpoints.
+	os = e.Eval(t.Context(), objs[4])
+	assert.Equal(t, status.Warning, os.Status().Result)
+	test.AssertConditions(t, `Endpoints PodsNotInEndpoints Ready pods missing from the endpoints: p1 (Warning)`, os.Conditions)
+
+	// Without selector, with an EndpointSlice.
+	os = e.Eval(t.Context(), objs[6])
+	assert.Equal(t, status.Ok, os.Status().Result)
+	test.AssertConditions(t, `Endpoints  Ready endpoints: 2 (Ok)`, os.Conditions)
+
+	os = e.Eval(t.Context(), objs[8])
+	assert.Equal(t, status.Warning, os.Status().Result)
+	test.AssertConditions(t, `Endpoints NoEndpoints No EndpointSlices or Endpoints found for the service (Warning)`, os.Conditions)
+}
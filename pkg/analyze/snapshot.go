@@ -0,0 +1,99 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/rhobs/kube-health/pkg/eval"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+var (
+	gkVolumeSnapshot        = schema.GroupKind{Group: "snapshot.storage.k8s.io", Kind: "VolumeSnapshot"}
+	gkVolumeSnapshotContent = schema.GroupKind{Group: "snapshot.storage.k8s.io", Kind: "VolumeSnapshotContent"}
+	grVolumeSnapshotContent = schema.GroupResource{Group: "snapshot.storage.k8s.io", Resource: "volumesnapshotcontents"}
+)
+
+// VolumeSnapshotAnalyzer evaluates the CSI VolumeSnapshots, including
+// the VolumeSnapshotContent they are bound to.
+type VolumeSnapshotAnalyzer struct {
+	e *eval.Evaluator
+}
+
+func (_ VolumeSnapshotAnalyzer) Supports(obj *status.Object) bool {
+	return obj.GroupVersionKind().GroupKind() == gkVolumeSnapshot
+}
+
+func (a VolumeSnapshotAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	conditions := []status.ConditionStatus{snapshotReadyCondition(obj)}
+
+	// The content is either pre-provisioned (spec.source) or created
+	// by the snapshot controller (status.boundVolumeSnapshotContentName).
+	contentName, _, _ := unstructured.NestedString(obj.Unstructured.Object, "status", "boundVolumeSnapshotContentName")
+	if contentName == "" {
+		contentName, _, _ = unstructured.NestedString(obj.Unstructured.Object, "spec", "source", "volumeSnapshotContentName")
+	}
+
+	var subStatuses []status.ObjectStatus
+	// The contents are cluster-scoped: without access to them, we can't
+	// tell whether the content is missing.
+	if contentName != "" && a.e.CheckAvailable(ctx, grVolumeSnapshotContent, eval.NamespaceNone) == nil {
+		ns := eval.NamespaceNone
+		var err error
+		subStatuses, err = a.e.EvalQuery(ctx, eval.RefQuerySpec{
+			Object: obj,
+			RefObject: corev1.ObjectReference{
+				APIVersion: "snapshot.storage.k8s.io/v1",
+				Kind:       gkVolumeSnapshotContent.Kind,
+				Name:       contentName,
+			},
+			NamespaceOverride: &ns,
+		}, VolumeSnapshotContentAnalyzer{})
+		if err != nil {
+			return status.UnknownStatusWithError(obj, err)
+		}
+		if len(subStatuses) == 0 {
+			conditions = append(conditions, SyntheticConditionError("ContentMissing", "NotFound",
+				fmt.Sprintf("VolumeSnapshotContent %s not found.", contentName)))
+		}
+	}
+
+	return AggregateResult(obj, subStatuses, conditions)
+}
+
+// VolumeSnapshotContentAnalyzer evaluates the VolumeSnapshotContents
+// based on their readiness.
+type VolumeSnapshotContentAnalyzer struct{}
+
+func (_ VolumeSnapshotContentAnalyzer) Supports(obj *status.Object) bool {
+	return obj.GroupVersionKind().GroupKind() == gkVolumeSnapshotContent
+}
+
+func (_ VolumeSnapshotContentAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	return AggregateResult(obj, nil, []status.ConditionStatus{snapshotReadyCondition(obj)})
+}
+
+// snapshotReadyCondition reports the status.readyToUse of a snapshot or
+// its content: an error reported in status.error fails it, otherwise
+// a snapshot not ready yet is still being provisioned.
+func snapshotReadyCondition(obj *status.Object) status.ConditionStatus {
+	ready, _, _ := unstructured.NestedBool(obj.Unstructured.Object, "status", "readyToUse")
+	if ready {
+		return SyntheticConditionOk("ReadyToUse", "Snapshot is ready to use.")
+	}
+	if errMsg, found, _ := unstructured.NestedString(obj.Unstructured.Object, "status", "error", "message"); found {
+		return SyntheticConditionError("ReadyToUse", "SnapshotError", errMsg)
+	}
+	return SyntheticConditionProgressing("ReadyToUse", "Provisioning", "Snapshot is being provisioned.")
+}
+
+func init() {
+	Register.Register(func(e *eval.Evaluator) eval.Analyzer {
+		return VolumeSnapshotAnalyzer{e: e}
+	})
+	Register.RegisterSimple(VolumeSnapshotContentAnalyzer{})
+}
@@ -0,0 +1,48 @@
+package analyze_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestVolumeSnapshotAnalyzer(t *testing.T) {
+	e, l, objs := test.TestEvaluator("volumesnapshots.yaml")
+
+	os := e.Eval(t.Context(), objs[0])
+	assert.Equal(t, status.Ok, os.Status().Result)
+	test.AssertConditions(t, `ReadyToUse  Snapshot is ready to use. (Ok)`, os.Conditions)
+	require.Len(t, os.SubStatuses, 1)
+	test.AssertConditions(t, `ReadyToUse  Snapshot is ready to use. (Ok)`, os.SubStatuses[0].Conditions)
+
+	os = e.Eval(t.Context(), objs[1])
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `ReadyToUse SnapshotError Failed to check and update snapshot content: rpc error: code = Internal desc = snapshot quota exceeded (Error)`,
+		os.Conditions)
+	require.Len(t, os.SubStatuses, 1)
+	test.AssertConditions(t, `ReadyToUse SnapshotError rpc error: code = Internal desc = snapshot quota exceeded (Error)`,
+		os.SubStatuses[0].Conditions)
+
+	os = e.Eval(t.Context(), objs[2])
+	assert.True(t, os.Status().Progressing)
+	test.AssertConditions(t, `ReadyToUse Provisioning Snapshot is being provisioned. (Unknown)`, os.Conditions)
+	assert.Empty(t, os.SubStatuses)
+
+	os = e.Eval(t.Context(), objs[3])
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `ReadyToUse  Snapshot is ready to use. (Ok)
+ContentMissing NotFound VolumeSnapshotContent snapcontent-missing not found. (Error)`, os.Conditions)
+
+	// Without access to the contents, the missing one can't be detected.
+	l.RegisterUnavailable(schema.GroupResource{Group: "snapshot.storage.k8s.io", Resource: "volumesnapshotcontents"},
+		errors.New("forbidden"))
+	e.Reset()
+	os = e.Eval(t.Context(), objs[3])
+	assert.Equal(t, status.Ok, os.Status().Result)
+}
@@ -0,0 +1,183 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/rhobs/kube-health/pkg/eval"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+var (
+	gkStatefulSet = appsv1.SchemeGroupVersion.WithKind("StatefulSet").GroupKind()
+)
+
+type StatefulSetAnalyzer struct {
+	e    *eval.Evaluator
+	opts Options
+}
+
+func (_ StatefulSetAnalyzer) Supports(obj *status.Object) bool {
+	return obj.GroupVersionKind().GroupKind() == gkStatefulSet
+}
+
+func (a StatefulSetAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	subStatuses, err := a.e.EvalQuery(ctx,
+		eval.NewSelectorLabelQuerySpec(obj, gkPod), PodAnalyzer{e: a.e, opts: a.opts})
+
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+	if a.opts.IgnoreReplacedEvictedPods {
+		subStatuses = withoutReplacedEvictedPods(subStatuses, specReplicas(obj))
+	}
+
+	conditions, err := AnalyzeObjectConditions(obj, DefaultConditionAnalyzers)
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+
+	conditions = append(AnalyzeObservedGeneration(obj), conditions...)
+
+	var sts appsv1.StatefulSet
+	err = FromUnstructured(obj.Unstructured.Object, &sts)
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+	conditions = append(conditions, statefulSetSyntheticConditions(&sts)...)
+
+	// The replicas are identified by their ordinals: list them in order,
+	// with the one holding back the others.
+	slices.SortStableFunc(subStatuses, func(a, b status.ObjectStatus) int {
+		return statefulSetOrdinal(&sts, a.Object.GetName()) - statefulSetOrdinal(&sts, b.Object.GetName())
+	})
+	conditions = append(conditions, statefulSetOrdinalConditions(&sts, subStatuses)...)
+
+	return AggregateResult(obj, subStatuses, conditions)
+}
+
+func statefulSetSyntheticConditions(sts *appsv1.StatefulSet) []status.ConditionStatus {
+	var conditions []status.ConditionStatus
+	replicas := statefulSetReplicas(sts)
+
+	if replicas > sts.Status.ReadyReplicas {
+		conditions = append(conditions, ConditionStatusError(
+			SyntheticCondition("ReplicasReady", false, "NotReady",
+				fmt.Sprintf("Ready: %d/%d", sts.Status.ReadyReplicas, replicas), time.Time{})))
+	} else {
+		conditions = append(conditions, ConditionStatusOk(
+			SyntheticCondition("ReplicasReady", true, "Ready",
+				fmt.Sprintf("Ready: %d/%d", sts.Status.ReadyReplicas, replicas), time.Time{})))
+	}
+
+	updated := sts.Status.UpdatedReplicas >= replicas &&
+		(sts.Status.UpdateRevision == "" || sts.Status.CurrentRevision == sts.Status.UpdateRevision)
+	if !updated {
+		message := fmt.Sprintf("Updated: %d/%d, current: %d",
+			sts.Status.UpdatedReplicas, replicas, sts.Status.CurrentReplicas)
+		if sts.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+			// The pods are updated only when deleted manually: the mismatch
+			// is expected and not a sign of a problem.
+			conditions = append(conditions, ConditionStatusUnknown(
+				SyntheticCondition("UpdateProgressing", false, "OnDelete", message, time.Time{})))
+		} else {
+			conditions = append(conditions, ConditionStatusProgressing(
+				SyntheticCondition("UpdateProgressing", true, "RollingUpdate", message, time.Time{})))
+		}
+	}
+
+	return conditions
+}
+
+// statefulSetOrdinalConditions reports the replicas, by their ordinals,
+// missing or not ready. With the OrderedReady pod management policy,
+// the controller doesn't proceed past the first of them.
+func statefulSetOrdinalConditions(sts *appsv1.StatefulSet, pods []status.ObjectStatus) []status.ConditionStatus {
+	replicas := statefulSetReplicas(sts)
+	if sts.Status.ReadyReplicas >= replicas {
+		return nil
+	}
+
+	podsByName := make(map[string]*status.Object, len(pods))
+	for _, pod := range pods {
+		podsByName[pod.Object.GetName()] = pod.Object
+	}
+
+	var start int32
+	if sts.Spec.Ordinals != nil {
+		start = sts.Spec.Ordinals.Start
+	}
+
+	var notReady []string
+	for ordinal := start; ordinal < start+replicas; ordinal++ {
+		name := fmt.Sprintf("%s-%d", sts.Name, ordinal)
+		pod, found := podsByName[name]
+		switch {
+		case !found:
+			notReady = append(notReady, name+" missing")
+		case !podReady(pod):
+			notReady = append(notReady, name+" not ready")
+		}
+	}
+
+	if len(notReady) == 0 {
+		return nil
+	}
+
+	if sts.Spec.PodManagementPolicy == appsv1.ParallelPodManagement {
+		return []status.ConditionStatus{SyntheticConditionError("OrdinalsReady", "NotReady",
+			strings.Join(notReady, ", "))}
+	}
+	return []status.ConditionStatus{SyntheticConditionError("OrdinalsReady", "Blocked",
+		notReady[0]+", blocking rollout")}
+}
+
+// statefulSetOrdinal returns the ordinal of the pod from its name. The pods
+// not named after the StatefulSet go last.
+func statefulSetOrdinal(sts *appsv1.StatefulSet, name string) int {
+	suffix, found := strings.CutPrefix(name, sts.Name+"-")
+	if !found {
+		return math.MaxInt32
+	}
+	ordinal, err := strconv.Atoi(suffix)
+	if err != nil || ordinal < 0 {
+		return math.MaxInt32
+	}
+	return ordinal
+}
+
+func statefulSetReplicas(sts *appsv1.StatefulSet) int32 {
+	if sts.Spec.Replicas == nil {
+		// Controller uses 1 as default if not specified.
+		return 1
+	}
+	return *sts.Spec.Replicas
+}
+
+// podReady returns true if the pod reports the Ready condition.
+func podReady(obj *status.Object) bool {
+	var pod corev1.Pod
+	if err := FromUnstructured(obj.Unstructured.Object, &pod); err != nil {
+		return false
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func init() {
+	Register.RegisterAware(func(e *eval.Evaluator, r *AnalyzerRegister) eval.Analyzer {
+		return StatefulSetAnalyzer{e: e, opts: r.Options()}
+	})
+}
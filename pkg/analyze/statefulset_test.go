@@ -0,0 +1,89 @@
+package analyze_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/analyze"
+	"github.com/rhobs/kube-health/pkg/print"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestStatefulSetAnalyzer(t *testing.T) {
+	var os status.ObjectStatus
+	p := print.NewTreePrinter(print.PrintOptions{ShowOk: true})
+	e, _, objs := test.TestEvaluator("statefulsets.yaml", "pods.yaml")
+
+	os = e.Eval(t.Context(), objs[0])
+	assert.False(t, os.Status().Progressing)
+	assert.Equal(t, os.Status().Result, status.Ok)
+
+	sb := &strings.Builder{}
+	p.PrintStatuses([]status.ObjectStatus{os}, sb)
+	test.AssertStr(t, `
+OBJECT           CONDITION                       AGE    REASON
+Ok default/StatefulSet/ss1
+│                ReplicasReady=True                     Ready
+└─ Ok Pod/p1
+   │             PodReadyToStartContainers=True  24h
+   │             Initialized=True                24h
+   │             Ready=True                      24h
+   │             ContainersReady=True            24h
+   │             PodScheduled=True               24h
+   └─ Ok Container/p1c
+                 Running=True                    24h
+`, sb.String())
+
+	os = e.Eval(t.Context(), objs[1])
+	assert.True(t, os.Status().Progressing)
+	assert.Equal(t, os.Status().Result, status.Error)
+	test.AssertConditions(t, `
+ReplicasReady NotReady Ready: 0/2 (Error)
+UpdateProgressing RollingUpdate Updated: 1/2, current: 1 (Unknown)
+OrdinalsReady Blocked ss2-0 missing, blocking rollout (Error)`, os.Conditions)
+	assert.Len(t, os.SubStatuses, 1)
+
+	// Pending updates with OnDelete strategy are expected.
+	os = e.Eval(t.Context(), objs[2])
+	assert.False(t, os.Status().Progressing)
+	assert.Equal(t, os.Status().Result, status.Ok)
+	test.AssertConditions(t, `
+ReplicasReady Ready Ready: 1/1 (Ok)
+UpdateProgressing OnDelete Updated: 0/1, current: 1 (Unknown)`, os.Conditions)
+
+	// The controller hasn't observed the latest spec yet.
+	os = e.Eval(t.Context(), objs[3])
+	assert.True(t, os.Status().Progressing)
+	test.AssertConditions(t, `
+ObservedGeneration Outdated Observed generation 2 is less than desired generation 3 (Unknown)
+ReplicasReady Ready Ready: 1/1 (Ok)`, os.Conditions)
+
+	// The replicas are listed by their ordinals: the first one not ready
+	// holds back the rollout of the others.
+	os = e.Eval(t.Context(), objs[4])
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `
+ReplicasReady NotReady Ready: 2/3 (Error)
+OrdinalsReady Blocked web-2 not ready, blocking rollout (Error)`, os.Conditions)
+	require.Len(t, os.SubStatuses, 3)
+	for i, sub := range os.SubStatuses {
+		assert.Equal(t, fmt.Sprintf("web-%d", i), sub.Object.GetName())
+	}
+
+	// With the parallel pod management, the replicas don't wait for each other.
+	os = e.Eval(t.Context(), objs[5])
+	test.AssertConditions(t, `
+ReplicasReady NotReady Ready: 1/3 (Error)
+OrdinalsReady NotReady db-0 not ready, db-2 missing (Error)`, os.Conditions)
+	require.Len(t, os.SubStatuses, 2)
+	assert.Equal(t, "db-0", os.SubStatuses[0].Object.GetName())
+	assert.Equal(t, "db-1", os.SubStatuses[1].Object.GetName())
+
+	// Some controllers store the observed generation elsewhere.
+	assert.Empty(t, analyze.AnalyzeObservedGeneration(objs[3], "status", "rollout", "observedGeneration"))
+}
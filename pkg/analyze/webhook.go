@@ -0,0 +1,155 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/rhobs/kube-health/pkg/eval"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+var (
+	gkValidatingWebhookConfiguration = schema.GroupKind{Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"}
+	gkMutatingWebhookConfiguration   = schema.GroupKind{Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration"}
+)
+
+// WebhookAnalyzer checks the backends of the admission webhooks: a webhook
+// pointing at a missing Service, or at one without ready endpoints, fails
+// the admission of the objects it intercepts, possibly across the whole cluster.
+// It's enabled by Options.WebhookChecks.
+type WebhookAnalyzer struct {
+	e    *eval.Evaluator
+	opts Options
+}
+
+func (a WebhookAnalyzer) Supports(obj *status.Object) bool {
+	if !a.opts.WebhookChecks {
+		return false
+	}
+	gk := obj.GroupVersionKind().GroupKind()
+	return gk == gkValidatingWebhookConfiguration || gk == gkMutatingWebhookConfiguration
+}
+
+func (a WebhookAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	// Both the kinds share the clientConfig format.
+	webhooks, _, err := unstructured.NestedSlice(obj.Unstructured.Object, "webhooks")
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+
+	var conditions []status.ConditionStatus
+	// Multiple webhooks often share the same Service.
+	checked := make(map[types.NamespacedName]backendCheck)
+	for _, whData := range webhooks {
+		whMap, ok := whData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var wh admissionregistrationv1.ValidatingWebhook
+		if err := FromUnstructured(whMap, &wh); err != nil {
+			return status.UnknownStatusWithError(obj, err)
+		}
+
+		svc := wh.ClientConfig.Service
+		if svc == nil {
+			// The webhooks using URLs are out of the cluster's reach.
+			continue
+		}
+
+		key := types.NamespacedName{Namespace: svc.Namespace, Name: svc.Name}
+		check, found := checked[key]
+		if !found {
+			check, err = a.serviceProblem(ctx, obj, key)
+			if err != nil {
+				return status.UnknownStatusWithError(obj, err)
+			}
+			checked[key] = check
+		}
+
+		reason := check.reason
+		var message string
+		switch reason {
+		case "":
+			continue
+		case "ServiceUnavailable":
+			conditions = append(conditions, SyntheticConditionUnknown("Backend", reason,
+				fmt.Sprintf("Webhook %s: can't check Service %s", wh.Name, key), check.err))
+			continue
+		case "ServiceNotFound":
+			message = fmt.Sprintf("Webhook %s: Service %s not found", wh.Name, key)
+		default:
+			message = fmt.Sprintf("Webhook %s: Service %s has no ready endpoints", wh.Name, key)
+		}
+		// A failing webhook with the Fail policy (the default) rejects
+		// the requests it intercepts, while with Ignore it's only skipped.
+		if wh.FailurePolicy != nil && *wh.FailurePolicy == admissionregistrationv1.Ignore {
+			conditions = append(conditions, SyntheticConditionWarning("Backend", reason, message))
+		} else {
+			conditions = append(conditions, SyntheticConditionError("Backend", reason, message))
+		}
+	}
+
+	if len(checked) == 0 {
+		// Nothing to check in the cluster.
+		return status.OkStatus(obj, nil)
+	}
+	if len(conditions) == 0 {
+		conditions = append(conditions, SyntheticConditionOk("Backend",
+			fmt.Sprintf("Ready webhook services: %d", len(checked))))
+	}
+
+	return AggregateResult(obj, nil, conditions)
+}
+
+// backendCheck is the result of checking the Service of a webhook.
+type backendCheck struct {
+	reason string // empty when the Service is ready
+	err    error  // why the Service can't be checked, with the ServiceUnavailable reason
+}
+
+// serviceProblem checks the Service of a webhook exists and has ready
+// endpoints. The Services that can't be listed (e.g. due to missing
+// permissions) are reported as unavailable rather than missing.
+func (a WebhookAnalyzer) serviceProblem(ctx context.Context, obj *status.Object,
+	svc types.NamespacedName) (backendCheck, error) {
+	if err := a.e.CheckAvailable(ctx, grService, svc.Namespace); err != nil {
+		return backendCheck{reason: "ServiceUnavailable", err: err}, nil
+	}
+
+	svcs, err := a.e.Load(ctx, eval.RefQuerySpec{
+		Object: obj,
+		RefObject: corev1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       gkService.Kind,
+			Name:       svc.Name,
+		},
+		NamespaceOverride: &svc.Namespace,
+	})
+	if err != nil {
+		return backendCheck{}, err
+	}
+	if len(svcs) == 0 {
+		return backendCheck{reason: "ServiceNotFound"}, nil
+	}
+
+	endpoints, err := ServiceAnalyzer{e: a.e}.loadEndpoints(ctx, svcs[0])
+	if err != nil {
+		return backendCheck{}, err
+	}
+	if endpoints.ready == 0 {
+		return backendCheck{reason: "NoReadyEndpoints"}, nil
+	}
+	return backendCheck{}, nil
+}
+
+func init() {
+	Register.RegisterAware(func(e *eval.Evaluator, r *AnalyzerRegister) eval.Analyzer {
+		return WebhookAnalyzer{e: e, opts: r.Options()}
+	})
+}
@@ -0,0 +1,50 @@
+package analyze_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/analyze"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestWebhookAnalyzer(t *testing.T) {
+	var os status.ObjectStatus
+	e, _, objs := test.TestEvaluator("webhooks.yaml")
+
+	// The check is disabled by default.
+	os = e.Eval(t.Context(), objs[0])
+	assert.Equal(t, status.Ok, os.Status().Result)
+	assert.Empty(t, os.Conditions)
+
+	opts := analyze.DefaultOptions()
+	opts.WebhookChecks = true
+	e, _, objs = test.TestEvaluatorWithOptions(opts, "webhooks.yaml")
+
+	os = e.Eval(t.Context(), objs[0])
+	assert.Equal(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `
+Backend ServiceNotFound Webhook validate.widgets.example.com: Service webhooks/missing not found (Error)
+Backend NoReadyEndpoints Webhook validate.gadgets.example.com: Service webhooks/unready has no ready endpoints (Warning)`,
+		os.Conditions)
+
+	os = e.Eval(t.Context(), objs[1])
+	assert.Equal(t, status.Ok, os.Status().Result)
+	test.AssertConditions(t, `Backend  Ready webhook services: 1 (Ok)`, os.Conditions)
+
+	// Not allowed to list the Services: the missing ones can't be told.
+	e, l, objs := test.TestEvaluatorWithOptions(opts, "webhooks.yaml")
+	l.RegisterUnavailable(schema.GroupResource{Resource: "services"},
+		apierrors.NewForbidden(schema.GroupResource{Resource: "services"}, "", errors.New("denied")))
+	os = e.Eval(t.Context(), objs[0])
+	assert.NotEqual(t, status.Error, os.Status().Result)
+	test.AssertConditions(t, `
+Backend ServiceUnavailable Webhook validate.widgets.example.com: can't check Service webhooks/missing (Unknown)
+Backend ServiceUnavailable Webhook validate.gadgets.example.com: can't check Service webhooks/unready (Unknown)`,
+		os.Conditions)
+}
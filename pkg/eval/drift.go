@@ -0,0 +1,225 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// DriftType describes how the live state differs from the expected one.
+type DriftType string
+
+const (
+	// DriftMissing is reported for an expected object not found in the cluster.
+	DriftMissing DriftType = "Missing"
+	// DriftExtra is reported for a live object not present in the expected set.
+	DriftExtra DriftType = "Extra"
+	// DriftChanged is reported for a live object differing from the expected one.
+	DriftChanged DriftType = "Changed"
+)
+
+// DriftFields are the fields compared between the expected and live objects.
+// Only the values set in the expected object are compared, so that the fields
+// defaulted by the API server are not reported.
+var DriftFields = [][]string{
+	{"metadata", "labels"},
+	{"metadata", "annotations"},
+	{"spec"},
+	{"data"},
+}
+
+// FieldDrift is a single field differing between the expected and live object.
+type FieldDrift struct {
+	Path     string
+	Expected interface{}
+	Actual   interface{}
+}
+
+func (f FieldDrift) String() string {
+	return fmt.Sprintf("%s: expected %v, got %v", f.Path, f.Expected, f.Actual)
+}
+
+// Drift describes the difference of a single object.
+type Drift struct {
+	Type     DriftType
+	Identity ObjectIdentity
+	// Fields are set for DriftChanged only.
+	Fields []FieldDrift
+}
+
+// DriftReport is the result of comparing the expected objects with the live ones.
+type DriftReport struct {
+	Drifts []Drift
+	// Warnings describe the live objects that couldn't be loaded: the drift
+	// of the expected objects of the same kind is not known.
+	Warnings []string
+}
+
+// InSync returns true when no drift was found.
+func (r DriftReport) InSync() bool {
+	return len(r.Drifts) == 0
+}
+
+// DriftOptions configure the comparison of the expected and live objects.
+type DriftOptions struct {
+	// Extra enables reporting the live objects not present in the expected set.
+	Extra bool
+}
+
+// driftScope is a kind of the expected objects in their namespace.
+type driftScope struct {
+	namespace string
+	gk        schema.GroupKind
+}
+
+func (s driftScope) String() string {
+	if s.namespace == "" {
+		return s.gk.String()
+	}
+	return fmt.Sprintf("%s in namespace %s", s.gk, s.namespace)
+}
+
+// ComputeDrift compares the expected objects (e.g. loaded from manifests) with
+// the live state provided by the loader.
+//
+// With opts.Extra, objects of the expected kinds living in the expected
+// namespaces, which are not expected themselves, are reported as extra.
+// Objects with owner references are skipped for this purpose, as they are
+// managed by other objects.
+//
+// The kinds failing to load are reported as warnings, the drift of the rest
+// is still computed.
+func ComputeDrift(ctx context.Context, loader Loader, expected []*status.Object, opts DriftOptions) (DriftReport, error) {
+	var scopes []driftScope
+	for _, obj := range expected {
+		scope := driftScope{namespace: obj.GetNamespace(), gk: obj.GroupVersionKind().GroupKind()}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	var report DriftReport
+	live := make(map[ObjectIdentity]*status.Object)
+	failed := make(map[driftScope]struct{})
+	for _, scope := range scopes {
+		objs, err := loader.Load(ctx, scope.namespace,
+			GroupKindMatcher{IncludedKinds: []schema.GroupKind{scope.gk}}, nil)
+		if err != nil {
+			if ctx.Err() != nil {
+				return DriftReport{}, ctx.Err()
+			}
+			failed[scope] = struct{}{}
+			report.Warnings = append(report.Warnings,
+				fmt.Sprintf("Can't load the live objects of %s: %v", scope, err))
+			continue
+		}
+		for _, obj := range objs {
+			live[IdentityOf(obj)] = obj
+		}
+	}
+
+	expectedIds := make(map[ObjectIdentity]struct{})
+	for _, obj := range expected {
+		id := IdentityOf(obj)
+		expectedIds[id] = struct{}{}
+
+		if _, found := failed[driftScope{namespace: id.Namespace, gk: id.GroupKind}]; found {
+			continue
+		}
+
+		liveObj, found := live[id]
+		if !found {
+			report.Drifts = append(report.Drifts, Drift{Type: DriftMissing, Identity: id})
+			continue
+		}
+
+		var fields []FieldDrift
+		for _, path := range DriftFields {
+			fields = append(fields, diffFields(strings.Join(path, "."),
+				nestedValue(obj.Unstructured.Object, path),
+				nestedValue(liveObj.Unstructured.Object, path))...)
+		}
+		if len(fields) > 0 {
+			report.Drifts = append(report.Drifts, Drift{Type: DriftChanged, Identity: id, Fields: fields})
+		}
+	}
+
+	if !opts.Extra {
+		return report, nil
+	}
+
+	var extra []Drift
+	for id, obj := range live {
+		if _, found := expectedIds[id]; found || len(obj.GetOwnerReferences()) > 0 {
+			continue
+		}
+		// The loads across all namespaces return the objects from the others.
+		if !slices.Contains(scopes, driftScope{namespace: id.Namespace, gk: id.GroupKind}) {
+			continue
+		}
+		extra = append(extra, Drift{Type: DriftExtra, Identity: id})
+	}
+	slices.SortFunc(extra, func(a, b Drift) int {
+		return strings.Compare(a.Identity.String(), b.Identity.String())
+	})
+	report.Drifts = append(report.Drifts, extra...)
+
+	return report, nil
+}
+
+func nestedValue(obj map[string]interface{}, path []string) interface{} {
+	var val interface{} = obj
+	for _, field := range path {
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		val = m[field]
+	}
+	return val
+}
+
+// diffFields returns the fields set in the expected value that differ in
+// the actual one.
+func diffFields(path string, expected, actual interface{}) []FieldDrift {
+	switch exp := expected.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		act, _ := actual.(map[string]interface{})
+		keys := make([]string, 0, len(exp))
+		for k := range exp {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+
+		var ret []FieldDrift
+		for _, k := range keys {
+			ret = append(ret, diffFields(path+"."+k, exp[k], act[k])...)
+		}
+		return ret
+	case []interface{}:
+		act, ok := actual.([]interface{})
+		if !ok || len(act) != len(exp) {
+			return []FieldDrift{{Path: path, Expected: expected, Actual: actual}}
+		}
+
+		var ret []FieldDrift
+		for i := range exp {
+			ret = append(ret, diffFields(fmt.Sprintf("%s[%d]", path, i), exp[i], act[i])...)
+		}
+		return ret
+	default:
+		// The numbers might be decoded differently (e.g. int64 vs. float64).
+		if reflect.DeepEqual(expected, actual) || fmt.Sprint(expected) == fmt.Sprint(actual) {
+			return nil
+		}
+		return []FieldDrift{{Path: path, Expected: expected, Actual: actual}}
+	}
+}
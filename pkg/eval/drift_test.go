@@ -0,0 +1,132 @@
+package eval
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func testDeployment(name, uid string, replicas int64) unstructured.Unstructured {
+	u := unstructured.Unstructured{}
+	u.SetAPIVersion("apps/v1")
+	u.SetKind("Deployment")
+	u.SetNamespace("default")
+	u.SetName(name)
+	if uid != "" {
+		u.SetUID(types.UID(uid))
+	}
+	unstructured.SetNestedField(u.Object, replicas, "spec", "replicas")
+	unstructured.SetNestedField(u.Object, "RollingUpdate", "spec", "strategy", "type")
+	return u
+}
+
+func TestComputeDrift(t *testing.T) {
+	loader := NewFakeLoader()
+
+	owned := testDeployment("owned", "uid-owned", 1)
+	owned.SetOwnerReferences([]metav1.OwnerReference{{Kind: "Foo", Name: "foo"}})
+	cm := testConfigMap("cm1", "uid-cm1")
+	unstructured.SetNestedField(cm.Object, "live", "data", "key")
+	_, err := loader.Register(
+		testDeployment("in-sync", "uid-1", 2),
+		testDeployment("changed", "uid-2", 1),
+		testDeployment("extra", "uid-3", 1),
+		owned,
+		cm,
+	)
+	require.NoError(t, err)
+
+	var expected []*status.Object
+	expectedCm := testConfigMap("cm1", "")
+	unstructured.SetNestedField(expectedCm.Object, "expected", "data", "key")
+	for _, u := range []unstructured.Unstructured{
+		testDeployment("in-sync", "", 2),
+		testDeployment("changed", "", 3),
+		testDeployment("missing", "", 1),
+		expectedCm,
+	} {
+		obj, err := status.NewObjectFromUnstructured(&u)
+		require.NoError(t, err)
+		expected = append(expected, obj)
+	}
+
+	report, err := ComputeDrift(t.Context(), loader, expected, DriftOptions{Extra: true})
+	require.NoError(t, err)
+	assert.False(t, report.InSync())
+
+	gkDeployment := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+	assert.Equal(t, []Drift{
+		{
+			Type:     DriftChanged,
+			Identity: ObjectIdentity{GroupKind: gkDeployment, Namespace: "default", Name: "changed"},
+			Fields:   []FieldDrift{{Path: "spec.replicas", Expected: int64(3), Actual: int64(1)}},
+		},
+		{
+			Type:     DriftMissing,
+			Identity: ObjectIdentity{GroupKind: gkDeployment, Namespace: "default", Name: "missing"},
+		},
+		{
+			Type:     DriftChanged,
+			Identity: ObjectIdentity{GroupKind: schema.GroupKind{Kind: "ConfigMap"}, Namespace: "default", Name: "cm1"},
+			Fields:   []FieldDrift{{Path: "data.key", Expected: "expected", Actual: "live"}},
+		},
+		{
+			Type:     DriftExtra,
+			Identity: ObjectIdentity{GroupKind: gkDeployment, Namespace: "default", Name: "extra"},
+		},
+	}, report.Drifts)
+
+	assert.Equal(t, "spec.replicas: expected 3, got 1", report.Drifts[0].Fields[0].String())
+	assert.Empty(t, report.Warnings)
+
+	// The extra objects are reported only on request.
+	report, err = ComputeDrift(t.Context(), loader, expected, DriftOptions{})
+	require.NoError(t, err)
+	assert.Len(t, report.Drifts, 3)
+	for _, d := range report.Drifts {
+		assert.NotEqual(t, DriftExtra, d.Type)
+	}
+
+	// The ConfigMaps can't be listed: their drift is unknown, the rest is still reported.
+	loader.RegisterUnavailable(schema.GroupResource{Resource: "configmaps"},
+		apierrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "", errors.New("denied")))
+	report, err = ComputeDrift(t.Context(), loader, expected, DriftOptions{Extra: true})
+	require.NoError(t, err)
+	var driftTypes []DriftType
+	for _, d := range report.Drifts {
+		driftTypes = append(driftTypes, d.Type)
+	}
+	assert.Equal(t, []DriftType{DriftChanged, DriftMissing, DriftExtra}, driftTypes)
+	require.Len(t, report.Warnings, 1)
+	assert.Contains(t, report.Warnings[0], "Can't load the live objects of ConfigMap in namespace default")
+}
+
+func TestDiffFields(t *testing.T) {
+	expected := map[string]interface{}{
+		"replicas": int64(2),
+		"containers": []interface{}{
+			map[string]interface{}{"name": "c1", "image": "img:v2"},
+		},
+	}
+	actual := map[string]interface{}{
+		"replicas": float64(2),
+		"containers": []interface{}{
+			map[string]interface{}{"name": "c1", "image": "img:v1", "imagePullPolicy": "Always"},
+		},
+		"revisionHistoryLimit": int64(10),
+	}
+
+	assert.Equal(t, []FieldDrift{
+		{Path: "spec.containers[0].image", Expected: "img:v2", Actual: "img:v1"},
+	}, diffFields("spec", expected, actual))
+	assert.Empty(t, diffFields("spec", expected, expected))
+}
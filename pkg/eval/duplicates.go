@@ -0,0 +1,87 @@
+package eval
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// DuplicateCondition is the type of the condition added to objects sharing
+// the identity with another object in the evaluated set.
+const DuplicateCondition = "Duplicate"
+
+// ObjectIdentity identifies an object regardless of its UID.
+type ObjectIdentity struct {
+	GroupKind schema.GroupKind
+	Namespace string
+	Name      string
+}
+
+func IdentityOf(obj *status.Object) ObjectIdentity {
+	return ObjectIdentity{
+		GroupKind: obj.GroupVersionKind().GroupKind(),
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+	}
+}
+
+func (i ObjectIdentity) String() string {
+	if i.Namespace == "" {
+		return fmt.Sprintf("%s/%s", i.GroupKind.String(), i.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", i.Namespace, i.GroupKind.String(), i.Name)
+}
+
+// FindDuplicates returns the identities shared by multiple different objects,
+// with the different objects sharing them.
+//
+// Objects are considered different when their UIDs differ. Objects without
+// UID (e.g. coming from manifest files) are always considered different,
+// so that overlapping manifests get detected. The same object passed
+// several times is listed only once.
+func FindDuplicates(objects []*status.Object) map[ObjectIdentity][]*status.Object {
+	groups := make(map[ObjectIdentity][]*status.Object)
+	uids := make(map[types.UID]struct{})
+	for _, obj := range objects {
+		if obj.GetUID() != "" {
+			if _, seen := uids[obj.GetUID()]; seen {
+				continue
+			}
+			uids[obj.GetUID()] = struct{}{}
+		}
+		id := IdentityOf(obj)
+		groups[id] = append(groups[id], obj)
+	}
+
+	ret := make(map[ObjectIdentity][]*status.Object)
+	for id, objs := range groups {
+		if len(objs) > 1 {
+			ret[id] = objs
+		}
+	}
+	return ret
+}
+
+// markDuplicate adds a warning condition to the status of an object whose
+// identity is shared with other objects.
+func markDuplicate(os status.ObjectStatus, count int) status.ObjectStatus {
+	cond := status.ConditionStatus{
+		Condition: &metav1.Condition{
+			Type:    DuplicateCondition,
+			Status:  metav1.ConditionTrue,
+			Reason:  "ConflictingResources",
+			Message: fmt.Sprintf("%d objects share the identity %s", count, IdentityOf(os.Object)),
+		},
+		CondStatus: &status.Status{Result: status.Warning, Status: status.Warning.String()},
+	}
+	os.Conditions = append(os.Conditions, cond)
+	if os.ObjStatus.Result < status.Warning {
+		os.ObjStatus.Result = status.Warning
+		os.ObjStatus.Status = status.Warning.String()
+	}
+	return os
+}
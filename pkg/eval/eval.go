@@ -2,10 +2,17 @@ package eval
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"slices"
+	"sync"
+	"time"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
 
 	"github.com/rhobs/kube-health/pkg/status"
 )
@@ -31,21 +38,36 @@ type Loader interface {
 	Get(context.Context, *status.Object) (*status.Object, error)
 
 	// Load evaluates the query based on the backend data.
+	// When only some of the resources fail to load, the objects loaded
+	// successfully are returned together with the error.
 	Load(c context.Context, ns string, gkm GroupKindMatcher, exclude []schema.GroupKind) ([]*status.Object, error)
 
-	// Load evaluates the query based on the backend data.
-	LoadPodLogs(c context.Context, obj *status.Object, container string, tailLines int64) ([]byte, error)
+	// LoadPodLogs loads the last tailLines of the container logs. With previous
+	// set, the logs of the previous instance of the container are loaded.
+	LoadPodLogs(c context.Context, obj *status.Object, container string, tailLines int64, previous bool) ([]byte, error)
 
 	// LoadResource loads the resource based on its group resource, namespace and name
 	LoadResource(ctx context.Context, gvr schema.GroupResource, namespace string, name string) ([]*status.Object, error)
 
-	// LoadResourceBySelector loads the resource based on its group resource, namespace and label selector
-	LoadResourceBySelector(ctx context.Context, gvr schema.GroupResource, namespace string, label string) ([]*status.Object, error)
+	// LoadResourceBySelector loads the resource based on its group resource, namespace and label
+	// and field selectors. Empty selectors match all objects.
+	LoadResourceBySelector(ctx context.Context, gvr schema.GroupResource, namespace string, label string, field string) ([]*status.Object, error)
+
+	// LoadByFieldSelector loads the objects of the matching kinds selected by the field selector.
+	// Like with Load, the objects loaded successfully are returned together with the error
+	// on partial failure.
+	LoadByFieldSelector(ctx context.Context, ns string, gkm GroupKindMatcher, selector fields.Selector) ([]*status.Object, error)
 
 	// ResourceToKind helps to translate a groupResource to the corresponding groupVersionKind
 	ResourceToKind(gr schema.GroupResource) schema.GroupVersionKind
 }
 
+// resetter is optionally implemented by loaders keeping state that should
+// not outlive a single evaluation cycle.
+type resetter interface {
+	Reset()
+}
+
 // Evaluator is the entry structure for the status evaluation cycle.
 //
 // It peformes the following steps:
@@ -56,11 +78,28 @@ type Evaluator struct {
 	analyzers      []Analyzer
 	loader         Loader
 	analyzersCache map[types.UID]Analyzer
+	parallelism    int           // maximum number of sub-objects analyzed concurrently
+	workers        chan struct{} // tokens for the goroutines spawned by RunParallel
+	analyzerDebug  io.Writer     // destination of the analyzer selection explanations, if set
+	maxEvalDepth   int           // maximum number of nested objects analyzed below an evaluated one
+	objectTimeout  time.Duration // maximum time spent on evaluating a single object, if set
+
+	// mtx guards the caches, allowing the analyzers to run concurrently.
+	mtx sync.Mutex
 
 	cache              map[types.UID]*status.Object         // mapping of UID to the object
+	detached           map[types.UID]*status.Object         // objects loaded outside of the namespace caches, e.g. by field selectors
 	nsCache            map[string]*nsCache                  // mapping of namespace to its cache
 	ownership          map[types.UID]map[types.UID]struct{} // mapping of owner UID to the set of owned UIDs
 	ownershipRefreshNs []string                             // indicator to refresh the ownership relations (after a change)
+	warnings           []error                              // non-fatal errors since the last Reset() call
+	availability       map[availabilityKey]error            // results of the API availability checks
+	memo               map[memoKey]status.ObjectStatus      // statuses of the analyzed sub-objects
+}
+
+type availabilityKey struct {
+	gr schema.GroupResource
+	ns string
 }
 
 // NewEvaluator creates a new Evaluator instance.
@@ -69,9 +108,16 @@ func NewEvaluator(analyzerInits []AnalyzerInit, loader Loader) *Evaluator {
 		loader:         loader,
 		analyzersCache: make(map[types.UID]Analyzer),
 
-		cache:     make(map[types.UID]*status.Object),
-		ownership: make(map[types.UID]map[types.UID]struct{}),
-		nsCache:   make(map[string]*nsCache),
+		parallelism:  1,
+		maxEvalDepth: DefaultMaxEvalDepth,
+
+		cache:    make(map[types.UID]*status.Object),
+		detached: make(map[types.UID]*status.Object),
+
+		availability: make(map[availabilityKey]error),
+		memo:         make(map[memoKey]status.ObjectStatus),
+		ownership:    make(map[types.UID]map[types.UID]struct{}),
+		nsCache:      make(map[string]*nsCache),
 	}
 
 	// Initialize the analyzers.
@@ -83,22 +129,42 @@ func NewEvaluator(analyzerInits []AnalyzerInit, loader Loader) *Evaluator {
 	return evaluator
 }
 
+// WithParallelism allows analyzing up to n sub-objects of a query concurrently.
+// Values lower than 2 keep the sequential evaluation, which is the default.
+// The limit applies to the whole evaluation, including the nested analyzers.
+func (e *Evaluator) WithParallelism(n int) *Evaluator {
+	e.parallelism = max(n, 1)
+	e.workers = nil
+	if e.parallelism > 1 {
+		// The calling goroutine counts as one of the workers.
+		e.workers = make(chan struct{}, e.parallelism-1)
+	}
+	return e
+}
+
 // Filter returns the objects from the cache that match the matcher.
 // It expects the objects to be in the cache. This methods is intended
 // to run during evaluation of the Load method in the following order:
 //
-//  1. The Load method runs preloadQuery to fill in the cache.
+//  1. The Load method runs loadNamespace to fill in the cache.
 //  2. The Load method runs Eval on the query spec to get the objects.
 //  3. The Eval method runs Filter to get the objects from the cache.
 //
-// We need to run the preloadQuery before the Eval method to support
+// We need to fill in the cache before the Eval method to support
 // searching for objects based on the ownership relations.
 func (e *Evaluator) Filter(ns string, matcher GroupKindMatcher) []*status.Object {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	return e.filter(ns, matcher)
+}
+
+func (e *Evaluator) filter(ns string, matcher GroupKindMatcher) []*status.Object {
 	ret := []*status.Object{}
 	if ns == NamespaceAll {
 		for ns := range e.nsCache {
 			if ns != NamespaceAll { // prevent infinite recursion
-				ret = append(ret, e.Filter(ns, matcher)...)
+				ret = append(ret, e.filter(ns, matcher)...)
 			}
 		}
 	} else {
@@ -112,10 +178,20 @@ func (e *Evaluator) Filter(ns string, matcher GroupKindMatcher) []*status.Object
 }
 
 func (e *Evaluator) Reset() {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
 	clear(e.cache)
+	clear(e.detached)
+	clear(e.availability)
+	clear(e.memo)
 	clear(e.ownership)
 	clear(e.nsCache)
 	clear(e.ownershipRefreshNs)
+	e.warnings = nil
+	if r, ok := e.loader.(resetter); ok {
+		r.Reset()
+	}
 }
 
 func (e *Evaluator) EvalResource(ctx context.Context, gr schema.GroupResource, namespace string, name string) ([]status.ObjectStatus, error) {
@@ -129,7 +205,7 @@ func (e *Evaluator) EvalResource(ctx context.Context, gr schema.GroupResource, n
 
 func (e *Evaluator) EvalResourceWithSelector(ctx context.Context,
 	gr schema.GroupResource, namespace string, label string) ([]status.ObjectStatus, error) {
-	objects, err := e.loader.LoadResourceBySelector(ctx, gr, namespace, label)
+	objects, err := e.loader.LoadResourceBySelector(ctx, gr, namespace, label, "")
 	if err != nil {
 		return nil, err
 	}
@@ -140,22 +216,44 @@ func (e *Evaluator) EvalResourceWithSelector(ctx context.Context,
 // Evaluates the status of the object. It gets the most recent version
 // of the object and runs the appropriate analyzer on it.
 func (e *Evaluator) Eval(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	ctx, err := e.enterObject(ctx, obj)
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+	ctx, cancel := e.withObjectTimeout(ctx, obj)
+	defer cancel()
 	analyzer := e.findAnalyzer(ctx, obj)
 
-	var updatedObj *status.Object
-
+	e.mtx.Lock()
 	updatedObj, found := e.cache[obj.UID]
+	if !found {
+		updatedObj, found = e.detached[obj.UID]
+	}
+	e.mtx.Unlock()
 
 	if !found {
-		var err error
 		updatedObj, err = e.loader.Get(ctx, obj)
 		if err != nil {
+			noteTimeout(ctx, err, "getting %s/%s", obj.Kind, obj.GetName())
+			if terr := objectTimeoutErr(ctx); terr != nil {
+				err = terr
+			}
 			return status.UnknownStatusWithError(obj, err)
 		}
-		e.updateCache(obj)
+		e.mtx.Lock()
+		if e.updateCache(obj) && !slices.Contains(e.ownershipRefreshNs, obj.GetNamespace()) {
+			// The object might own objects already in the cache.
+			e.ownershipRefreshNs = append(e.ownershipRefreshNs, obj.GetNamespace())
+		}
+		e.mtx.Unlock()
 	}
 
-	return analyzer.Analyze(ctx, updatedObj)
+	os := analyzer.Analyze(ctx, updatedObj)
+	if err := objectTimeoutErr(ctx); err != nil {
+		// The analysis is likely incomplete.
+		return status.UnknownStatusWithError(obj, err)
+	}
+	return os
 }
 
 // EvalQuery loads the objects specified by the query and runs the analyzer.
@@ -170,14 +268,63 @@ func (e *Evaluator) EvalQuery(ctx context.Context, q QuerySpec, analyzer Analyze
 	return e.analyzeObjects(ctx, objects, analyzer), nil
 }
 
+// Warnings returns the non-fatal errors that occurred while loading the objects
+// since the last Reset() call, such as resources not permitted to list.
+func (e *Evaluator) Warnings() []error {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	return slices.Clone(e.warnings)
+}
+
+// CheckAvailable checks whether the resource can be listed in the namespace,
+// returning the error if not. It's meant to be used by the analyzers as
+// a preflight for the resources they depend on, such as the aggregated APIs
+// (e.g. metrics.k8s.io). The result is cached until the next Reset() call.
+func (e *Evaluator) CheckAvailable(ctx context.Context, gr schema.GroupResource, ns string) error {
+	key := availabilityKey{gr: gr, ns: ns}
+	e.mtx.Lock()
+	err, found := e.availability[key]
+	e.mtx.Unlock()
+	if found {
+		return err
+	}
+
+	_, err = e.loader.LoadResourceBySelector(ctx, gr, ns, "", "")
+	if err != nil {
+		klog.V(2).InfoS("resource not available", "resource", gr, "namespace", ns, "error", err)
+	}
+
+	e.mtx.Lock()
+	e.availability[key] = err
+	e.mtx.Unlock()
+	return err
+}
+
+// GetResource loads the named object of the resource, bypassing the cache.
+// It returns nil if the object doesn't exist. Unlike the queries, skipping
+// the resources failing to load, it returns the error when the object can't
+// be loaded (e.g. due to missing permissions), so that the analyzers can tell
+// a missing object from an unknown one.
+func (e *Evaluator) GetResource(ctx context.Context, gr schema.GroupResource, ns, name string) (*status.Object, error) {
+	objs, err := e.loader.LoadResource(ctx, gr, ns, name)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil || len(objs) == 0 {
+		return nil, err
+	}
+	return objs[0], nil
+}
+
 func (e *Evaluator) ResourceToKind(gr schema.GroupResource) schema.GroupVersionKind {
 	return e.loader.ResourceToKind(gr)
 }
 
 // Load loads the objects specified by the query.
 func (e *Evaluator) Load(ctx context.Context, q QuerySpec) ([]*status.Object, error) {
-	if e.getNsCache(q.Namespace()).updateMatcher(q.GroupKindMatcher()) {
-		e.loadNamespace(ctx, q.Namespace())
+	if err := e.loadNamespace(ctx, q.Namespace(), q.GroupKindMatcher()); err != nil {
+		return nil, err
 	}
 
 	objects := q.Eval(ctx, e)
@@ -187,7 +334,13 @@ func (e *Evaluator) Load(ctx context.Context, q QuerySpec) ([]*status.Object, er
 func (e *Evaluator) findAnalyzer(ctx context.Context, obj *status.Object) Analyzer {
 	for _, analyzer := range e.analyzers {
 		if analyzer.Supports(obj) {
+			e.mtx.Lock()
+			_, explained := e.analyzersCache[obj.UID]
 			e.analyzersCache[obj.UID] = analyzer
+			if e.analyzerDebug != nil && !explained {
+				fmt.Fprintln(e.analyzerDebug, e.ExplainAnalyzer(obj))
+			}
+			e.mtx.Unlock()
 			return analyzer
 		}
 	}
@@ -201,18 +354,53 @@ func (e *Evaluator) getNsCache(ns string) *nsCache {
 	return e.nsCache[ns]
 }
 
-func (e *Evaluator) loadNamespace(ctx context.Context, ns string) error {
-	var gksLoaded []schema.GroupKind
+// loadNamespace loads the objects matching the matcher into the cache of
+// the namespace, unless loaded already. The requests are done outside of
+// the evaluator lock, so that the analyzers running in parallel don't wait
+// for each other. Only the loads of the same namespace are serialized,
+// to avoid loading the same objects twice.
+func (e *Evaluator) loadNamespace(ctx context.Context, ns string, matcher GroupKindMatcher) error {
+	e.mtx.Lock()
+	loading := e.getNsCache(ns).loading
+	e.mtx.Unlock()
+
+	select {
+	case loading <- struct{}{}:
+		defer func() { <-loading }()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	e.mtx.Lock()
 	nsCache := e.getNsCache(ns)
-	for gk, _ := range nsCache.objects {
+	prevMatcher := nsCache.matcher
+	if !nsCache.updateMatcher(matcher) {
+		e.mtx.Unlock()
+		return nil
+	}
+	var gksLoaded []schema.GroupKind
+	for gk := range nsCache.objects {
 		gksLoaded = append(gksLoaded, gk)
 	}
+	loadMatcher := nsCache.matcher
+	e.mtx.Unlock()
 
-	var err error
+	objs, err := e.loader.Load(ctx, ns, loadMatcher, gksLoaded)
 
-	objs, err := e.loader.Load(ctx, ns, nsCache.matcher, gksLoaded)
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
 	if err != nil {
-		return err
+		noteTimeout(ctx, err, "loading objects in namespace %q", ns)
+		if ctx.Err() != nil {
+			// Let the following queries load the objects again, as the ctx
+			// might be specific to this query (see WithObjectTimeout).
+			nsCache.matcher = prevMatcher
+			return err
+		}
+		// Some of the resources failed to load (e.g. due to missing permissions):
+		// continue with the objects we've got.
+		klog.V(1).InfoS("loading resources failed partially", "namespace", ns, "error", err)
+		e.warnings = append(e.warnings, err)
 	}
 
 	nsCache.needsRefill = false
@@ -246,15 +434,73 @@ func (e *Evaluator) loadNamespace(ctx context.Context, ns string) error {
 }
 
 func (e *Evaluator) analyzeObjects(ctx context.Context, objects []*status.Object, analyzer Analyzer) []status.ObjectStatus {
+	if e.parallelism > 1 && len(objects) > 1 {
+		return e.analyzeObjectsParallel(ctx, objects, analyzer)
+	}
+
 	var ret []status.ObjectStatus
 	for _, obj := range objects {
-		var a Analyzer
-		if analyzer == nil {
-			a = e.findAnalyzer(ctx, obj)
-		} else {
-			a = analyzer
+		ret = append(ret, e.analyzeObject(ctx, obj, analyzer))
+	}
+	return ret
+}
+
+// analyzeObjectsParallel is a variant of analyzeObjects analyzing the objects
+// concurrently. The order of the results matches the order of the objects.
+func (e *Evaluator) analyzeObjectsParallel(ctx context.Context, objects []*status.Object, analyzer Analyzer) []status.ObjectStatus {
+	return e.RunParallel(len(objects), func(i int) []status.ObjectStatus {
+		return []status.ObjectStatus{e.analyzeObject(ctx, objects[i], analyzer)}
+	})
+}
+
+func (e *Evaluator) analyzeObject(ctx context.Context, obj *status.Object, analyzer Analyzer) status.ObjectStatus {
+	ctx, err := e.enterObject(ctx, obj)
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+	if analyzer == nil {
+		analyzer = e.findAnalyzer(ctx, obj)
+	}
+	if os, found := e.memoized(obj, analyzer); found {
+		return os
+	}
+	os := analyzer.Analyze(ctx, obj)
+	e.memoize(ctx, obj, analyzer, os)
+	return os
+}
+
+// RunParallel calls fn for each index in [0, n), with at most the configured
+// parallelism of the evaluator at a time. The results are concatenated in
+// the order of the indexes. It allows the analyzers to evaluate independent
+// sub-objects concurrently.
+//
+// The workers are shared across the nested calls: when none is available,
+// fn runs in the calling goroutine. This keeps the overall limit without
+// the risk of the nested calls waiting for each other.
+func (e *Evaluator) RunParallel(n int, fn func(i int) []status.ObjectStatus) []status.ObjectStatus {
+	results := make([][]status.ObjectStatus, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		select {
+		case e.workers <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-e.workers
+					wg.Done()
+				}()
+				results[i] = fn(i)
+			}()
+		default:
+			// No spare worker (or sequential evaluation).
+			results[i] = fn(i)
 		}
-		ret = append(ret, a.Analyze(ctx, obj))
+	}
+	wg.Wait()
+
+	var ret []status.ObjectStatus
+	for _, r := range results {
+		ret = append(ret, r...)
 	}
 	return ret
 }
@@ -269,6 +515,9 @@ func (e *Evaluator) updateCache(obj *status.Object) bool {
 }
 
 func (e *Evaluator) filterOwnedBy(owner *status.Object, candidates []*status.Object) []*status.Object {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
 	// Ensure the ownership relations are up-to-date.
 	e.refreshOwnership()
 
@@ -304,11 +553,13 @@ type nsCache struct {
 	objects     map[schema.GroupKind][]*status.Object
 	matcher     GroupKindMatcher
 	needsRefill bool
+	loading     chan struct{} // held while loading the objects of the namespace
 }
 
 func newNsCache() *nsCache {
 	return &nsCache{
 		objects: make(map[schema.GroupKind][]*status.Object),
+		loading: make(chan struct{}, 1),
 	}
 }
 
@@ -0,0 +1,309 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// slowAnalyzer takes some time to analyze each object and tracks
+// the maximum number of concurrent calls.
+type slowAnalyzer struct {
+	delay   func(obj *status.Object) time.Duration
+	running atomic.Int32
+	maxSeen atomic.Int32
+}
+
+func (*slowAnalyzer) Supports(obj *status.Object) bool { return true }
+
+func (a *slowAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	running := a.running.Add(1)
+	defer a.running.Add(-1)
+	for {
+		seen := a.maxSeen.Load()
+		if running <= seen || a.maxSeen.CompareAndSwap(seen, running) {
+			break
+		}
+	}
+
+	time.Sleep(a.delay(obj))
+	return status.OkStatus(obj, nil)
+}
+
+func testConfigMaps(t testing.TB, loader *FakeLoader, count int) {
+	var objs []unstructured.Unstructured
+	for i := range count {
+		objs = append(objs, testConfigMap(fmt.Sprintf("cm%02d", i), fmt.Sprintf("uid-%d", i)))
+	}
+	_, err := loader.Register(objs...)
+	require.NoError(t, err)
+}
+
+var configMapsQuery = KindQuerySpec{
+	GK: NewGroupKindMatcherSingle(schema.GroupKind{Kind: "ConfigMap"}),
+	Ns: "default",
+}
+
+func TestAnalyzeObjectsParallelOrder(t *testing.T) {
+	loader := NewFakeLoader()
+	testConfigMaps(t, loader, 10)
+
+	analyzer := &slowAnalyzer{delay: func(obj *status.Object) time.Duration {
+		// Let the first objects finish last.
+		var i int
+		fmt.Sscanf(obj.Name, "cm%d", &i)
+		return time.Duration(10-i) * time.Millisecond
+	}}
+
+	e := NewEvaluator([]AnalyzerInit{func(*Evaluator) Analyzer { return analyzer }}, loader).
+		WithParallelism(4)
+	statuses, err := e.EvalQuery(t.Context(), configMapsQuery, nil)
+	require.NoError(t, err)
+
+	require.Len(t, statuses, 10)
+	for i, s := range statuses {
+		assert.Equal(t, fmt.Sprintf("cm%02d", i), s.Object.Name)
+	}
+	assert.Greater(t, analyzer.maxSeen.Load(), int32(1))
+	assert.LessOrEqual(t, analyzer.maxSeen.Load(), int32(4))
+}
+
+func TestRunParallelSequentialByDefault(t *testing.T) {
+	e := NewEvaluator(nil, NewFakeLoader())
+
+	var running, maxSeen atomic.Int32
+	e.RunParallel(5, func(i int) []status.ObjectStatus {
+		maxSeen.Store(max(maxSeen.Load(), running.Add(1)))
+		defer running.Add(-1)
+		return nil
+	})
+	assert.Equal(t, int32(1), maxSeen.Load())
+}
+
+func TestRunParallelNestedLimit(t *testing.T) {
+	e := NewEvaluator(nil, NewFakeLoader()).WithParallelism(3)
+
+	var running, maxSeen atomic.Int32
+	leaf := func(i int) []status.ObjectStatus {
+		cur := running.Add(1)
+		defer running.Add(-1)
+		for {
+			seen := maxSeen.Load()
+			if cur <= seen || maxSeen.CompareAndSwap(seen, cur) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		return []status.ObjectStatus{status.OkStatus(&status.Object{}, nil)}
+	}
+
+	// The nested calls share the limit with the outer ones.
+	statuses := e.RunParallel(4, func(i int) []status.ObjectStatus {
+		return e.RunParallel(5, func(j int) []status.ObjectStatus {
+			return e.RunParallel(2, leaf)
+		})
+	})
+	assert.Len(t, statuses, 40)
+	assert.Greater(t, maxSeen.Load(), int32(1))
+	assert.LessOrEqual(t, maxSeen.Load(), int32(3))
+}
+
+// latencyLoader simulates the latency of the API server and tracks
+// the maximum number of concurrent requests.
+type latencyLoader struct {
+	*FakeLoader
+	latency time.Duration
+	loads   atomic.Int32
+	running atomic.Int32
+	maxSeen atomic.Int32
+}
+
+func (l *latencyLoader) request() func() {
+	running := l.running.Add(1)
+	for {
+		seen := l.maxSeen.Load()
+		if running <= seen || l.maxSeen.CompareAndSwap(seen, running) {
+			break
+		}
+	}
+	time.Sleep(l.latency)
+	return func() { l.running.Add(-1) }
+}
+
+func (l *latencyLoader) Load(ctx context.Context, ns string, matcher GroupKindMatcher, exclude []schema.GroupKind) ([]*status.Object, error) {
+	defer l.request()()
+	l.loads.Add(1)
+	return l.FakeLoader.Load(ctx, ns, matcher, exclude)
+}
+
+func (l *latencyLoader) LoadPodLogs(ctx context.Context, obj *status.Object, container string, tailLines int64, previous bool) ([]byte, error) {
+	defer l.request()()
+	return l.FakeLoader.LoadPodLogs(ctx, obj, container, tailLines, previous)
+}
+
+// loadingAnalyzer queries the objects in the namespace given by the name of
+// the analyzed object, the way the analyzers look for the related objects.
+type loadingAnalyzer struct {
+	e *Evaluator
+}
+
+func (*loadingAnalyzer) Supports(obj *status.Object) bool { return true }
+
+func (a *loadingAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	if _, err := a.e.Load(ctx, KindQuerySpec{
+		GK: NewGroupKindMatcherSingle(schema.GroupKind{Kind: "Secret"}),
+		Ns: obj.GetName(),
+	}); err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+	return status.OkStatus(obj, nil)
+}
+
+func TestLoadParallel(t *testing.T) {
+	fake := NewFakeLoader()
+	testConfigMaps(t, fake, 10)
+	loader := &latencyLoader{FakeLoader: fake, latency: 10 * time.Millisecond}
+
+	e := NewEvaluator([]AnalyzerInit{func(e *Evaluator) Analyzer { return &loadingAnalyzer{e} }}, loader).
+		WithParallelism(4)
+	_, err := e.EvalQuery(t.Context(), configMapsQuery, nil)
+	require.NoError(t, err)
+
+	// The requests for different namespaces don't wait for each other.
+	assert.Greater(t, loader.maxSeen.Load(), int32(1))
+	assert.Equal(t, int32(11), loader.loads.Load())
+
+	// The parallel queries for the same namespace load it only once.
+	loader.loads.Store(0)
+	e.RunParallel(8, func(i int) []status.ObjectStatus {
+		_, err := e.Load(t.Context(), KindQuerySpec{
+			GK: NewGroupKindMatcherSingle(schema.GroupKind{Kind: "Secret"}),
+			Ns: "shared",
+		})
+		assert.NoError(t, err)
+		return nil
+	})
+	assert.Equal(t, int32(1), loader.loads.Load())
+}
+
+func BenchmarkAnalyzeObjects(b *testing.B) {
+	for _, parallelism := range []int{1, 8} {
+		b.Run(fmt.Sprintf("parallelism=%d", parallelism), func(b *testing.B) {
+			fake := NewFakeLoader()
+			testConfigMaps(b, fake, 50)
+			loader := &latencyLoader{FakeLoader: fake, latency: time.Millisecond}
+			e := NewEvaluator([]AnalyzerInit{func(e *Evaluator) Analyzer { return &loadingAnalyzer{e} }}, loader).
+				WithParallelism(parallelism)
+
+			for b.Loop() {
+				e.Reset()
+				if _, err := e.EvalQuery(context.Background(), configMapsQuery, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// partialLoader fails to load some of the resources.
+type partialLoader struct {
+	*FakeLoader
+	err error
+}
+
+func (l *partialLoader) Load(ctx context.Context, ns string, matcher GroupKindMatcher, exclude []schema.GroupKind) ([]*status.Object, error) {
+	objs, err := l.FakeLoader.Load(ctx, ns, matcher, exclude)
+	if err != nil {
+		return nil, err
+	}
+	return objs, l.err
+}
+
+func TestEvalQueryPartialLoad(t *testing.T) {
+	fake := NewFakeLoader()
+	testConfigMaps(t, fake, 2)
+	loader := &partialLoader{FakeLoader: fake, err: fmt.Errorf("secrets is forbidden")}
+
+	analyzer := &slowAnalyzer{delay: func(*status.Object) time.Duration { return 0 }}
+	e := NewEvaluator([]AnalyzerInit{func(*Evaluator) Analyzer { return analyzer }}, loader)
+
+	statuses, err := e.EvalQuery(t.Context(), configMapsQuery, nil)
+	require.NoError(t, err)
+	assert.Len(t, statuses, 2)
+	require.Len(t, e.Warnings(), 1)
+	assert.ErrorContains(t, e.Warnings()[0], "secrets is forbidden")
+
+	e.Reset()
+	assert.Empty(t, e.Warnings())
+}
+
+func TestEvalFieldQuery(t *testing.T) {
+	loader := NewFakeLoader()
+	testConfigMaps(t, loader, 3)
+
+	analyzer := &slowAnalyzer{delay: func(*status.Object) time.Duration { return 0 }}
+	e := NewEvaluator([]AnalyzerInit{func(*Evaluator) Analyzer { return analyzer }}, loader)
+
+	statuses, err := e.EvalQuery(t.Context(), FieldQuerySpec{
+		Ns:       "default",
+		GK:       configMapsQuery.GK,
+		Selector: fields.OneTermEqualSelector("metadata.name", "cm01"),
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "cm01", statuses[0].Object.Name)
+
+	// The selected objects don't affect loading the rest of the kind.
+	statuses, err = e.EvalQuery(t.Context(), configMapsQuery, nil)
+	require.NoError(t, err)
+	assert.Len(t, statuses, 3)
+}
+
+// testList wraps the objects into a v1 List, as printed by `kubectl get -o yaml`.
+func testList(items ...unstructured.Unstructured) unstructured.Unstructured {
+	list := unstructured.UnstructuredList{Items: items}
+	list.SetAPIVersion("v1")
+	list.SetKind("List")
+	return unstructured.Unstructured{Object: list.UnstructuredContent()}
+}
+
+func TestEvalListItems(t *testing.T) {
+	loader := NewFakeLoader()
+	objs, err := loader.Register(
+		testList(
+			testConfigMap("cm0", "uid-0"),
+			testList(
+				testConfigMap("cm1", "uid-1"),
+				testConfigMap("cm2", "uid-2"),
+			),
+		),
+		testConfigMap("cm3", "uid-3"),
+	)
+	require.NoError(t, err)
+
+	analyzer := &slowAnalyzer{delay: func(*status.Object) time.Duration { return 0 }}
+	e := NewEvaluator([]AnalyzerInit{func(*Evaluator) Analyzer { return analyzer }}, loader)
+
+	var names []string
+	for _, obj := range objs {
+		os := e.Eval(t.Context(), obj)
+		assert.Equal(t, status.Ok, os.Status().Result)
+		names = append(names, os.Object.GetName())
+	}
+	assert.Equal(t, []string{"cm0", "cm1", "cm2", "cm3"}, names)
+
+	// The items are also available to the queries.
+	statuses, err := e.EvalQuery(t.Context(), configMapsQuery, nil)
+	require.NoError(t, err)
+	assert.Len(t, statuses, 4)
+}
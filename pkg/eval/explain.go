@@ -0,0 +1,79 @@
+package eval
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// AnalyzerExplanation describes how the analyzer for an object was selected.
+// The analyzers are tried in the order of registration (the plugins first,
+// then the built-in ones, the generic analyzer last) and the first one
+// supporting the object wins.
+type AnalyzerExplanation struct {
+	Object *status.Object
+	// Chosen is the name of the selected analyzer, empty if none supports
+	// the object.
+	Chosen string
+	// Position is the index of the chosen analyzer in the registration order.
+	Position int
+	// Total is the number of the registered analyzers.
+	Total int
+	// Supporting lists the names of all the analyzers supporting the object,
+	// in the registration order. The chosen one is the first one.
+	Supporting []string
+}
+
+// ExplainAnalyzer reports which analyzer is used for the object and why.
+func (e *Evaluator) ExplainAnalyzer(obj *status.Object) AnalyzerExplanation {
+	ret := AnalyzerExplanation{Object: obj, Position: -1, Total: len(e.analyzers)}
+	for i, analyzer := range e.analyzers {
+		if !analyzer.Supports(obj) {
+			continue
+		}
+		name := analyzerName(analyzer)
+		if ret.Chosen == "" {
+			ret.Chosen = name
+			ret.Position = i
+		}
+		ret.Supporting = append(ret.Supporting, name)
+	}
+	return ret
+}
+
+// String formats the explanation as a single line, such as:
+//
+//	apps/Deployment default/dp: analyze.DeploymentAnalyzer (analyzer 4 of 15; also supported by: *analyze.GenericAnalyzer)
+func (x AnalyzerExplanation) String() string {
+	gvk := x.Object.GroupVersionKind()
+	kind := gvk.Kind
+	if gvk.Group != "" {
+		kind = gvk.Group + "/" + kind
+	}
+	name := x.Object.GetName()
+	if ns := x.Object.GetNamespace(); ns != "" {
+		name = ns + "/" + name
+	}
+
+	if x.Chosen == "" {
+		return fmt.Sprintf("%s %s: no analyzer supports the object (%d registered)", kind, name, x.Total)
+	}
+	line := fmt.Sprintf("%s %s: %s (analyzer %d of %d", kind, name, x.Chosen, x.Position+1, x.Total)
+	if len(x.Supporting) > 1 {
+		line += "; also supported by: " + strings.Join(x.Supporting[1:], ", ")
+	}
+	return line + ")"
+}
+
+// WithAnalyzerDebug writes the explanation of the analyzer selection
+// (see ExplainAnalyzer) to w, once for each evaluated object.
+func (e *Evaluator) WithAnalyzerDebug(w io.Writer) *Evaluator {
+	e.analyzerDebug = w
+	return e
+}
+
+func analyzerName(a Analyzer) string {
+	return fmt.Sprintf("%T", a)
+}
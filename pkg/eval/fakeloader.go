@@ -2,10 +2,15 @@ package eval
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 
@@ -18,6 +23,8 @@ type FakeLoader struct {
 	cache   map[types.UID]*status.Object
 	nsCache map[string]*nsCache
 	podLogs map[string]string
+	// unavailable resources fail to load with the error
+	unavailable map[schema.GroupResource]error
 
 	// baseTime is used to replace the datetime data
 	// Given we focus mainly on relative values, we want the relative time
@@ -28,22 +35,54 @@ type FakeLoader struct {
 
 func NewFakeLoader() *FakeLoader {
 	return &FakeLoader{
-		cache:    make(map[types.UID]*status.Object),
-		nsCache:  make(map[string]*nsCache),
-		podLogs:  make(map[string]string),
-		baseTime: time.Now().UTC().Add(-24 * time.Hour),
+		cache:       make(map[types.UID]*status.Object),
+		nsCache:     make(map[string]*nsCache),
+		podLogs:     make(map[string]string),
+		unavailable: make(map[schema.GroupResource]error),
+		baseTime:    time.Now().UTC().Add(-24 * time.Hour),
 	}
 }
 
 func (l *FakeLoader) Load(ctx context.Context, ns string, matcher GroupKindMatcher, exclude []schema.GroupKind) ([]*status.Object, error) {
-	var ret []*status.Object
-	nsCache := l.getNsCache(ns)
+	if ns == NamespaceAll {
+		var ret []*status.Object
+		for ns := range l.nsCache {
+			if ns != NamespaceAll {
+				objs, _ := l.Load(ctx, ns, matcher, exclude)
+				ret = append(ret, objs...)
+			}
+		}
+		return ret, nil
+	}
+
+	var (
+		ret  []*status.Object
+		errs []error
+	)
+	// Don't create the missing cache: the loads might run concurrently.
+	nsCache := l.nsCache[ns]
+	if nsCache == nil {
+		return nil, nil
+	}
 	for gk, objects := range nsCache.objects {
-		if matcher.Match(gk) {
-			ret = append(ret, objects...)
+		if !matcher.Match(gk) {
+			continue
+		}
+		// Like the real loader, skip the resources failing to load.
+		if err, found := l.unavailable[fakeGroupResource(gk)]; found {
+			errs = append(errs, err)
+			continue
 		}
+		ret = append(ret, objects...)
 	}
-	return ret, nil
+	return ret, errors.Join(errs...)
+}
+
+// fakeGroupResource guesses the resource of the kind, as there is no
+// discovery to ask.
+func fakeGroupResource(gk schema.GroupKind) schema.GroupResource {
+	plural, _ := meta.UnsafeGuessKindToResource(gk.WithVersion(""))
+	return plural.GroupResource()
 }
 
 func (l *FakeLoader) ResourceToKind(gr schema.GroupResource) schema.GroupVersionKind {
@@ -52,59 +91,97 @@ func (l *FakeLoader) ResourceToKind(gr schema.GroupResource) schema.GroupVersion
 }
 
 func (l *FakeLoader) LoadResource(ctx context.Context, gr schema.GroupResource, namespace string, name string) ([]*status.Object, error) {
+	if err, found := l.unavailable[gr]; found {
+		return nil, err
+	}
 	r := []*status.Object{}
 	for _, v := range l.cache {
-		// this is not exact check (Kind comparison is missing) but right now it's sufficient for
-		// testing
-		if v.Name == name && v.GroupVersionKind().Group == gr.Group && v.Namespace == namespace {
+		if v.Name == name && fakeGroupResource(v.GroupVersionKind().GroupKind()) == gr && v.Namespace == namespace {
 			r = append(r, v)
 		}
 	}
 	return r, nil
 }
 
-func (l *FakeLoader) LoadResourceBySelector(ctx context.Context, gr schema.GroupResource, namespace string, label string) ([]*status.Object, error) {
+func (l *FakeLoader) LoadResourceBySelector(ctx context.Context, gr schema.GroupResource, namespace string, label string, field string) ([]*status.Object, error) {
+	if err, found := l.unavailable[gr]; found {
+		return nil, err
+	}
 	// noop
 	return nil, nil
 }
 
-func (l *FakeLoader) LoadPodLogs(ctx context.Context, obj *status.Object, container string, tailLines int64) ([]byte, error) {
-	logs := l.podLogs[fmt.Sprintf("%s-%s-%s", obj.Namespace, obj.Name, container)]
-	return []byte(logs), nil
+// RegisterUnavailable makes loading the resource fail with the error.
+func (l *FakeLoader) RegisterUnavailable(gr schema.GroupResource, err error) {
+	l.unavailable[gr] = err
+}
+
+func (l *FakeLoader) LoadByFieldSelector(ctx context.Context, ns string, matcher GroupKindMatcher, selector fields.Selector) ([]*status.Object, error) {
+	objs, err := l.Load(ctx, ns, matcher, nil)
+	return filterByFields(objs, selector), err
+}
+
+func (l *FakeLoader) LoadPodLogs(ctx context.Context, obj *status.Object, container string, tailLines int64, previous bool) ([]byte, error) {
+	logs := l.podLogs[podLogsKey(obj.Namespace, obj.Name, container, previous)]
+	lines := strings.SplitAfter(logs, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if tailLines > 0 && int64(len(lines)) > tailLines {
+		lines = lines[int64(len(lines))-tailLines:]
+	}
+	return []byte(strings.Join(lines, "")), nil
 }
 
 func (l *FakeLoader) Get(ctx context.Context, obj *status.Object) (*status.Object, error) {
-	obj, found := l.cache[obj.UID]
+	ret, found := l.cache[obj.UID]
 	if !found {
-		return nil, fmt.Errorf("Object %v not found", obj)
+		gvk := obj.GroupVersionKind()
+		return nil, apierrors.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, obj.GetName())
 	}
 
-	return obj, nil
+	return ret, nil
 }
 
 func (l *FakeLoader) Register(objects ...unstructured.Unstructured) ([]*status.Object, error) {
 	var ret []*status.Object
 	for _, uo := range objects {
 		updateTime(uo, l.baseTime)
-		nsCache := l.getNsCache(uo.GetNamespace())
-		o, err := status.NewObjectFromUnstructured(&uo)
+		// Lists are registered as their items.
+		objs, err := status.NewObjectsFromUnstructured(&uo)
 		if err != nil {
 			return nil, err
 		}
 
-		if o.UID == "" {
-			return nil, fmt.Errorf("Object %#v has no UID provided", uo)
-		}
+		for _, o := range objs {
+			if o.UID == "" {
+				return nil, fmt.Errorf("Object %#v has no UID provided", o.Unstructured)
+			}
 
-		l.cache[o.UID] = o
-		nsCache.append(o)
-		ret = append(ret, o)
+			l.cache[o.UID] = o
+			l.getNsCache(o.GetNamespace()).append(o)
+			ret = append(ret, o)
+		}
 	}
 	return ret, nil
 }
 
 func (f *FakeLoader) RegisterPodLogs(namespace, pod, container, logs string) {
-	f.podLogs[fmt.Sprintf("%s-%s-%s", namespace, pod, container)] = logs
+	f.podLogs[podLogsKey(namespace, pod, container, false)] = logs
+}
+
+// RegisterPreviousPodLogs registers the logs of the previous instance
+// of the container.
+func (f *FakeLoader) RegisterPreviousPodLogs(namespace, pod, container, logs string) {
+	f.podLogs[podLogsKey(namespace, pod, container, true)] = logs
+}
+
+func podLogsKey(namespace, pod, container string, previous bool) string {
+	key := fmt.Sprintf("%s-%s-%s", namespace, pod, container)
+	if previous {
+		key += "-previous"
+	}
+	return key
 }
 
 func (l *FakeLoader) getNsCache(ns string) *nsCache {
@@ -0,0 +1,81 @@
+package eval
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// ServerFieldSelectors lists the fields the API server supports in field
+// selectors per kind, on top of metadata.name and metadata.namespace supported
+// for all kinds. Selectors using other fields are evaluated on the client side.
+var ServerFieldSelectors = map[schema.GroupKind][]string{
+	{Kind: "Pod"}: {
+		"spec.nodeName", "spec.restartPolicy", "spec.schedulerName",
+		"spec.serviceAccountName", "spec.hostNetwork",
+		"status.phase", "status.podIP", "status.nominatedNodeName",
+	},
+	{Kind: "Event"}: {
+		"involvedObject.kind", "involvedObject.namespace", "involvedObject.name",
+		"involvedObject.uid", "involvedObject.apiVersion", "involvedObject.resourceVersion",
+		"involvedObject.fieldPath", "reason", "reportingComponent", "source", "type",
+	},
+	{Kind: "Secret"}:                    {"type"},
+	{Kind: "Namespace"}:                 {"status.phase"},
+	{Kind: "Node"}:                      {"spec.unschedulable"},
+	{Kind: "ReplicationController"}:     {"status.replicas"},
+	{Group: "apps", Kind: "ReplicaSet"}: {"status.replicas"},
+	{Group: "batch", Kind: "Job"}:       {"status.successful"},
+	{Group: "certificates.k8s.io", Kind: "CertificateSigningRequest"}: {"spec.signerName"},
+}
+
+// serverSupportsFieldSelector returns true when all the fields used in the
+// selector can be evaluated by the API server for the kind.
+func serverSupportsFieldSelector(gk schema.GroupKind, selector fields.Selector) bool {
+	for _, req := range selector.Requirements() {
+		switch req.Field {
+		case "metadata.name", "metadata.namespace":
+			continue
+		}
+		if !slices.Contains(ServerFieldSelectors[gk], req.Field) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchesFieldSelector evaluates the field selector against the object on the
+// client side. The fields are addressed by their dot-separated path.
+func MatchesFieldSelector(obj *unstructured.Unstructured, selector fields.Selector) bool {
+	if selector == nil || selector.Empty() {
+		return true
+	}
+
+	set := make(fields.Set)
+	for _, req := range selector.Requirements() {
+		val, found, _ := unstructured.NestedFieldNoCopy(obj.Object, strings.Split(req.Field, ".")...)
+		if found && val != nil {
+			set[req.Field] = fmt.Sprint(val)
+		} else {
+			set[req.Field] = ""
+		}
+	}
+	return selector.Matches(set)
+}
+
+// filterByFields returns the objects matching the field selector.
+func filterByFields(objs []*status.Object, selector fields.Selector) []*status.Object {
+	var ret []*status.Object
+	for _, obj := range objs {
+		if MatchesFieldSelector(obj.Unstructured, selector) {
+			ret = append(ret, obj)
+		}
+	}
+	return ret
+}
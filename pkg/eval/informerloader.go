@@ -0,0 +1,380 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// DefaultInformerSyncTimeout bounds the wait for the initial sync of
+// the informers. The resources not synced in time are listed directly instead.
+const DefaultInformerSyncTimeout = 30 * time.Second
+
+// InformerLoader is a Loader serving the objects from a cache kept up-to-date
+// via watches (informers), instead of listing the resources on every
+// evaluation. It's suitable for long-running processes polling the same
+// resources repeatedly, such as the monitor.
+//
+// The informers are started lazily on the first request for the resources
+// and watch them across all namespaces. After the initial sync, loading
+// the resources doesn't issue any list requests. Only the explicitly requested
+// kinds are watched: the queries for all the kinds (such as the generic owner
+// queries) are passed to the API server, as watching every type of the cluster
+// would be too expensive. The resources whose informer fails to sync (e.g.
+// due to missing permissions) are listed directly as well.
+type InformerLoader struct {
+	client *client
+	// lister serves the requests not covered by the informers.
+	lister      *RealLoader
+	ctx         context.Context // bounds the lifetime of the informers
+	resync      time.Duration
+	syncTimeout time.Duration
+
+	mtx       sync.Mutex
+	informers map[schema.GroupVersionResource]*informerEntry
+}
+
+// informerEntry tracks a started informer.
+type informerEntry struct {
+	informer informers.GenericInformer
+	stop     context.CancelFunc
+	// failed is set once the informer can't sync: it's stopped and
+	// the resource is listed directly from then on.
+	failed error
+}
+
+// NewInformerLoader creates a new InformerLoader. The informers are stopped
+// when the ctx is done. The resync period controls how often the handlers
+// get the full state replayed from the cache: 0 disables resyncing.
+// The consistent lists and the RBAC preflight are not supported, as the
+// informers don't issue the lists these apply to.
+func NewInformerLoader(ctx context.Context, config RESTClientGetter, opts LoaderOptions,
+	resync time.Duration) (*InformerLoader, error) {
+	if opts.ConsistentList {
+		return nil, fmt.Errorf("consistent lists are not supported with informers")
+	}
+	if opts.RBACPreflight {
+		return nil, fmt.Errorf("RBAC preflight is not supported with informers")
+	}
+
+	client, err := newGenericClient(config)
+	if err != nil {
+		return nil, err
+	}
+	client.excludedNamespaces = opts.ExcludedNamespaces
+	client.maxConcurrentLists = opts.MaxConcurrentLists
+
+	return newInformerLoader(ctx, client, resync), nil
+}
+
+func newInformerLoader(ctx context.Context, client *client, resync time.Duration) *InformerLoader {
+	return &InformerLoader{
+		client:      client,
+		lister:      &RealLoader{client: client},
+		ctx:         ctx,
+		resync:      resync,
+		syncTimeout: DefaultInformerSyncTimeout,
+		informers:   make(map[schema.GroupVersionResource]*informerEntry),
+	}
+}
+
+// WithSyncTimeout sets the maximum time to wait for the initial sync of
+// the informers, DefaultInformerSyncTimeout by default.
+func (l *InformerLoader) WithSyncTimeout(timeout time.Duration) *InformerLoader {
+	l.syncTimeout = timeout
+	return l
+}
+
+// synced returns the synced informers for the resources. The missing
+// informers are started together and waited for up to the sync timeout.
+// The resources with failed informers are left out: they are supposed to be
+// listed directly. An error is returned only when the ctx is done.
+func (l *InformerLoader) synced(ctx context.Context,
+	gvrs []schema.GroupVersionResource) (map[schema.GroupVersionResource]informers.GenericInformer, error) {
+	pending := make(map[schema.GroupVersionResource]*informerEntry, len(gvrs))
+	l.mtx.Lock()
+	for _, gvr := range gvrs {
+		entry, found := l.informers[gvr]
+		if !found {
+			entry = l.start(gvr)
+			l.informers[gvr] = entry
+		}
+		if entry.failed == nil {
+			pending[gvr] = entry
+		}
+	}
+	l.mtx.Unlock()
+
+	waitCtx, cancel := context.WithTimeout(ctx, l.syncTimeout)
+	defer cancel()
+
+	ret := make(map[schema.GroupVersionResource]informers.GenericInformer, len(pending))
+	for gvr, entry := range pending {
+		// The informers run in parallel: the wait is bounded by the common deadline.
+		synced := cache.WaitForCacheSync(waitCtx.Done(), func() bool {
+			return entry.informer.Informer().HasSynced() || l.failed(entry)
+		})
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !synced {
+			l.fail(gvr, entry, fmt.Errorf("informer not synced within %s", l.syncTimeout))
+		}
+		if !l.failed(entry) {
+			ret[gvr] = entry.informer
+		}
+	}
+	return ret, nil
+}
+
+// start starts the informer for the resource. It's called with the mtx held.
+func (l *InformerLoader) start(gvr schema.GroupVersionResource) *informerEntry {
+	klog.V(3).InfoS("starting informer", "resource", gvr)
+	ctx, cancel := context.WithCancel(l.ctx)
+	entry := &informerEntry{
+		informer: dynamicinformer.NewFilteredDynamicInformer(l.client.dynamic, gvr, metav1.NamespaceAll, l.resync,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, nil),
+		stop: cancel,
+	}
+
+	// Retrying makes no sense for the errors not going away by themselves.
+	_ = entry.informer.Informer().SetWatchErrorHandlerWithContext(
+		func(ctx context.Context, r *cache.Reflector, err error) {
+			if apierrors.IsForbidden(err) || apierrors.IsNotFound(err) || apierrors.IsMethodNotSupported(err) {
+				l.fail(gvr, entry, err)
+				return
+			}
+			cache.DefaultWatchErrorHandler(ctx, r, err)
+		})
+	go entry.informer.Informer().RunWithContext(ctx)
+	return entry
+}
+
+// fail stops the informer, so that the resource is listed directly.
+func (l *InformerLoader) fail(gvr schema.GroupVersionResource, entry *informerEntry, err error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if entry.failed != nil {
+		return
+	}
+	klog.V(2).InfoS("informer failed, listing the resource directly", "resource", gvr, "error", err)
+	entry.failed = err
+	entry.stop()
+}
+
+func (l *InformerLoader) failed(entry *informerEntry) bool {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return entry.failed != nil
+}
+
+// informer returns the synced informer for the resource, or nil if
+// the resource should be listed directly.
+func (l *InformerLoader) informer(ctx context.Context, gvr schema.GroupVersionResource) (informers.GenericInformer, error) {
+	synced, err := l.synced(ctx, []schema.GroupVersionResource{gvr})
+	if err != nil {
+		return nil, err
+	}
+	return synced[gvr], nil
+}
+
+func (l *InformerLoader) Get(ctx context.Context, obj *status.Object) (*status.Object, error) {
+	mapping, err := l.client.mapper.RESTMapping(obj.GroupVersionKind().GroupKind())
+	if err != nil {
+		return nil, fmt.Errorf("failed to map object: %w", err)
+	}
+
+	inf, err := l.informer(ctx, mapping.Resource)
+	if err != nil {
+		return nil, err
+	}
+	if inf == nil {
+		return l.lister.Get(ctx, obj)
+	}
+
+	objs, err := l.fromCache(inf, mappedNamespace(mapping, obj), obj.GetName(), labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	if len(objs) == 0 {
+		// Match the API server response, so that the callers can tell the
+		// object was deleted.
+		return nil, apierrors.NewNotFound(mapping.Resource.GroupResource(), obj.GetName())
+	}
+	return objs[0], nil
+}
+
+func (l *InformerLoader) Load(ctx context.Context, ns string, matcher GroupKindMatcher, exclude []schema.GroupKind) ([]*status.Object, error) {
+	if len(matcher.IncludedKinds) == 0 {
+		// Not worth watching all the kinds, see InformerLoader.
+		return l.lister.Load(ctx, ns, matcher, exclude)
+	}
+
+	resources := l.client.compileGroupKindMatcher(matcher, ns)
+	if len(exclude) > 0 {
+		resources = l.client.filterResources(resources, true, nil, exclude)
+	}
+	gvrs := resources.toSlice()
+	synced, err := l.synced(ctx, gvrs)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		ret      []*status.Object
+		errs     []error
+		uncached []schema.GroupVersionResource
+	)
+	for _, gvr := range gvrs {
+		inf, found := synced[gvr]
+		if !found {
+			uncached = append(uncached, gvr)
+			continue
+		}
+		objs, err := l.fromCache(inf, ns, "", labels.Everything())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("listing resources failed (%s): %w", gvr, err))
+			continue
+		}
+		ret = append(ret, objs...)
+	}
+
+	// The error might be partial: keep the objects loaded successfully.
+	unsts, err := l.client.listBulk(ctx, ns, uncached)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	errs = append(errs, err)
+	for _, unst := range unsts {
+		obj, err := status.NewObjectFromUnstructured(unst)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, obj)
+	}
+	return ret, errors.Join(errs...)
+}
+
+func (l *InformerLoader) LoadPodLogs(ctx context.Context, obj *status.Object, container string, tailLines int64, previous bool) ([]byte, error) {
+	return l.client.podLogs(ctx, obj, container, tailLines, previous)
+}
+
+func (l *InformerLoader) LoadResource(ctx context.Context, gr schema.GroupResource, namespace string, name string) ([]*status.Object, error) {
+	inf, err := l.informer(ctx, l.gvr(gr))
+	if err != nil {
+		return nil, err
+	}
+	if inf == nil {
+		return l.lister.LoadResource(ctx, gr, namespace, name)
+	}
+	return l.fromCache(inf, namespace, name, labels.Everything())
+}
+
+func (l *InformerLoader) LoadResourceBySelector(ctx context.Context,
+	gr schema.GroupResource, namespace string, labelSelector string, fieldSelector string) ([]*status.Object, error) {
+	selector, err := labels.Parse(labelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector %q: %w", labelSelector, err)
+	}
+	fieldSel, err := fields.ParseSelector(fieldSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid field selector %q: %w", fieldSelector, err)
+	}
+
+	inf, err := l.informer(ctx, l.gvr(gr))
+	if err != nil {
+		return nil, err
+	}
+	if inf == nil {
+		return l.lister.LoadResourceBySelector(ctx, gr, namespace, labelSelector, fieldSelector)
+	}
+	objs, err := l.fromCache(inf, namespace, "", selector)
+	if err != nil {
+		return nil, err
+	}
+	return filterByFields(objs, fieldSel), nil
+}
+
+// LoadByFieldSelector evaluates the selector against the cached objects.
+func (l *InformerLoader) LoadByFieldSelector(ctx context.Context, ns string,
+	matcher GroupKindMatcher, selector fields.Selector) ([]*status.Object, error) {
+	if len(matcher.IncludedKinds) == 0 {
+		return l.lister.LoadByFieldSelector(ctx, ns, matcher, selector)
+	}
+	objs, err := l.Load(ctx, ns, matcher, nil)
+	return filterByFields(objs, selector), err
+}
+
+func (l *InformerLoader) ResourceToKind(gr schema.GroupResource) schema.GroupVersionKind {
+	return l.client.resources[gr].GroupVersionKind
+}
+
+func (l *InformerLoader) gvr(gr schema.GroupResource) schema.GroupVersionResource {
+	return gr.WithVersion(l.client.resources[gr].Version)
+}
+
+// fromCache returns the objects of the resource from the informer cache.
+// If name is set, only the object with the name is returned.
+func (l *InformerLoader) fromCache(inf informers.GenericInformer,
+	ns, name string, selector labels.Selector) ([]*status.Object, error) {
+	allNs := ns == NamespaceAll || ns == NamespaceNone
+	var (
+		items []runtime.Object
+		err   error
+	)
+	switch {
+	case name != "":
+		key := name
+		if !allNs && ns != "" {
+			key = ns + "/" + name
+		}
+		item, exists, err := inf.Informer().GetIndexer().GetByKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			items = append(items, item.(runtime.Object))
+		}
+	case allNs:
+		items, err = inf.Lister().List(selector)
+	default:
+		items, err = inf.Lister().ByNamespace(ns).List(selector)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*status.Object, 0, len(items))
+	for _, item := range items {
+		unst, ok := item.(*unstructured.Unstructured)
+		if !ok {
+			return nil, fmt.Errorf("expected *unstructured.Unstructured, got %T", item)
+		}
+		if ns == NamespaceAll && l.client.isExcludedNamespace(unst.GetNamespace()) {
+			continue
+		}
+		// The cached objects are shared: work on a copy.
+		obj, err := status.NewObjectFromUnstructured(unst.DeepCopy())
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, obj)
+	}
+	return ret, nil
+}
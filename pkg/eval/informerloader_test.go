@@ -0,0 +1,145 @@
+package eval
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestInformerLoader(t *testing.T) {
+	dynamic := createDynamicFakeClientWithObjects(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: test1Name, Namespace: testNS}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "kube-proxy", Namespace: "kube-system"}},
+	)
+	countLists := func() int {
+		count := 0
+		for _, action := range dynamic.Actions() {
+			if action.GetVerb() == "list" {
+				count++
+			}
+		}
+		return count
+	}
+
+	c := &client{
+		dynamic:            dynamic,
+		resources:          allTestResources,
+		excludedNamespaces: DefaultExcludedNamespaces,
+	}
+	l := newInformerLoader(t.Context(), c, 0)
+	matcher := NewGroupKindMatcherSingle(podGVK.GroupKind())
+
+	objs, err := l.Load(t.Context(), testNS, matcher, nil)
+	require.NoError(t, err)
+	require.Len(t, objs, 1)
+	assert.Equal(t, test1Name, objs[0].Name)
+	assert.Equal(t, 1, countLists())
+
+	// The following loads are served from the cache.
+	objs, err = l.Load(t.Context(), NamespaceAll, matcher, nil)
+	require.NoError(t, err)
+	require.Len(t, objs, 1)
+
+	objs, err = l.LoadResource(t.Context(), podGR, "kube-system", "kube-proxy")
+	require.NoError(t, err)
+	require.Len(t, objs, 1)
+	assert.Equal(t, "kube-proxy", objs[0].Name)
+	assert.Equal(t, 1, countLists())
+
+	// The changes get propagated via the watch.
+	pod, err := runtime.DefaultUnstructuredConverter.ToUnstructured(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-2", Namespace: testNS}})
+	require.NoError(t, err)
+	_, err = dynamic.Resource(podGVK.GroupVersion().WithResource(podGR.Resource)).Namespace(testNS).
+		Create(t.Context(), &unstructured.Unstructured{Object: pod}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		objs, err := l.Load(t.Context(), testNS, matcher, nil)
+		return err == nil && len(objs) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, countLists())
+}
+
+func TestInformerLoaderIncludeAll(t *testing.T) {
+	dynamic := createDynamicFakeClientWithObjects(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: test1Name, Namespace: testNS}},
+	)
+	c := &client{dynamic: dynamic, resources: resourcesMap{podGR: allTestResources[podGR]}}
+	l := newInformerLoader(t.Context(), c, 0)
+
+	// The queries for all the kinds are listed directly, without watching
+	// every type of the cluster.
+	objs, err := l.Load(t.Context(), testNS, GroupKindMatcher{IncludeAll: true}, nil)
+	require.NoError(t, err)
+	require.Len(t, objs, 1)
+	assert.Empty(t, l.informers)
+}
+
+func TestInformerLoaderFailedSync(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+	}{
+		// Not retried, even though the sync timeout is long.
+		{"forbidden", apierrors.NewForbidden(podGR, "", errors.New("denied"))},
+		// Retried until the sync timeout.
+		{"transient", apierrors.NewInternalError(errors.New("unavailable"))},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dynamic := createDynamicFakeClientWithObjects(
+				&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: test1Name, Namespace: testNS}},
+			)
+			var failing atomic.Bool
+			failing.Store(true)
+			dynamic.PrependReactor("list", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				if failing.Load() {
+					return true, nil, tc.err
+				}
+				return false, nil, nil
+			})
+
+			c := &client{dynamic: dynamic, resources: allTestResources}
+			syncTimeout := 5 * time.Second
+			if !apierrors.IsForbidden(tc.err) {
+				syncTimeout = 200 * time.Millisecond
+			}
+			l := newInformerLoader(t.Context(), c, 0).WithSyncTimeout(syncTimeout)
+			matcher := NewGroupKindMatcherSingle(podGVK.GroupKind())
+
+			start := time.Now()
+			_, err := l.Load(t.Context(), testNS, matcher, nil)
+			require.Error(t, err)
+			assert.Less(t, time.Since(start), 4*time.Second)
+			require.Contains(t, l.informers, podGVK.GroupVersion().WithResource(podGR.Resource))
+			assert.Error(t, l.informers[podGVK.GroupVersion().WithResource(podGR.Resource)].failed)
+
+			// The failed informer is not waited for again: the resource
+			// is listed directly.
+			failing.Store(false)
+			start = time.Now()
+			objs, err := l.Load(t.Context(), testNS, matcher, nil)
+			require.NoError(t, err)
+			require.Len(t, objs, 1)
+			assert.Less(t, time.Since(start), syncTimeout/2)
+		})
+	}
+}
+
+func TestInformerLoaderUnsupportedOptions(t *testing.T) {
+	_, err := NewInformerLoader(t.Context(), nil, LoaderOptions{ConsistentList: true}, 0)
+	assert.ErrorContains(t, err, "consistent lists are not supported")
+	_, err = NewInformerLoader(t.Context(), nil, LoaderOptions{RBACPreflight: true}, 0)
+	assert.ErrorContains(t, err, "RBAC preflight is not supported")
+}
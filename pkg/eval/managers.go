@@ -0,0 +1,125 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// FieldManagersCondition is the type of the condition added to failing objects
+// listing the field managers that touched their spec and status.
+const FieldManagersCondition = "FieldManagers"
+
+// FieldManager is a manager (usually a controller or a client) that recently
+// changed the object, based on the metadata.managedFields.
+type FieldManager struct {
+	Manager   string
+	Operation string
+	Time      time.Time
+	// Fields are the paths of the spec/status fields owned by the manager,
+	// up to the second level (e.g. "spec.replicas").
+	Fields []string
+}
+
+func (m FieldManager) String() string {
+	return fmt.Sprintf("%s (%s: %s)", m.Manager, m.Operation, strings.Join(m.Fields, ", "))
+}
+
+// attributedFields are the top-level fields the managers are reported for.
+var attributedFields = []string{"spec", "status"}
+
+// FieldManagers returns the managers owning some of the spec or status fields
+// of the object, the most recent first.
+func FieldManagers(obj *status.Object) []FieldManager {
+	var ret []FieldManager
+	for _, entry := range obj.Unstructured.GetManagedFields() {
+		if entry.FieldsV1 == nil {
+			continue
+		}
+		fields, err := managedFieldPaths(entry.FieldsV1.Raw)
+		if err != nil || len(fields) == 0 {
+			continue
+		}
+
+		m := FieldManager{
+			Manager:   entry.Manager,
+			Operation: string(entry.Operation),
+			Fields:    fields,
+		}
+		if entry.Time != nil {
+			m.Time = entry.Time.Time
+		}
+		ret = append(ret, m)
+	}
+
+	slices.SortStableFunc(ret, func(a, b FieldManager) int {
+		return b.Time.Compare(a.Time)
+	})
+	return ret
+}
+
+// managedFieldPaths extracts the paths of the attributed fields from
+// the FieldsV1 set, such as {"f:spec":{"f:replicas":{}}}.
+func managedFieldPaths(raw []byte) ([]string, error) {
+	var set map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return nil, err
+	}
+
+	var ret []string
+	for _, field := range attributedFields {
+		children, found := set["f:"+field]
+		if !found {
+			continue
+		}
+		var paths []string
+		for child := range children {
+			// Skip the "." (the field itself) and the list/map keys.
+			if name, ok := strings.CutPrefix(child, "f:"); ok {
+				paths = append(paths, field+"."+name)
+			}
+		}
+		if len(paths) == 0 {
+			paths = append(paths, field)
+		}
+		slices.Sort(paths)
+		ret = append(ret, paths...)
+	}
+	return ret, nil
+}
+
+// markFieldManagers adds a condition listing the field managers to the status
+// of a failing object. Healthy objects are left untouched. Given the object
+// is failing already, the warning doesn't change its result.
+func markFieldManagers(os status.ObjectStatus) status.ObjectStatus {
+	if os.Status().Result <= status.Ok || os.Object.Unstructured == nil {
+		return os
+	}
+	managers := FieldManagers(os.Object)
+	if len(managers) == 0 {
+		return os
+	}
+
+	msgs := make([]string, 0, len(managers))
+	for _, m := range managers {
+		msgs = append(msgs, m.String())
+	}
+	cond := status.ConditionStatus{
+		Condition: &metav1.Condition{
+			Type:               FieldManagersCondition,
+			Status:             metav1.ConditionTrue,
+			Reason:             "ManagedFields",
+			Message:            "Last changed by: " + strings.Join(msgs, "; "),
+			LastTransitionTime: metav1.NewTime(managers[0].Time),
+		},
+		CondStatus: &status.Status{Result: status.Warning, Status: status.Warning.String()},
+	}
+	os.Conditions = append(os.Conditions, cond)
+	return os
+}
@@ -0,0 +1,80 @@
+package eval
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func testManagedObject(t *testing.T) *status.Object {
+	u := testConfigMap("cm1", "uid-1")
+	now := time.Now()
+	u.SetManagedFields([]metav1.ManagedFieldsEntry{
+		{
+			Manager:    "kubectl",
+			Operation:  metav1.ManagedFieldsOperationApply,
+			Time:       &metav1.Time{Time: now.Add(-time.Hour)},
+			FieldsType: "FieldsV1",
+			FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:replicas":{},"f:template":{"f:spec":{}}}}`)},
+		},
+		{
+			Manager:    "my-operator",
+			Operation:  metav1.ManagedFieldsOperationUpdate,
+			Time:       &metav1.Time{Time: now.Add(-time.Minute)},
+			FieldsType: "FieldsV1",
+			FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:replicas":{}}}`)},
+		},
+		{
+			Manager:     "kube-controller-manager",
+			Operation:   metav1.ManagedFieldsOperationUpdate,
+			Subresource: "status",
+			Time:        &metav1.Time{Time: now.Add(-10 * time.Minute)},
+			FieldsType:  "FieldsV1",
+			FieldsV1:    &metav1.FieldsV1{Raw: []byte(`{"f:status":{".":{},"f:conditions":{}}}`)},
+		},
+		{
+			// Metadata only: not attributed.
+			Manager:    "labeler",
+			Operation:  metav1.ManagedFieldsOperationUpdate,
+			Time:       &metav1.Time{Time: now},
+			FieldsType: "FieldsV1",
+			FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:labels":{}}}`)},
+		},
+	})
+	obj, err := status.NewObjectFromUnstructured(&u)
+	require.NoError(t, err)
+	return obj
+}
+
+func TestFieldManagers(t *testing.T) {
+	managers := FieldManagers(testManagedObject(t))
+	require.Len(t, managers, 3)
+
+	assert.Equal(t, "my-operator (Update: spec.replicas)", managers[0].String())
+	assert.Equal(t, "kube-controller-manager (Update: status.conditions)", managers[1].String())
+	assert.Equal(t, "kubectl (Apply: spec.replicas, spec.template)", managers[2].String())
+}
+
+func TestMarkFieldManagers(t *testing.T) {
+	obj := testManagedObject(t)
+
+	// Healthy objects are not annotated.
+	os := markFieldManagers(status.OkStatus(obj, nil))
+	assert.Empty(t, os.Conditions)
+
+	os = markFieldManagers(status.ObjectStatus{
+		Object:    obj,
+		ObjStatus: status.Status{Result: status.Error, Status: status.Error.String()},
+	})
+	assert.Equal(t, status.Error, os.Status().Result)
+	cond := status.GetCondition(os.Conditions, FieldManagersCondition)
+	require.NotNil(t, cond)
+	assert.Equal(t, "Last changed by: my-operator (Update: spec.replicas); "+
+		"kube-controller-manager (Update: status.conditions); "+
+		"kubectl (Apply: spec.replicas, spec.template)", cond.Message)
+}
@@ -0,0 +1,45 @@
+package eval
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// memoKey identifies the analysis of an object. The analyzer is part of it,
+// as the same object can be analyzed differently by different parents.
+type memoKey struct {
+	uid      types.UID
+	analyzer string
+}
+
+// memoized returns the status of the object computed earlier in the same
+// evaluation cycle (since the last Reset() call), if any.
+//
+// It prevents analyzing the sub-objects shared by multiple parents
+// repeatedly, e.g. a Pod selected by several Services in a monitor run
+// with overlapping targets.
+func (e *Evaluator) memoized(obj *status.Object, analyzer Analyzer) (status.ObjectStatus, bool) {
+	if obj.UID == "" {
+		return status.ObjectStatus{}, false
+	}
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	os, found := e.memo[memoKey{uid: obj.UID, analyzer: analyzerName(analyzer)}]
+	return os, found
+}
+
+// memoize stores the status of the object for the rest of the evaluation cycle.
+func (e *Evaluator) memoize(ctx context.Context, obj *status.Object, analyzer Analyzer, os status.ObjectStatus) {
+	// The objects without UID (e.g. the synthetic ones) can't be told apart.
+	// The analysis interrupted by a timeout or cancellation is incomplete.
+	// The one cut by the cycle or depth guard depends on the path.
+	if obj.UID == "" || ctx.Err() != nil || pathTruncated(ctx) {
+		return
+	}
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	e.memo[memoKey{uid: obj.UID, analyzer: analyzerName(analyzer)}] = os
+}
@@ -0,0 +1,60 @@
+package eval
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// countingAnalyzer is like ownedAnalyzer, counting the analyzed objects.
+type countingAnalyzer struct {
+	ownedAnalyzer
+
+	mtx    *sync.Mutex
+	counts map[string]int
+}
+
+func (a countingAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	a.mtx.Lock()
+	a.counts[obj.GetName()]++
+	a.mtx.Unlock()
+	return a.ownedAnalyzer.Analyze(ctx, obj)
+}
+
+func TestEvalMemoizesSharedSubObjects(t *testing.T) {
+	shared := testConfigMap("shared", "uid-shared")
+	shared.SetOwnerReferences([]metav1.OwnerReference{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "a", UID: types.UID("uid-a")},
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "b", UID: types.UID("uid-b")},
+	})
+
+	loader := NewFakeLoader()
+	objs, err := loader.Register(testConfigMap("a", "uid-a"), testConfigMap("b", "uid-b"), shared)
+	require.NoError(t, err)
+
+	counts := make(map[string]int)
+	evaluator := NewEvaluator([]AnalyzerInit{
+		func(e *Evaluator) Analyzer {
+			return countingAnalyzer{ownedAnalyzer: ownedAnalyzer{e: e}, mtx: &sync.Mutex{}, counts: counts}
+		},
+	}, loader)
+
+	for _, obj := range objs[:2] {
+		os := evaluator.Eval(t.Context(), obj)
+		require.Len(t, os.SubStatuses, 1)
+		assert.Equal(t, "shared", os.SubStatuses[0].Object.GetName())
+	}
+	assert.Equal(t, 1, counts["shared"])
+
+	// The next run analyzes the object again.
+	evaluator.Reset()
+	evaluator.Eval(t.Context(), objs[0])
+	assert.Equal(t, 2, counts["shared"])
+}
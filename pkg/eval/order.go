@@ -0,0 +1,48 @@
+package eval
+
+import (
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// OrderByDependencies returns the order in which to evaluate the objects so
+// that the owners go before the objects they own, based on the ownerReferences.
+//
+// Evaluating an owner loads its sub-objects into the evaluator cache, so
+// the owned objects evaluated later don't need to be loaded again.
+// The returned slice contains the indexes to the objects. Apart from the
+// dependencies, the original order is kept.
+func OrderByDependencies(objects []*status.Object) []int {
+	byUID := make(map[types.UID]int, len(objects))
+	for i, obj := range objects {
+		if _, found := byUID[obj.UID]; !found {
+			byUID[obj.UID] = i
+		}
+	}
+
+	ret := make([]int, 0, len(objects))
+	visited := make([]bool, len(objects))
+	onPath := make([]bool, len(objects)) // protection against cyclic references
+
+	var visit func(i int)
+	visit = func(i int) {
+		if visited[i] || onPath[i] {
+			return
+		}
+		onPath[i] = true
+		for _, ref := range objects[i].GetOwnerReferences() {
+			if owner, found := byUID[ref.UID]; found && owner != i {
+				visit(owner)
+			}
+		}
+		onPath[i] = false
+		visited[i] = true
+		ret = append(ret, i)
+	}
+
+	for i := range objects {
+		visit(i)
+	}
+	return ret
+}
@@ -0,0 +1,102 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// OrphanedCondition is the type of the condition added to objects whose
+// owners no longer exist.
+const OrphanedCondition = "Orphaned"
+
+// MissingOwners returns the owner references of the object pointing to
+// objects that don't exist (anymore). An owner recreated with the same name
+// but a different UID is considered missing as well.
+//
+// The owners are looked up in the evaluator cache first, falling back
+// to loading them from the cluster.
+func (e *Evaluator) MissingOwners(ctx context.Context, obj *status.Object) ([]metav1.OwnerReference, error) {
+	var ret []metav1.OwnerReference
+	for _, ref := range obj.GetOwnerReferences() {
+		e.mtx.Lock()
+		_, found := e.cache[ref.UID]
+		if !found {
+			_, found = e.detached[ref.UID]
+		}
+		e.mtx.Unlock()
+		if found {
+			continue
+		}
+
+		owner, err := e.loader.Get(ctx, ownerStub(obj, ref))
+		if apierrors.IsNotFound(err) {
+			ret = append(ret, ref)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load owner %s/%s: %w", ref.Kind, ref.Name, err)
+		}
+		if owner.UID != ref.UID {
+			ret = append(ret, ref)
+			continue
+		}
+
+		// Other objects are likely to share the owner.
+		e.mtx.Lock()
+		e.detached[owner.UID] = owner
+		e.mtx.Unlock()
+	}
+	return ret, nil
+}
+
+// ownerStub returns an object identifying the owner to load.
+func ownerStub(obj *status.Object, ref metav1.OwnerReference) *status.Object {
+	return &status.Object{
+		TypeMeta: metav1.TypeMeta{APIVersion: ref.APIVersion, Kind: ref.Kind},
+		ObjectMeta: metav1.ObjectMeta{
+			// The loader ignores the namespace for cluster-scoped owners.
+			Namespace: obj.GetNamespace(),
+			Name:      ref.Name,
+			UID:       ref.UID,
+		},
+	}
+}
+
+// markOrphaned adds a warning condition to the status of an object whose
+// owners no longer exist. When the owners can't be checked, the status
+// is left untouched.
+func markOrphaned(ctx context.Context, e *Evaluator, os status.ObjectStatus) status.ObjectStatus {
+	if len(os.Object.GetOwnerReferences()) == 0 {
+		return os
+	}
+	missing, err := e.MissingOwners(ctx, os.Object)
+	if err != nil || len(missing) == 0 {
+		return os
+	}
+
+	owners := make([]string, 0, len(missing))
+	for _, ref := range missing {
+		owners = append(owners, fmt.Sprintf("%s/%s", ref.Kind, ref.Name))
+	}
+	cond := status.ConditionStatus{
+		Condition: &metav1.Condition{
+			Type:    OrphanedCondition,
+			Status:  metav1.ConditionTrue,
+			Reason:  "OwnerNotFound",
+			Message: "Owner no longer exists: " + strings.Join(owners, ", "),
+		},
+		CondStatus: &status.Status{Result: status.Warning, Status: status.Warning.String()},
+	}
+	os.Conditions = append(os.Conditions, cond)
+	if os.ObjStatus.Result < status.Warning {
+		os.ObjStatus.Result = status.Warning
+		os.ObjStatus.Status = status.Warning.String()
+	}
+	return os
+}
@@ -0,0 +1,50 @@
+package eval
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestStatusPollerOrphans(t *testing.T) {
+	owned := func(name, uid, ownerName, ownerUID string) unstructured.Unstructured {
+		u := testConfigMap(name, uid)
+		u.SetOwnerReferences([]metav1.OwnerReference{
+			{APIVersion: "v1", Kind: "ConfigMap", Name: ownerName, UID: types.UID(ownerUID)},
+		})
+		return u
+	}
+
+	loader := NewFakeLoader()
+	objs, err := loader.Register(
+		testConfigMap("owner", "uid-owner"),
+		owned("cm1", "uid-1", "owner", "uid-owner"),
+		owned("cm2", "uid-2", "gone", "uid-gone"),
+	)
+	require.NoError(t, err)
+
+	evaluator := NewEvaluator([]AnalyzerInit{
+		func(*Evaluator) Analyzer { return okAnalyzer{} },
+	}, loader)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	update := <-NewStatusPoller(0, evaluator, objs[1:]).WithOrphans().Start(ctx)
+	require.Len(t, update.Statuses, 2)
+
+	assert.Equal(t, status.Ok, update.Statuses[0].Status().Result)
+	assert.Nil(t, status.GetCondition(update.Statuses[0].Conditions, OrphanedCondition))
+
+	assert.Equal(t, status.Warning, update.Statuses[1].Status().Result)
+	cond := status.GetCondition(update.Statuses[1].Conditions, OrphanedCondition)
+	require.NotNil(t, cond)
+	assert.Equal(t, "OwnerNotFound", cond.Reason)
+	assert.Equal(t, "Owner no longer exists: ConfigMap/gone", cond.Message)
+}
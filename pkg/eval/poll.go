@@ -2,17 +2,47 @@ package eval
 
 import (
 	"context"
+	"math/rand/v2"
 	"time"
 
 	"github.com/rhobs/kube-health/pkg/status"
 )
 
+// DefaultPollJitter is the default maximum factor by which the monitor
+// randomly changes its intervals, e.g. 0.1 for ±10%. It keeps multiple
+// pollers from hitting the API at the same time.
+const DefaultPollJitter = 0.1
+
+// JitterInterval returns the interval randomly lengthened or shortened by up
+// to the factor, e.g. between 54s and 66s for a minute and 0.1. This way
+// the average interval stays the same.
+func JitterInterval(interval time.Duration, factor float64) time.Duration {
+	if factor <= 0 || interval <= 0 {
+		return interval
+	}
+	return interval + time.Duration(float64(interval)*factor*(2*rand.Float64()-1))
+}
+
 // StatusPoller polls the status of a set of objects at a regular interval.
 type StatusPoller struct {
 	interval  time.Duration
 	evaluator *Evaluator
 	objects   []*status.Object
 	eventChan chan StatusUpdate
+
+	// duplicates tracks objects sharing the same identity.
+	duplicates map[ObjectIdentity][]*status.Object
+
+	// streaming enables sending partial updates after each evaluated object.
+	streaming bool
+	// dependencyOrder enables evaluating the owners before the owned objects.
+	dependencyOrder bool
+	// fieldManagers enables reporting the field managers of the failing objects.
+	fieldManagers bool
+	// orphans enables reporting the objects whose owners no longer exist.
+	orphans bool
+	// jitter is the maximum factor to change the interval by.
+	jitter float64
 }
 
 func NewStatusPoller(interval time.Duration, evaluator *Evaluator, objects []*status.Object) *StatusPoller {
@@ -21,12 +51,64 @@ func NewStatusPoller(interval time.Duration, evaluator *Evaluator, objects []*st
 		evaluator: evaluator,
 		objects:   objects,
 		eventChan: make(chan StatusUpdate),
+
+		duplicates: FindDuplicates(objects),
 	}
 }
 
+// WithStreaming makes the poller send a partial update after each evaluated
+// object, before the full update at the end of each run. It allows showing
+// the first results sooner when evaluating many objects.
+func (s *StatusPoller) WithStreaming() *StatusPoller {
+	s.streaming = true
+	return s
+}
+
+// WithDependencyOrder makes the poller evaluate the owners before the objects
+// they own (see OrderByDependencies), reducing the number of objects to load
+// when both are passed. The updates keep the original order of the objects.
+func (s *StatusPoller) WithDependencyOrder() *StatusPoller {
+	s.dependencyOrder = true
+	return s
+}
+
+// WithFieldManagers makes the poller report which field managers last changed
+// the spec and status of the failing objects (see FieldManagers). It helps
+// diagnosing conflicting controllers.
+func (s *StatusPoller) WithFieldManagers() *StatusPoller {
+	s.fieldManagers = true
+	return s
+}
+
+// WithOrphans makes the poller report the objects whose owners (based on
+// the ownerReferences) no longer exist, as they are likely leftovers.
+func (s *StatusPoller) WithOrphans() *StatusPoller {
+	s.orphans = true
+	return s
+}
+
+// WithJitter sets the maximum factor by which the interval is randomly
+// changed between the runs (see JitterInterval). The interval is kept
+// as is by default.
+func (s *StatusPoller) WithJitter(factor float64) *StatusPoller {
+	s.jitter = factor
+	return s
+}
+
 type StatusUpdate struct {
 	Statuses []status.ObjectStatus
 	Error    error
+	// Warnings are the non-fatal errors hit during the evaluation, such as
+	// resources not permitted to list. The statuses are still reported.
+	Warnings []error
+	// Partial is true when the update contains only the objects evaluated
+	// so far in the current run.
+	Partial bool
+}
+
+// Score returns the health score of the update. See status.Score for details.
+func (u StatusUpdate) Score(weights status.ScoreWeights) float64 {
+	return status.Score(u.Statuses, weights)
 }
 
 // Start starts the poller and returns a channel that will receive status updates.
@@ -41,7 +123,7 @@ func (s *StatusPoller) Start(ctx context.Context) <-chan StatusUpdate {
 			select {
 			case <-ctx.Done():
 				return
-			case <-time.After(s.interval):
+			case <-time.After(s.nextDelay()):
 				s.run(ctx)
 			}
 		}
@@ -50,16 +132,65 @@ func (s *StatusPoller) Start(ctx context.Context) <-chan StatusUpdate {
 	return s.eventChan
 }
 
+// nextDelay returns the delay before the next run.
+func (s *StatusPoller) nextDelay() time.Duration {
+	return JitterInterval(s.interval, s.jitter)
+}
+
 func (s *StatusPoller) run(ctx context.Context) {
 	// Reset the evaluator to clear the cache from previous run.
 	s.evaluator.Reset()
 
-	statuses := make([]status.ObjectStatus, 0, len(s.objects))
-	for _, obj := range s.objects {
-		statuses = append(statuses, s.evaluator.Eval(ctx, obj))
+	order := s.evalOrder()
+	results := make([]*status.ObjectStatus, len(s.objects))
+	for n, i := range order {
+		obj := s.objects[i]
+		os := s.evaluator.Eval(ctx, obj)
+		if dups, found := s.duplicates[IdentityOf(obj)]; found {
+			os = markDuplicate(os, len(dups))
+		}
+		if s.orphans {
+			os = markOrphaned(ctx, s.evaluator, os)
+		}
+		if s.fieldManagers {
+			os = markFieldManagers(os)
+		}
+		results[i] = &os
+
+		if s.streaming && n < len(order)-1 {
+			s.eventChan <- StatusUpdate{
+				Statuses: collectStatuses(results),
+				Partial:  true,
+			}
+		}
 	}
 
 	s.eventChan <- StatusUpdate{
-		Statuses: statuses,
+		Statuses: collectStatuses(results),
+		Warnings: s.evaluator.Warnings(),
+	}
+}
+
+// evalOrder returns the indexes of the objects in the order of evaluation.
+func (s *StatusPoller) evalOrder() []int {
+	if s.dependencyOrder {
+		return OrderByDependencies(s.objects)
+	}
+	order := make([]int, len(s.objects))
+	for i := range order {
+		order[i] = i
+	}
+	return order
+}
+
+// collectStatuses returns the statuses evaluated so far, in the original order.
+// The consumers might reorder the statuses: it always returns a new slice.
+func collectStatuses(results []*status.ObjectStatus) []status.ObjectStatus {
+	ret := make([]status.ObjectStatus, 0, len(results))
+	for _, os := range results {
+		if os != nil {
+			ret = append(ret, *os)
+		}
 	}
+	return ret
 }
@@ -0,0 +1,265 @@
+package eval
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+type okAnalyzer struct{}
+
+func (okAnalyzer) Supports(obj *status.Object) bool { return true }
+
+func (okAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	return status.OkStatus(obj, nil)
+}
+
+func testConfigMap(name, uid string) unstructured.Unstructured {
+	u := unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind("ConfigMap")
+	u.SetNamespace("default")
+	u.SetName(name)
+	u.SetUID(types.UID(uid))
+	return u
+}
+
+func TestStatusPollerDuplicates(t *testing.T) {
+	loader := NewFakeLoader()
+	objs, err := loader.Register(
+		testConfigMap("cm1", "uid-1"),
+		testConfigMap("cm1", "uid-2"),
+		testConfigMap("cm2", "uid-3"),
+	)
+	require.NoError(t, err)
+
+	// The same object passed twice is not a duplicate, nor counted twice.
+	objs = append(objs, objs[2], objs[0])
+
+	evaluator := NewEvaluator([]AnalyzerInit{
+		func(*Evaluator) Analyzer { return okAnalyzer{} },
+	}, loader)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	update := <-NewStatusPoller(0, evaluator, objs).Start(ctx)
+	require.Len(t, update.Statuses, 5)
+
+	for _, os := range []status.ObjectStatus{update.Statuses[0], update.Statuses[1], update.Statuses[4]} {
+		assert.Equal(t, status.Warning, os.Status().Result)
+		cond := status.GetCondition(os.Conditions, DuplicateCondition)
+		require.NotNil(t, cond)
+		assert.Equal(t, "2 objects share the identity default/ConfigMap/cm1", cond.Message)
+	}
+
+	for _, os := range update.Statuses[2:4] {
+		assert.Equal(t, status.Ok, os.Status().Result)
+		assert.Nil(t, status.GetCondition(os.Conditions, DuplicateCondition))
+	}
+}
+
+func TestFindDuplicatesWithoutUID(t *testing.T) {
+	// Objects coming from overlapping manifests don't have UIDs.
+	var objs []*status.Object
+	for range 2 {
+		u := testConfigMap("cm1", "")
+		obj, err := status.NewObjectFromUnstructured(&u)
+		require.NoError(t, err)
+		objs = append(objs, obj)
+	}
+
+	dups := FindDuplicates(objs)
+	assert.Len(t, dups[IdentityOf(objs[0])], 2)
+}
+
+func TestFindDuplicatesSameObject(t *testing.T) {
+	var objs []*status.Object
+	for _, uid := range []string{"uid-1", "uid-2", "uid-2", "uid-3"} {
+		u := testConfigMap("cm1", uid)
+		obj, err := status.NewObjectFromUnstructured(&u)
+		require.NoError(t, err)
+		objs = append(objs, obj)
+	}
+
+	// The object passed twice counts once.
+	dups := FindDuplicates(objs)
+	assert.Len(t, dups[IdentityOf(objs[0])], 3)
+
+	// Only the same object, passed several times.
+	assert.Empty(t, FindDuplicates([]*status.Object{objs[1], objs[2]}))
+}
+
+func TestStatusPollerJitter(t *testing.T) {
+	// No jitter by default: waiting polls at the interval asked for.
+	poller := NewStatusPoller(time.Minute, nil, nil)
+	assert.Equal(t, time.Minute, poller.nextDelay())
+
+	poller.WithJitter(DefaultPollJitter)
+	var shorter, longer bool
+	for range 50 {
+		delay := poller.nextDelay()
+		assert.GreaterOrEqual(t, delay, 54*time.Second)
+		assert.LessOrEqual(t, delay, 66*time.Second)
+		shorter = shorter || delay < time.Minute
+		longer = longer || delay > time.Minute
+	}
+	assert.True(t, shorter)
+	assert.True(t, longer)
+
+	poller.WithJitter(0)
+	assert.Equal(t, time.Minute, poller.nextDelay())
+}
+
+// blockingAnalyzer waits for a signal before analyzing the blocked object.
+type blockingAnalyzer struct {
+	blocked string
+	unblock chan struct{}
+}
+
+func (blockingAnalyzer) Supports(obj *status.Object) bool { return true }
+
+func (a blockingAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	if obj.GetName() == a.blocked {
+		<-a.unblock
+	}
+	return status.OkStatus(obj, nil)
+}
+
+func TestStatusPollerStreaming(t *testing.T) {
+	loader := NewFakeLoader()
+	objs, err := loader.Register(
+		testConfigMap("cm1", "uid-1"),
+		testConfigMap("cm2", "uid-2"),
+		testConfigMap("cm3", "uid-3"),
+	)
+	require.NoError(t, err)
+
+	analyzer := blockingAnalyzer{blocked: "cm2", unblock: make(chan struct{})}
+	evaluator := NewEvaluator([]AnalyzerInit{
+		func(*Evaluator) Analyzer { return analyzer },
+	}, loader)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	updates := NewStatusPoller(time.Hour, evaluator, objs).WithStreaming().Start(ctx)
+
+	// The first object is reported while the second one is still being evaluated.
+	update := <-updates
+	assert.True(t, update.Partial)
+	require.Len(t, update.Statuses, 1)
+	assert.Equal(t, "cm1", update.Statuses[0].Object.GetName())
+
+	close(analyzer.unblock)
+
+	update = <-updates
+	assert.True(t, update.Partial)
+	assert.Len(t, update.Statuses, 2)
+
+	update = <-updates
+	assert.False(t, update.Partial)
+	assert.Len(t, update.Statuses, 3)
+}
+
+// countingLoader counts the objects loaded one by one.
+type countingLoader struct {
+	*FakeLoader
+	gets atomic.Int32
+}
+
+func (l *countingLoader) Get(ctx context.Context, obj *status.Object) (*status.Object, error) {
+	l.gets.Add(1)
+	return l.FakeLoader.Get(ctx, obj)
+}
+
+// ownerAnalyzer evaluates the objects owned by the root objects.
+type ownerAnalyzer struct {
+	e *Evaluator
+}
+
+func (ownerAnalyzer) Supports(obj *status.Object) bool { return true }
+
+func (a ownerAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	if len(obj.GetOwnerReferences()) > 0 {
+		return status.OkStatus(obj, nil)
+	}
+	subStatuses, err := a.e.EvalQuery(ctx, OwnerQuerySpec{
+		Object: obj,
+		GK:     GroupKindMatcher{IncludeAll: true},
+	}, nil)
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+	return status.OkStatus(obj, subStatuses)
+}
+
+func TestStatusPollerDependencyOrder(t *testing.T) {
+	owned := testConfigMap("owned", "uid-owned")
+	owned.SetOwnerReferences([]metav1.OwnerReference{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "owner", UID: "uid-owner"},
+	})
+
+	poll := func(dependencyOrder bool) (StatusUpdate, int32) {
+		loader := &countingLoader{FakeLoader: NewFakeLoader()}
+		// The owned object goes first.
+		objs, err := loader.Register(owned, testConfigMap("owner", "uid-owner"))
+		require.NoError(t, err)
+
+		evaluator := NewEvaluator([]AnalyzerInit{
+			func(e *Evaluator) Analyzer { return ownerAnalyzer{e: e} },
+		}, loader)
+		poller := NewStatusPoller(time.Hour, evaluator, objs)
+		if dependencyOrder {
+			poller.WithDependencyOrder()
+		}
+
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		return <-poller.Start(ctx), loader.gets.Load()
+	}
+
+	unordered, unorderedGets := poll(false)
+	ordered, orderedGets := poll(true)
+
+	// The results are the same, in the original order.
+	require.Len(t, ordered.Statuses, 2)
+	for i, os := range ordered.Statuses {
+		assert.Equal(t, unordered.Statuses[i].Object.GetName(), os.Object.GetName())
+		assert.Equal(t, unordered.Statuses[i].Status(), os.Status())
+		assert.Len(t, os.SubStatuses, len(unordered.Statuses[i].SubStatuses))
+	}
+	assert.Equal(t, "owned", ordered.Statuses[0].Object.GetName())
+	assert.Len(t, ordered.Statuses[1].SubStatuses, 1)
+
+	// The owned object is already loaded with the owner.
+	assert.Equal(t, int32(2), unorderedGets)
+	assert.Equal(t, int32(1), orderedGets)
+}
+
+func TestOrderByDependencies(t *testing.T) {
+	var objs []*status.Object
+	for _, u := range []unstructured.Unstructured{
+		testConfigMap("pod", "uid-pod"),
+		testConfigMap("rs", "uid-rs"),
+		testConfigMap("deploy", "uid-deploy"),
+		testConfigMap("other", "uid-other"),
+	} {
+		obj, err := status.NewObjectFromUnstructured(&u)
+		require.NoError(t, err)
+		objs = append(objs, obj)
+	}
+	objs[0].SetOwnerReferences([]metav1.OwnerReference{{UID: "uid-rs"}})
+	objs[1].SetOwnerReferences([]metav1.OwnerReference{{UID: "uid-deploy"}})
+	// Cyclic references don't break the ordering.
+	objs[2].SetOwnerReferences([]metav1.OwnerReference{{UID: "uid-pod"}})
+
+	assert.Equal(t, []int{2, 1, 0, 3}, OrderByDependencies(objs))
+}
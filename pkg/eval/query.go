@@ -8,6 +8,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/klog/v2"
@@ -39,7 +40,8 @@ type QuerySpec interface {
 	// Eval evaluates the query and returns the objects.
 	// If applicable, the loader is already preloaded with the objects based
 	// on the GroupKindMatcher and Namespace. It's still the repsonsibility
-	// of the Eval method to do the final filtering.
+	// of the Eval method to do the final filtering. It's called without
+	// holding the evaluator lock, so that it can query the loader directly.
 	Eval(ctx context.Context, e *Evaluator) []*status.Object
 }
 
@@ -75,7 +77,13 @@ func (m GroupKindMatcher) Merge(other GroupKindMatcher) GroupKindMatcher {
 	// If any of the matchers includes all kinds, the result will include all kinds.
 	if !m.IncludeAll && !other.IncludeAll {
 		includedKinds = append(includedKinds, m.IncludedKinds...)
-		includedKinds = append(includedKinds, other.IncludedKinds...)
+		for _, gk := range other.IncludedKinds {
+			// Keep the merged matcher equal to the original one when
+			// the kinds are already included.
+			if !slices.Contains(includedKinds, gk) {
+				includedKinds = append(includedKinds, gk)
+			}
+		}
 	} else {
 		includeAll = true
 	}
@@ -192,6 +200,37 @@ func (qs KindQuerySpec) Eval(ctx context.Context, e *Evaluator) []*status.Object
 	return e.Filter(qs.Namespace(), qs.GK)
 }
 
+// SelectorQuerySpec is a query that returns objects of the kinds matching
+// the label selector. Unlike LabelQuerySpec, it's not bound to an object.
+type SelectorQuerySpec struct {
+	GK       GroupKindMatcher
+	Ns       string
+	Selector labels.Selector
+}
+
+func (qs SelectorQuerySpec) Namespace() string {
+	return qs.Ns
+}
+
+func (qs SelectorQuerySpec) GroupKindMatcher() GroupKindMatcher {
+	return qs.GK
+}
+
+func (qs SelectorQuerySpec) Eval(ctx context.Context, e *Evaluator) []*status.Object {
+	candidates := e.Filter(qs.Ns, qs.GK)
+	if qs.Selector == nil {
+		return candidates
+	}
+
+	var ret []*status.Object
+	for _, cand := range candidates {
+		if qs.Selector.Matches(labels.Set(cand.GetLabels())) {
+			ret = append(ret, cand)
+		}
+	}
+	return ret
+}
+
 // OwnerQuerySpec is a query that returns objects owned by the specified object.
 type OwnerQuerySpec struct {
 	Object *status.Object
@@ -265,6 +304,48 @@ func (qs LabelQuerySpec) Eval(ctx context.Context, e *Evaluator) []*status.Objec
 	return ret
 }
 
+// FieldQuerySpec is a query that returns objects based on the field selector,
+// such as `status.phase=Running` or `spec.nodeName=node1`.
+//
+// Unlike the other queries, it doesn't load all the objects of the matching
+// kinds: the selector is passed to the API server where supported (see
+// ServerFieldSelectors). The objects loaded this way are not used to answer
+// other queries.
+type FieldQuerySpec struct {
+	Ns       string
+	GK       GroupKindMatcher
+	Selector fields.Selector
+}
+
+func (qs FieldQuerySpec) GroupKindMatcher() GroupKindMatcher {
+	// Empty matcher: the objects are loaded in the Eval method.
+	return GroupKindMatcher{}
+}
+
+func (qs FieldQuerySpec) Namespace() string {
+	return qs.Ns
+}
+
+func (qs FieldQuerySpec) Eval(ctx context.Context, e *Evaluator) []*status.Object {
+	if qs.Selector == nil {
+		return nil
+	}
+
+	objs, err := e.loader.LoadByFieldSelector(ctx, qs.Ns, qs.GK, qs.Selector)
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	if err != nil {
+		noteTimeout(ctx, err, "loading objects by field selector %q", qs.Selector)
+		klog.V(1).InfoS("loading resources by field selector failed", "selector", qs.Selector, "error", err)
+		e.warnings = append(e.warnings, err)
+	}
+	for _, obj := range objs {
+		e.detached[obj.UID] = obj
+	}
+	return objs
+}
+
 func NewSelectorLabelQuerySpec(obj *status.Object, gk schema.GroupKind) LabelQuerySpec {
 	return LabelQuerySpec{
 		Object:   obj,
@@ -319,10 +400,14 @@ func buildSelector(obj *status.Object, mode labelSelectorMode, path ...string) (
 }
 
 // RefQuerySpec is a query that returns objects referenced by the specified object.
-// It assumes the reference to be in the same namespace.
+// It assumes the reference to be in the same namespace, unless overridden.
 type RefQuerySpec struct {
 	Object    *status.Object
 	RefObject corev1.ObjectReference
+	// NamespaceOverride specifies the namespace of the referenced object,
+	// e.g. NamespaceNone for the cluster-scoped ones. If nil, the namespace
+	// of the Object is used.
+	NamespaceOverride *string
 }
 
 func (qs RefQuerySpec) GroupKindMatcher() GroupKindMatcher {
@@ -334,11 +419,14 @@ func (qs RefQuerySpec) GroupKindMatcher() GroupKindMatcher {
 }
 
 func (qs RefQuerySpec) Namespace() string {
+	if qs.NamespaceOverride != nil {
+		return *qs.NamespaceOverride
+	}
 	return qs.Object.GetNamespace()
 }
 
 func (qs RefQuerySpec) Eval(ctx context.Context, e *Evaluator) []*status.Object {
-	candidates := e.Filter(qs.Object.GetNamespace(), qs.GroupKindMatcher())
+	candidates := e.Filter(qs.Namespace(), qs.GroupKindMatcher())
 	var ret []*status.Object
 
 	for _, cand := range candidates {
@@ -355,8 +443,18 @@ func (qs RefQuerySpec) Eval(ctx context.Context, e *Evaluator) []*status.Object
 type PodLogQuerySpec struct {
 	Object    *status.Object
 	Container string
+	// TailLines is the number of lines loaded from the end of the logs.
+	// DefaultLogTailLines is used when not set.
+	TailLines int64
+	// Previous loads the logs of the previous instance of the container,
+	// e.g. after a restart.
+	Previous bool
 }
 
+// DefaultLogTailLines is the number of log lines loaded by PodLogQuerySpec
+// unless set explicitly.
+const DefaultLogTailLines int64 = 5
+
 func (qs PodLogQuerySpec) GroupKindMatcher() GroupKindMatcher {
 	// Empty matcher: we don't want load any objects implicitly.
 	return GroupKindMatcher{}
@@ -368,8 +466,13 @@ func (qs PodLogQuerySpec) Namespace() string {
 
 func (qs PodLogQuerySpec) Eval(ctx context.Context, e *Evaluator) []*status.Object {
 	data := make(map[string]interface{}, 1)
-	logs, err := e.loader.LoadPodLogs(ctx, qs.Object, qs.Container, 5)
+	tailLines := qs.TailLines
+	if tailLines <= 0 {
+		tailLines = DefaultLogTailLines
+	}
+	logs, err := e.loader.LoadPodLogs(ctx, qs.Object, qs.Container, tailLines, qs.Previous)
 	if err != nil {
+		noteTimeout(ctx, err, "loading logs of Pod/%s container %s", qs.Object.GetName(), qs.Container)
 		klog.V(4).ErrorS(err, "Failed to get logs", "object", qs.Object)
 	} else {
 		data["log"] = string(logs)
@@ -2,17 +2,25 @@ package eval
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"path"
 	"slices"
+	"strings"
 	"sync"
 
+	"golang.org/x/sync/errgroup"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	discoveryclient "k8s.io/client-go/discovery"
 	dynamicclient "k8s.io/client-go/dynamic"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
@@ -20,18 +28,90 @@ import (
 	"github.com/rhobs/kube-health/pkg/status"
 )
 
+// DefaultMaxConcurrentLists is the recommended limit of parallel list requests.
+const DefaultMaxConcurrentLists = 16
+
 // RealLoader is responsible for loading the objects from the cluster.
 type RealLoader struct {
-	client *client
+	client  *client
+	skipped []schema.GroupResource
+}
+
+// LoaderOptions allows tuning the way the RealLoader queries the cluster.
+type LoaderOptions struct {
+	// ConsistentList pins all the list requests to the same resourceVersion
+	// until the next Reset, so that a single evaluation reflects a coherent
+	// state of the cluster. APIs not supporting exact resourceVersion matching
+	// fall back to listing the most recent state.
+	ConsistentList bool
+
+	// RBACPreflight checks up front which of the discovered resources
+	// the user is allowed to list and ignores the rest, instead of failing
+	// with Forbidden errors during the evaluation.
+	RBACPreflight bool
+	// PreflightNamespace is the namespace to check the namespaced resources
+	// against. If empty, the access across all namespaces is checked.
+	PreflightNamespace string
+
+	// ExcludedNamespaces lists the namespaces whose objects are skipped when
+	// listing across all namespaces. Shell patterns (e.g. "openshift-*") are
+	// supported. Explicitly requested namespaces are always loaded.
+	ExcludedNamespaces []string
+
+	// MaxConcurrentLists limits the number of list (and access review) requests
+	// running in parallel when loading multiple resources at once. Lower it when
+	// running against rate-limited API servers. Zero means unbounded.
+	MaxConcurrentLists int
+}
+
+// DefaultExcludedNamespaces are the system namespaces that usually only add
+// noise when checking the whole cluster.
+var DefaultExcludedNamespaces = []string{
+	"kube-system",
+	"kube-public",
+	"kube-node-lease",
+	"openshift",
+	"openshift-*",
 }
 
-func NewRealLoader(config RESTClientGetter) (*RealLoader, error) {
+// NewRealLoader creates a loader querying the cluster. The context bounds
+// the requests done up front, i.e. the RBAC preflight check.
+func NewRealLoader(ctx context.Context, config RESTClientGetter, opts LoaderOptions) (*RealLoader, error) {
 	client, err := newGenericClient(config)
 	if err != nil {
 		return nil, err
 	}
+	client.consistentList = opts.ConsistentList
+	client.maxConcurrentLists = opts.MaxConcurrentLists
+
+	for _, pattern := range opts.ExcludedNamespaces {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid excluded namespace pattern %q: %w", pattern, err)
+		}
+	}
+	client.excludedNamespaces = opts.ExcludedNamespaces
+
+	loader := &RealLoader{client: client}
+	if opts.RBACPreflight {
+		loader.skipped, err = client.preflight(ctx, opts.PreflightNamespace)
+		if err != nil {
+			return nil, fmt.Errorf("RBAC preflight check failed: %w", err)
+		}
+	}
+
+	return loader, nil
+}
+
+// Skipped returns the resources ignored by the RBAC preflight check
+// because the user is not allowed to list them.
+func (l *RealLoader) Skipped() []schema.GroupResource {
+	return l.skipped
+}
 
-	return &RealLoader{client: client}, nil
+// Reset releases the resourceVersion pinned when using consistent lists.
+// It's called by the Evaluator at the beginning of each evaluation cycle.
+func (l *RealLoader) Reset() {
+	l.client.resetSnapshot()
 }
 
 // Get returns the updated version of the object. If the object is not
@@ -54,11 +134,8 @@ func (l *RealLoader) Get(ctx context.Context, obj *status.Object) (*status.Objec
 // to evaluator.
 func (l *RealLoader) Load(ctx context.Context, ns string, matcher GroupKindMatcher, exclude []schema.GroupKind) ([]*status.Object, error) {
 	var ret []*status.Object
-	unsts, err := l.client.listWithMatcher(ctx, ns, matcher, exclude)
-
-	if err != nil {
-		return nil, err
-	}
+	// The error might be partial: keep the objects loaded successfully.
+	unsts, listErr := l.client.listWithMatcher(ctx, ns, matcher, exclude)
 
 	for _, unst := range unsts {
 		obj, err := status.NewObjectFromUnstructured(unst)
@@ -68,11 +145,38 @@ func (l *RealLoader) Load(ctx context.Context, ns string, matcher GroupKindMatch
 		ret = append(ret, obj)
 	}
 
-	return ret, nil
+	return ret, listErr
+}
+
+func (l *RealLoader) LoadByFieldSelector(ctx context.Context, ns string,
+	matcher GroupKindMatcher, selector fields.Selector) ([]*status.Object, error) {
+	var (
+		ret  []*status.Object
+		errs []error
+	)
+	resources := l.client.compileGroupKindMatcher(matcher, ns)
+	for _, gvr := range resources.toSlice() {
+		unsts, err := l.client.listByFieldSelector(ctx, gvr, ns, selector)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			errs = append(errs, err)
+			continue
+		}
+		for _, unst := range unsts {
+			obj, err := status.NewObjectFromUnstructured(unst)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, obj)
+		}
+	}
+	return ret, errors.Join(errs...)
 }
 
-func (l *RealLoader) LoadPodLogs(ctx context.Context, obj *status.Object, container string, tailLines int64) ([]byte, error) {
-	return l.client.podLogs(ctx, obj, container, tailLines)
+func (l *RealLoader) LoadPodLogs(ctx context.Context, obj *status.Object, container string, tailLines int64, previous bool) ([]byte, error) {
+	return l.client.podLogs(ctx, obj, container, tailLines, previous)
 }
 
 func (l *RealLoader) ResourceToKind(gr schema.GroupResource) schema.GroupVersionKind {
@@ -80,15 +184,19 @@ func (l *RealLoader) ResourceToKind(gr schema.GroupResource) schema.GroupVersion
 }
 
 func (l *RealLoader) LoadResourceBySelector(ctx context.Context,
-	gr schema.GroupResource, namespace string, labelSelector string) ([]*status.Object, error) {
-	gvk := l.client.resources[gr].GroupVersionKind
+	gr schema.GroupResource, namespace string, labelSelector string, fieldSelector string) ([]*status.Object, error) {
+	res, found := l.client.resources[gr]
+	if !found {
+		return nil, fmt.Errorf("resource %s not served by the API server", gr)
+	}
+	gvk := res.GroupVersionKind
 	gvr := schema.GroupVersionResource{
 		Group:    gr.Group,
 		Version:  gvk.Version,
 		Resource: gr.Resource,
 	}
 
-	unsts, err := l.client.listWithSelector(ctx, gvr, namespace, labelSelector)
+	unsts, err := l.client.listWithSelector(ctx, gvr, namespace, labelSelector, fieldSelector)
 	if err != nil {
 		return nil, err
 	}
@@ -106,7 +214,12 @@ func (l *RealLoader) LoadResourceBySelector(ctx context.Context,
 }
 
 func (l *RealLoader) LoadResource(ctx context.Context, gr schema.GroupResource, namespace string, name string) ([]*status.Object, error) {
-	gvk := l.client.resources[gr].GroupVersionKind
+	res, found := l.client.resources[gr]
+	if !found {
+		// Not discovered, or skipped by the RBAC preflight check.
+		return nil, fmt.Errorf("resource %s not served by the API server", gr)
+	}
+	gvk := res.GroupVersionKind
 
 	gvr := schema.GroupVersionResource{
 		Group:    gr.Group,
@@ -159,7 +272,15 @@ type client struct {
 	dynamic      dynamicclient.Interface
 	mapper       meta.RESTMapper
 	corev1client corev1client.CoreV1Interface
+	authzclient  authorizationv1client.AuthorizationV1Interface
 	resources    resourcesMap
+
+	consistentList bool
+	snapshotMtx    sync.Mutex
+	snapshotRV     string // resourceVersion shared by the lists when consistentList is set
+
+	excludedNamespaces []string
+	maxConcurrentLists int // zero means unbounded
 }
 
 func newGenericClient(clientGetter RESTClientGetter) (*client, error) {
@@ -183,6 +304,11 @@ func newGenericClient(clientGetter RESTClientGetter) (*client, error) {
 		return nil, fmt.Errorf("failed to create corev1 client: %w", err)
 	}
 
+	authzclient, err := authorizationv1client.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create authorization client: %w", err)
+	}
+
 	mapper, err := clientGetter.ToRESTMapper()
 	if err != nil {
 		return nil, err
@@ -191,6 +317,7 @@ func newGenericClient(clientGetter RESTClientGetter) (*client, error) {
 	ret := &client{
 		dynamic:      dynamic,
 		corev1client: coreclient,
+		authzclient:  authzclient,
 		mapper:       mapper,
 		resources:    make(resourcesMap),
 	}
@@ -243,6 +370,71 @@ func (c *client) discover(discovery discoveryclient.DiscoveryInterface) error {
 	return nil
 }
 
+// preflight removes the resources the user is not allowed to list from
+// the discovered resources, using SelfSubjectAccessReview. It returns
+// the removed resources. A resource whose check fails is kept: the access
+// is unknown rather than denied, and listing it will tell. Only the
+// cancellation of the context fails the whole preflight.
+func (c *client) preflight(ctx context.Context, ns string) ([]schema.GroupResource, error) {
+	var mtx sync.Mutex
+	var denied []schema.GroupResource
+
+	g, gctx := errgroup.WithContext(ctx)
+	if c.maxConcurrentLists > 0 {
+		g.SetLimit(c.maxConcurrentLists)
+	}
+	for gr, gvk := range c.resources {
+		g.Go(func() error {
+			allowed, err := c.canList(gctx, gr, gvk.namespaced, ns)
+			if err != nil {
+				if ctxErr := gctx.Err(); ctxErr != nil {
+					return ctxErr
+				}
+				klog.V(2).ErrorS(err, "Checking access failed, keeping the resource", "resource", gr)
+				return nil
+			}
+			if !allowed {
+				mtx.Lock()
+				denied = append(denied, gr)
+				mtx.Unlock()
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	for _, gr := range denied {
+		delete(c.resources, gr)
+	}
+	slices.SortFunc(denied, func(a, b schema.GroupResource) int {
+		return strings.Compare(a.String(), b.String())
+	})
+
+	klog.V(2).InfoS("RBAC preflight finished", "allowed", len(c.resources), "denied", denied)
+	return denied, nil
+}
+
+func (c *client) canList(ctx context.Context, gr schema.GroupResource, namespaced bool, ns string) (bool, error) {
+	attrs := &authorizationv1.ResourceAttributes{
+		Verb:     "list",
+		Group:    gr.Group,
+		Resource: gr.Resource,
+	}
+	if namespaced {
+		attrs.Namespace = ns
+	}
+
+	review, err := c.authzclient.SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: attrs},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	return review.Status.Allowed, nil
+}
+
 // listWithMatcher lists all resources that match the given matcher.
 // We support additional filtering by excluding some GroupKinds, to skip loading
 // objects that are matched by the matcher, but we want to avoid them (for example
@@ -305,55 +497,70 @@ func (c *client) filterResources(resources resourcesMap,
 }
 
 // listBulk lists all objects of the resources in the given namespace.
-// The loading happens in parallel. If any of the resources fails to load,
-// we return an error. We return the first error that occurred.
+// The loading happens in parallel, with at most maxConcurrentLists requests
+// at a time. A failure to list some of the resources (e.g. due to missing
+// permissions) doesn't prevent loading the rest of them: the objects loaded
+// successfully are returned together with the errors joined.
+// When the ctx is done, only the ctx error is returned.
 func (c *client) listBulk(ctx context.Context, ns string, resources []schema.GroupVersionResource) ([]*unstructured.Unstructured, error) {
 	if len(resources) == 0 {
 		return nil, nil
 	}
-	resultsChan := make(chan []*unstructured.Unstructured)
-	doneChan := make(chan struct{})
-	wg := sync.WaitGroup{}
 
-	var out []*unstructured.Unstructured
-	go func() {
-		for res := range resultsChan {
-			out = append(out, res...)
+	var (
+		mu   sync.Mutex
+		out  []*unstructured.Unstructured
+		errs []error
+	)
+	if c.consistentList && c.snapshotResourceVersion() == "" {
+		// Load the first resource on its own to pin the resourceVersion
+		// the rest of the resources will be listed at.
+		res, err := c.list(ctx, resources[0], ns)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("listing resources failed (%s): %w", resources[0], err))
 		}
-		close(doneChan)
-	}()
+		out = res
+		resources = resources[1:]
+	}
 
 	klog.V(3).InfoS("starting to query resources", "count", len(resources))
-	var errResult error
 
+	var g errgroup.Group
+	if c.maxConcurrentLists > 0 {
+		g.SetLimit(c.maxConcurrentLists)
+	}
 	for _, resource := range resources {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
+		g.Go(func() error {
 			res, err := c.list(ctx, resource, ns)
+			mu.Lock()
+			defer mu.Unlock()
 			if err != nil {
-				// We only return one error.
-				errResult = fmt.Errorf("listing resources failed (%s): %w", resource, err)
-				return
+				errs = append(errs, fmt.Errorf("listing resources failed (%s): %w", resource, err))
+				return nil
 			}
-			resultsChan <- res
-		}()
+			out = append(out, res...)
+			return nil
+		})
 	}
+	g.Wait()
 
-	wg.Wait()
-	close(resultsChan)
-	<-doneChan
+	if err := ctx.Err(); err != nil {
+		// The errors of the canceled requests are just noise.
+		return nil, err
+	}
 
-	klog.V(3).InfoS("query results", "objects", len(out), "error", errResult)
-	return out, errResult
+	err := errors.Join(errs...)
+	klog.V(3).InfoS("query results", "objects", len(out), "error", err)
+	return out, err
 }
 
 func (c *client) listWithSelector(ctx context.Context,
-	resource schema.GroupVersionResource, ns string, labelSelector string) ([]*unstructured.Unstructured, error) {
+	resource schema.GroupVersionResource, ns string, labelSelector string, fieldSelector string) ([]*unstructured.Unstructured, error) {
 	var res []*unstructured.Unstructured
 
 	resp, err := c.dynamic.Resource(resource).Namespace(ns).List(ctx, metav1.ListOptions{
 		LabelSelector: labelSelector,
+		FieldSelector: fieldSelector,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("listing resources with selector %s failed (%s): %w", labelSelector, resource, err)
@@ -367,6 +574,36 @@ func (c *client) listWithSelector(ctx context.Context,
 }
 
 func (c *client) list(ctx context.Context, resource schema.GroupVersionResource, ns string) ([]*unstructured.Unstructured, error) {
+	return c.listWithFields(ctx, resource, ns, "")
+}
+
+// listWithFields lists the objects of the resource, passing the field selector
+// to the API server.
+func (c *client) listWithFields(ctx context.Context, resource schema.GroupVersionResource,
+	ns string, fieldSelector string) ([]*unstructured.Unstructured, error) {
+	// Leave the excluded namespaces out on the server side, where possible.
+	// The cluster-scoped resources don't support the namespace field.
+	if ns == NamespaceAll && c.resources[resource.GroupResource()].namespaced {
+		if excluded := ExcludedNamespacesFieldSelector(c.excludedNamespaces); excluded != "" {
+			selector := excluded
+			if fieldSelector != "" {
+				selector = fieldSelector + "," + excluded
+			}
+			out, err := c.listPages(ctx, resource, ns, selector)
+			if !apierrors.IsBadRequest(err) {
+				return out, err
+			}
+			klog.V(3).InfoS("namespace field selector not supported by the server, filtering on the client side",
+				"resource", resource, "error", err)
+		}
+	}
+	return c.listPages(ctx, resource, ns, fieldSelector)
+}
+
+// listPages lists all the pages of the objects of the resource. When listing
+// across all namespaces, the objects from the excluded namespaces are skipped.
+func (c *client) listPages(ctx context.Context, resource schema.GroupVersionResource,
+	ns string, fieldSelector string) ([]*unstructured.Unstructured, error) {
 	var out []*unstructured.Unstructured
 
 	var next string
@@ -379,15 +616,39 @@ func (c *client) list(ctx context.Context, resource schema.GroupVersionResource,
 		} else {
 			intf = nintf
 		}
-		resp, err := intf.List(ctx, metav1.ListOptions{
-			Limit:    250,
-			Continue: next,
-		})
+		opts := metav1.ListOptions{
+			Limit:         250,
+			Continue:      next,
+			FieldSelector: fieldSelector,
+		}
+		// The resourceVersion can't be combined with the continue token:
+		// the following pages are consistent with the first one anyway.
+		if next == "" {
+			c.applySnapshot(&opts)
+		}
+
+		resp, err := intf.List(ctx, opts)
+		if err != nil && opts.ResourceVersion != "" {
+			// Not all APIs support exact resourceVersion matching (e.g. aggregated
+			// APIs) and the snapshot might have been compacted meanwhile.
+			klog.V(3).InfoS("consistent list failed, falling back to the latest state",
+				"resource", resource, "resourceVersion", opts.ResourceVersion, "error", err)
+			opts.ResourceVersion = ""
+			opts.ResourceVersionMatch = ""
+			resp, err = intf.List(ctx, opts)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("listing resources failed (%s): %w", resource, err)
 		}
 
+		if next == "" {
+			c.pinSnapshot(resp.GetResourceVersion())
+		}
+
 		for _, item := range resp.Items {
+			if ns == NamespaceAll && c.isExcludedNamespace(item.GetNamespace()) {
+				continue
+			}
 			out = append(out, &item)
 		}
 
@@ -399,6 +660,100 @@ func (c *client) list(ctx context.Context, resource schema.GroupVersionResource,
 	return out, nil
 }
 
+// listByFieldSelector lists the objects of the resource matching the field
+// selector. The selector is evaluated by the API server when it supports all
+// the fields (see ServerFieldSelectors), otherwise on the client side.
+func (c *client) listByFieldSelector(ctx context.Context, resource schema.GroupVersionResource,
+	ns string, selector fields.Selector) ([]*unstructured.Unstructured, error) {
+	if serverSupportsFieldSelector(c.resources[resource.GroupResource()].GroupKind(), selector) {
+		out, err := c.listWithFields(ctx, resource, ns, selector.String())
+		if !apierrors.IsBadRequest(err) {
+			return out, err
+		}
+		klog.V(3).InfoS("field selector not supported by the server, filtering on the client side",
+			"resource", resource, "selector", selector, "error", err)
+	}
+
+	all, err := c.list(ctx, resource, ns)
+	if err != nil {
+		return nil, err
+	}
+	var out []*unstructured.Unstructured
+	for _, obj := range all {
+		if MatchesFieldSelector(obj, selector) {
+			out = append(out, obj)
+		}
+	}
+	return out, nil
+}
+
+// isExcludedNamespace returns true if the objects from the namespace should
+// be skipped when listing across all namespaces.
+func (c *client) isExcludedNamespace(ns string) bool {
+	return IsExcludedNamespace(ns, c.excludedNamespaces)
+}
+
+// IsExcludedNamespace returns true if the namespace matches any of the shell
+// patterns. The cluster-scoped objects (empty namespace) are never excluded.
+func IsExcludedNamespace(ns string, patterns []string) bool {
+	if ns == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, ns); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ExcludedNamespacesFieldSelector returns the field selector excluding
+// the namespaces on the server side. Only the plain names can be expressed
+// by a field selector: the other patterns need to be filtered on the client
+// side (see IsExcludedNamespace).
+func ExcludedNamespacesFieldSelector(patterns []string) string {
+	var reqs []string
+	for _, pattern := range patterns {
+		if !strings.ContainsAny(pattern, `*?[\`) {
+			reqs = append(reqs, "metadata.namespace!="+pattern)
+		}
+	}
+	return strings.Join(reqs, ",")
+}
+
+// applySnapshot sets the pinned resourceVersion to the list options, if any.
+func (c *client) applySnapshot(opts *metav1.ListOptions) {
+	if rv := c.snapshotResourceVersion(); rv != "" {
+		opts.ResourceVersion = rv
+		opts.ResourceVersionMatch = metav1.ResourceVersionMatchExact
+	}
+}
+
+// pinSnapshot remembers the resourceVersion for the following lists, unless
+// there is one pinned already.
+func (c *client) pinSnapshot(rv string) {
+	if !c.consistentList || rv == "" {
+		return
+	}
+	c.snapshotMtx.Lock()
+	defer c.snapshotMtx.Unlock()
+	if c.snapshotRV == "" {
+		c.snapshotRV = rv
+	}
+}
+
+func (c *client) snapshotResourceVersion() string {
+	c.snapshotMtx.Lock()
+	defer c.snapshotMtx.Unlock()
+	return c.snapshotRV
+}
+
+func (c *client) resetSnapshot() {
+	c.snapshotMtx.Lock()
+	defer c.snapshotMtx.Unlock()
+	c.snapshotRV = ""
+}
+
 func (c *client) get(ctx context.Context, obj *status.Object) (*unstructured.Unstructured, error) {
 	mapping, err := c.mapper.RESTMapping(obj.GroupVersionKind().GroupKind())
 	if err != nil {
@@ -406,7 +761,7 @@ func (c *client) get(ctx context.Context, obj *status.Object) (*unstructured.Uns
 	}
 
 	unst, err := c.dynamic.Resource(mapping.Resource).
-		Namespace(obj.GetNamespace()).
+		Namespace(mappedNamespace(mapping, obj)).
 		Get(ctx, obj.GetName(), metav1.GetOptions{})
 
 	if err != nil {
@@ -416,11 +771,21 @@ func (c *client) get(ctx context.Context, obj *status.Object) (*unstructured.Uns
 	return unst, nil
 }
 
-func (c *client) podLogs(ctx context.Context, obj *status.Object, container string, tailLines int64) ([]byte, error) {
+// mappedNamespace returns the namespace of the object, or an empty string
+// for cluster-scoped resources. It allows getting the cluster-scoped owners
+// of namespaced objects.
+func mappedNamespace(mapping *meta.RESTMapping, obj *status.Object) string {
+	if mapping.Scope != nil && mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return ""
+	}
+	return obj.GetNamespace()
+}
+
+func (c *client) podLogs(ctx context.Context, obj *status.Object, container string, tailLines int64, previous bool) ([]byte, error) {
 	opts := &corev1.PodLogOptions{
 		Container: container,
 		Follow:    false,
-		Previous:  false,
+		Previous:  previous,
 		TailLines: &tailLines,
 	}
 
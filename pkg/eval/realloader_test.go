@@ -1,20 +1,31 @@
 package eval
 
 import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/rhobs/kube-health/pkg/status"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/discovery/cached/memory"
+	dynamicclient "k8s.io/client-go/dynamic"
 	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes/fake"
 	restclient "k8s.io/client-go/rest"
+	clienttesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
@@ -471,7 +482,7 @@ func TestLoadResourceBySelector(t *testing.T) {
 			}
 			rl := RealLoader{client: c}
 			statusObjects, err := rl.LoadResourceBySelector(t.Context(),
-				tt.testResource.gr, tt.testResource.namespace, tt.testResource.label)
+				tt.testResource.gr, tt.testResource.namespace, tt.testResource.label, "")
 			assert.NoError(t, err)
 			assert.Equal(t, tt.expectedStatusObject, statusObjects)
 		})
@@ -552,3 +563,327 @@ func (m *MockClientConfig) Namespace() (string, bool, error) {
 func (m *MockClientConfig) ConfigAccess() clientcmd.ConfigAccess {
 	return nil
 }
+
+func TestConsistentList(t *testing.T) {
+	var listOpts []metav1.ListOptions
+	dynamic := createDynamicFakeClientWithObjects(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: test1Name, Namespace: testNS}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-2", Namespace: "another-ns"}},
+	)
+	dynamic.PrependReactor("list", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		opts := action.(clienttesting.ListActionImpl).ListOptions
+		listOpts = append(listOpts, opts)
+		if opts.ResourceVersion == "43" {
+			return true, nil, fmt.Errorf("too old resource version")
+		}
+		return true, &corev1.PodList{ListMeta: metav1.ListMeta{ResourceVersion: "42"}}, nil
+	})
+
+	c := &client{
+		dynamic:        dynamic,
+		resources:      allTestResources,
+		consistentList: true,
+	}
+	rl := RealLoader{client: c}
+	matcher := NewGroupKindMatcherSingle(podGVK.GroupKind())
+
+	_, err := rl.Load(t.Context(), testNS, matcher, nil)
+	assert.NoError(t, err)
+	_, err = rl.Load(t.Context(), "another-ns", matcher, nil)
+	assert.NoError(t, err)
+
+	assert.Len(t, listOpts, 2)
+	// The first list pins the resourceVersion for the following ones.
+	assert.Equal(t, "", listOpts[0].ResourceVersion)
+	assert.Equal(t, "42", listOpts[1].ResourceVersion)
+	assert.Equal(t, metav1.ResourceVersionMatchExact, listOpts[1].ResourceVersionMatch)
+
+	// Reset releases the pinned resourceVersion.
+	rl.Reset()
+	listOpts = nil
+	_, err = rl.Load(t.Context(), testNS, matcher, nil)
+	assert.NoError(t, err)
+	assert.Len(t, listOpts, 1)
+	assert.Equal(t, "", listOpts[0].ResourceVersion)
+
+	// Fall back to the latest state when the snapshot can't be served.
+	c.snapshotRV = "43"
+	listOpts = nil
+	_, err = rl.Load(t.Context(), testNS, matcher, nil)
+	assert.NoError(t, err)
+	assert.Len(t, listOpts, 2)
+	assert.Equal(t, "43", listOpts[0].ResourceVersion)
+	assert.Equal(t, "", listOpts[1].ResourceVersion)
+}
+
+func TestRBACPreflight(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	var namespaces []string
+	clientset.PrependReactor("create", "selfsubjectaccessreviews",
+		func(action clienttesting.Action) (bool, runtime.Object, error) {
+			review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+			attrs := review.Spec.ResourceAttributes
+			assert.Equal(t, "list", attrs.Verb)
+			namespaces = append(namespaces, attrs.Namespace)
+
+			// Deny access to pods and cluster operators.
+			review.Status.Allowed = attrs.Resource != "pods" && attrs.Resource != "clusteroperators"
+			return true, review, nil
+		})
+
+	c := &client{
+		authzclient: clientset.AuthorizationV1(),
+		resources:   maps.Clone(allTestResources),
+	}
+
+	skipped, err := c.preflight(t.Context(), testNS)
+	assert.NoError(t, err)
+	assert.Equal(t, []schema.GroupResource{coGR, podGR}, skipped)
+	assert.Equal(t, resourcesMap{
+		deploymentGR: allTestResources[deploymentGR],
+		pvcGR:        allTestResources[pvcGR],
+	}, c.resources)
+
+	// Cluster-scoped resources are checked without namespace.
+	assert.ElementsMatch(t, []string{testNS, testNS, testNS, ""}, namespaces)
+
+	// A failed check doesn't mean the access is denied.
+	clientset = fake.NewSimpleClientset()
+	clientset.PrependReactor("create", "selfsubjectaccessreviews",
+		func(action clienttesting.Action) (bool, runtime.Object, error) {
+			review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+			if review.Spec.ResourceAttributes.Resource == "pods" {
+				return true, nil, fmt.Errorf("transient failure")
+			}
+			review.Status.Allowed = review.Spec.ResourceAttributes.Resource != "clusteroperators"
+			return true, review, nil
+		})
+	c = &client{
+		authzclient: clientset.AuthorizationV1(),
+		resources:   maps.Clone(allTestResources),
+	}
+	skipped, err = c.preflight(t.Context(), testNS)
+	assert.NoError(t, err)
+	assert.Equal(t, []schema.GroupResource{coGR}, skipped)
+	assert.Contains(t, c.resources, podGR)
+
+	// The cancellation stops the preflight.
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	c = &client{
+		authzclient: clientset.AuthorizationV1(),
+		resources:   maps.Clone(allTestResources),
+	}
+	_, err = c.preflight(ctx, testNS)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestExcludedNamespaces(t *testing.T) {
+	objects := []runtime.Object{
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: test1Name, Namespace: testNS}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "kube-proxy", Namespace: "kube-system"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "prometheus", Namespace: "openshift-monitoring"}},
+	}
+	matcher := NewGroupKindMatcherSingle(podGVK.GroupKind())
+
+	names := func(objs []*status.Object) []string {
+		var ret []string
+		for _, obj := range objs {
+			ret = append(ret, obj.Name)
+		}
+		return ret
+	}
+
+	var selectors []string
+	dynamic := createDynamicFakeClientWithObjects(objects...)
+	dynamic.PrependReactor("list", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		selectors = append(selectors, action.(clienttesting.ListActionImpl).ListOptions.FieldSelector)
+		return false, nil, nil
+	})
+
+	// System namespaces are excluded by default.
+	rl := RealLoader{client: &client{
+		dynamic:            dynamic,
+		resources:          allTestResources,
+		excludedNamespaces: DefaultExcludedNamespaces,
+	}}
+	objs, err := rl.Load(t.Context(), NamespaceAll, matcher, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{test1Name}, names(objs))
+	// The plain names are excluded by the server, the patterns on the client side.
+	assert.Equal(t, []string{"metadata.namespace!=kube-node-lease,metadata.namespace!=kube-public," +
+		"metadata.namespace!=kube-system,metadata.namespace!=openshift"}, selectors)
+
+	// The servers not supporting the selector fall back to the client side.
+	dynamic = createDynamicFakeClientWithObjects(objects...)
+	dynamic.PrependReactor("list", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.(clienttesting.ListActionImpl).ListOptions.FieldSelector != "" {
+			return true, nil, apierrors.NewBadRequest("field label not supported: metadata.namespace")
+		}
+		return false, nil, nil
+	})
+	rl.client.dynamic = dynamic
+	objs, err = rl.Load(t.Context(), NamespaceAll, matcher, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{test1Name}, names(objs))
+
+	// Explicitly requested namespace is not filtered.
+	objs, err = rl.Load(t.Context(), "kube-system", matcher, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kube-proxy"}, names(objs))
+
+	// --include-system: no namespaces are excluded.
+	rl = RealLoader{client: &client{
+		dynamic:   createDynamicFakeClientWithObjects(objects...),
+		resources: allTestResources,
+	}}
+	objs, err = rl.Load(t.Context(), NamespaceAll, matcher, nil)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{test1Name, "kube-proxy", "prometheus"}, names(objs))
+}
+
+func TestListBulk(t *testing.T) {
+	resources := []schema.GroupVersionResource{
+		podGVK.GroupVersion().WithResource(podGR.Resource),
+		pvcGVK.GroupVersion().WithResource(pvcGR.Resource),
+	}
+	newClient := func() (*client, *dynamicfake.FakeDynamicClient) {
+		dynamic := createDynamicFakeClientWithObjects(
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: test1Name, Namespace: testNS}},
+			&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: test1Name, Namespace: testNS}},
+		)
+		return &client{dynamic: dynamic, resources: allTestResources}, dynamic
+	}
+
+	c, _ := newClient()
+	out, err := c.listBulk(t.Context(), testNS, resources)
+	require.NoError(t, err)
+	assert.Len(t, out, 2)
+
+	// The errors are returned together with the objects loaded successfully.
+	c, dynamic := newClient()
+	dynamic.PrependReactor("list", "persistentvolumeclaims", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("boom")
+	})
+	out, err = c.listBulk(t.Context(), testNS, resources)
+	assert.ErrorContains(t, err, "boom")
+	require.Len(t, out, 1)
+	assert.Equal(t, test1Name, out[0].GetName())
+
+	// Canceled context is reported as such.
+	c, _ = newClient()
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	out, err = c.listBulk(ctx, testNS, resources)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, out)
+}
+
+// trackingDynamicClient tracks the number of list requests running in parallel.
+type trackingDynamicClient struct {
+	dynamicclient.Interface
+
+	mtx     sync.Mutex
+	running int
+	maxSeen int
+}
+
+func (d *trackingDynamicClient) Resource(r schema.GroupVersionResource) dynamicclient.NamespaceableResourceInterface {
+	return trackingResource{NamespaceableResourceInterface: d.Interface.Resource(r), d: d}
+}
+
+type trackingResource struct {
+	dynamicclient.NamespaceableResourceInterface
+	d *trackingDynamicClient
+}
+
+func (r trackingResource) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	r.d.mtx.Lock()
+	r.d.running++
+	r.d.maxSeen = max(r.d.maxSeen, r.d.running)
+	r.d.mtx.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	r.d.mtx.Lock()
+	r.d.running--
+	r.d.mtx.Unlock()
+	return r.NamespaceableResourceInterface.List(ctx, opts)
+}
+
+func TestListBulkConcurrencyLimit(t *testing.T) {
+	var resources []schema.GroupVersionResource
+	for _, r := range []string{"pods", "persistentvolumeclaims", "configmaps", "services", "secrets"} {
+		resources = append(resources, schema.GroupVersionResource{Version: "v1", Resource: r})
+	}
+
+	for _, limit := range []int{2, 0} {
+		dynamic := &trackingDynamicClient{Interface: createDynamicFakeClientWithObjects()}
+		c := &client{dynamic: dynamic, resources: allTestResources, maxConcurrentLists: limit}
+		_, err := c.listBulk(t.Context(), NamespaceAll, resources)
+		require.NoError(t, err)
+		if limit > 0 {
+			assert.Equal(t, limit, dynamic.maxSeen)
+		} else {
+			// Unbounded.
+			assert.Equal(t, len(resources), dynamic.maxSeen)
+		}
+	}
+}
+
+func TestListByFieldSelector(t *testing.T) {
+	podsGVR := podGVK.GroupVersion().WithResource(podGR.Resource)
+	pvcsGVR := pvcGVK.GroupVersion().WithResource(pvcGR.Resource)
+	newClient := func() (*client, *dynamicfake.FakeDynamicClient, *[]string) {
+		dynamic := createDynamicFakeClientWithObjects(
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: testNS}, Spec: corev1.PodSpec{NodeName: "node1"}},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p2", Namespace: testNS}, Spec: corev1.PodSpec{NodeName: "node2"}},
+			&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pvc1", Namespace: testNS},
+				Spec: corev1.PersistentVolumeClaimSpec{VolumeName: "pv1"}},
+			&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pvc2", Namespace: testNS},
+				Spec: corev1.PersistentVolumeClaimSpec{VolumeName: "pv2"}},
+		)
+		// Record the field selectors sent to the server.
+		var sent []string
+		dynamic.PrependReactor("list", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+			sent = append(sent, action.(clienttesting.ListAction).GetListRestrictions().Fields.String())
+			return false, nil, nil
+		})
+		return &client{dynamic: dynamic, resources: allTestResources}, dynamic, &sent
+	}
+
+	names := func(objs []*unstructured.Unstructured) []string {
+		var ret []string
+		for _, obj := range objs {
+			ret = append(ret, obj.GetName())
+		}
+		return ret
+	}
+
+	// Supported by the server: passed as is. The fake client ignores
+	// the field selectors, so we check just the request.
+	c, _, sent := newClient()
+	_, err := c.listByFieldSelector(t.Context(), podsGVR, testNS, fields.OneTermEqualSelector("spec.nodeName", "node1"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"spec.nodeName=node1"}, *sent)
+
+	// Not supported by the server: filtered on the client side.
+	c, _, sent = newClient()
+	out, err := c.listByFieldSelector(t.Context(), pvcsGVR, testNS, fields.OneTermEqualSelector("spec.volumeName", "pv2"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{""}, *sent)
+	assert.Equal(t, []string{"pvc2"}, names(out))
+
+	// Rejected by the server: falling back to the client side.
+	c, dynamic, sent := newClient()
+	dynamic.PrependReactor("list", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if !action.(clienttesting.ListAction).GetListRestrictions().Fields.Empty() {
+			return true, nil, apierrors.NewBadRequest("field label not supported")
+		}
+		return false, nil, nil
+	})
+	out, err = c.listByFieldSelector(t.Context(), podsGVR, testNS, fields.OneTermEqualSelector("spec.nodeName", "node2"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{""}, *sent)
+	assert.Equal(t, []string{"p2"}, names(out))
+}
@@ -0,0 +1,78 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// errObjectTimeout is the cause of the context cancellation when
+// the evaluation of an object exceeds the per-object timeout.
+var errObjectTimeout = errors.New("object evaluation timeout")
+
+// objectTimeout tracks the first operation that failed due to the per-object
+// timeout, so that the error can name it.
+type objectTimeout struct {
+	timeout time.Duration
+	obj     *status.Object
+
+	mtx sync.Mutex
+	op  string
+}
+
+type objectTimeoutKey struct{}
+
+// WithObjectTimeout limits the time spent on evaluating a single object
+// (including its sub-objects) via Eval. An object hitting the timeout
+// is reported as Unknown with an error naming the operation that timed out,
+// instead of blocking the evaluation of the other objects.
+// Zero disables the timeout, which is the default.
+func (e *Evaluator) WithObjectTimeout(timeout time.Duration) *Evaluator {
+	e.objectTimeout = timeout
+	return e
+}
+
+// withObjectTimeout returns the context for evaluating the object, canceled
+// after the per-object timeout.
+func (e *Evaluator) withObjectTimeout(ctx context.Context, obj *status.Object) (context.Context, context.CancelFunc) {
+	if e.objectTimeout <= 0 {
+		return ctx, func() {}
+	}
+	ot := &objectTimeout{timeout: e.objectTimeout, obj: obj}
+	ctx = context.WithValue(ctx, objectTimeoutKey{}, ot)
+	return context.WithTimeoutCause(ctx, e.objectTimeout, errObjectTimeout)
+}
+
+// noteTimeout records the operation as the one that timed out, if err
+// was caused by the per-object timeout.
+func noteTimeout(ctx context.Context, err error, op string, args ...any) {
+	ot, _ := ctx.Value(objectTimeoutKey{}).(*objectTimeout)
+	if err == nil || ot == nil || context.Cause(ctx) != errObjectTimeout {
+		return
+	}
+	ot.mtx.Lock()
+	defer ot.mtx.Unlock()
+	if ot.op == "" {
+		ot.op = fmt.Sprintf(op, args...)
+	}
+}
+
+// objectTimeoutErr returns the error describing the per-object timeout,
+// or nil if the evaluation didn't hit it.
+func objectTimeoutErr(ctx context.Context) error {
+	ot, _ := ctx.Value(objectTimeoutKey{}).(*objectTimeout)
+	if ot == nil || context.Cause(ctx) != errObjectTimeout {
+		return nil
+	}
+	ot.mtx.Lock()
+	defer ot.mtx.Unlock()
+	op := ot.op
+	if op == "" {
+		op = fmt.Sprintf("evaluation of %s/%s", ot.obj.Kind, ot.obj.GetName())
+	}
+	return fmt.Errorf("%s timed out after %s", op, ot.timeout)
+}
@@ -0,0 +1,48 @@
+package eval
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// hangingLoader blocks when getting the object with the given name until
+// the context is canceled.
+type hangingLoader struct {
+	*FakeLoader
+	name string
+}
+
+func (l hangingLoader) Get(ctx context.Context, obj *status.Object) (*status.Object, error) {
+	if obj.GetName() == l.name {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return l.FakeLoader.Get(ctx, obj)
+}
+
+func TestEvalObjectTimeout(t *testing.T) {
+	loader := hangingLoader{FakeLoader: NewFakeLoader(), name: "slow"}
+	objs, err := loader.Register(testConfigMap("slow", "uid-slow"), testConfigMap("fast", "uid-fast"))
+	require.NoError(t, err)
+
+	evaluator := NewEvaluator([]AnalyzerInit{
+		func(*Evaluator) Analyzer { return okAnalyzer{} },
+	}, loader).WithObjectTimeout(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	update := <-NewStatusPoller(0, evaluator, objs).Start(ctx)
+	require.NoError(t, ctx.Err(), "the poll was blocked by the hanging object")
+	require.Len(t, update.Statuses, 2)
+
+	assert.Equal(t, status.Unknown, update.Statuses[0].Status().Result)
+	assert.EqualError(t, update.Statuses[0].Status().Err, "getting ConfigMap/slow timed out after 50ms")
+	assert.Equal(t, status.Ok, update.Statuses[1].Status().Result)
+	assert.NoError(t, update.Statuses[1].Status().Err)
+}
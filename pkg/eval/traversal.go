@@ -0,0 +1,87 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// DefaultMaxEvalDepth is the maximum number of nested objects analyzed below
+// an evaluated object. It's far beyond the depth of the usual hierarchies
+// (e.g. Deployment -> ReplicaSet -> Pod -> Container) and it only prevents
+// runaway recursion.
+const DefaultMaxEvalDepth = 32
+
+// evalPath is the chain of the objects being analyzed, from the evaluated
+// object down to the current one. It's passed through the context, so that
+// the analyzers don't need to be aware of it.
+type evalPath struct {
+	parent *evalPath
+	uid    types.UID
+	depth  int
+	// truncated is set when the traversal was cut below the object,
+	// making its status dependent on the path.
+	truncated atomic.Bool
+}
+
+type evalPathKey struct{}
+
+func (p *evalPath) contains(uid types.UID) bool {
+	for ; p != nil; p = p.parent {
+		if p.uid == uid {
+			return true
+		}
+	}
+	return false
+}
+
+// truncate marks the path as cut below its end.
+func (p *evalPath) truncate() {
+	for ; p != nil; p = p.parent {
+		p.truncated.Store(true)
+	}
+}
+
+// pathTruncated tells whether the traversal was cut below the current object.
+func pathTruncated(ctx context.Context) bool {
+	p, _ := ctx.Value(evalPathKey{}).(*evalPath)
+	return p != nil && p.truncated.Load()
+}
+
+// WithMaxEvalDepth limits the number of nested objects analyzed below
+// an evaluated object. The objects deeper than that are reported
+// with an error instead of being analyzed.
+func (e *Evaluator) WithMaxEvalDepth(n int) *Evaluator {
+	e.maxEvalDepth = n
+	return e
+}
+
+// enterObject extends the evaluation path in the context by the object.
+// It fails when the object is already on the path, i.e. when the ownership
+// relations contain a cycle (e.g. an object listing itself as its owner),
+// or when the path exceeds the maximum depth.
+//
+// The same object can still be analyzed multiple times, under different
+// parents (e.g. a Pod selected by multiple Services).
+func (e *Evaluator) enterObject(ctx context.Context, obj *status.Object) (context.Context, error) {
+	parent, _ := ctx.Value(evalPathKey{}).(*evalPath)
+	depth := 0
+	if parent != nil {
+		depth = parent.depth + 1
+	}
+
+	// Objects without UID (e.g. the synthetic ones) can't form a cycle.
+	if obj.UID != "" && parent.contains(obj.UID) {
+		parent.truncate()
+		return ctx, fmt.Errorf("ownership cycle detected: %s/%s is its own (transitive) owner", obj.Kind, obj.GetName())
+	}
+	if depth > e.maxEvalDepth {
+		parent.truncate()
+		return ctx, fmt.Errorf("maximum evaluation depth (%d) exceeded at %s/%s", e.maxEvalDepth, obj.Kind, obj.GetName())
+	}
+	return context.WithValue(ctx, evalPathKey{}, &evalPath{parent: parent, uid: obj.UID, depth: depth}), nil
+}
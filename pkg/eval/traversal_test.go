@@ -0,0 +1,76 @@
+package eval
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// ownedAnalyzer recursively evaluates the owned objects, the same way
+// as the generic analyzer does.
+type ownedAnalyzer struct {
+	e *Evaluator
+}
+
+func (ownedAnalyzer) Supports(obj *status.Object) bool { return true }
+
+func (a ownedAnalyzer) Analyze(ctx context.Context, obj *status.Object) status.ObjectStatus {
+	subStatuses, err := a.e.EvalQuery(ctx, OwnerQuerySpec{Object: obj, GK: GroupKindMatcher{IncludeAll: true}}, nil)
+	if err != nil {
+		return status.UnknownStatusWithError(obj, err)
+	}
+	return status.OkStatus(obj, subStatuses)
+}
+
+func TestEvalOwnershipCycle(t *testing.T) {
+	owned := func(name, uid, ownerName, ownerUID string) unstructured.Unstructured {
+		u := testConfigMap(name, uid)
+		u.SetOwnerReferences([]metav1.OwnerReference{
+			{APIVersion: "v1", Kind: "ConfigMap", Name: ownerName, UID: types.UID(ownerUID)},
+		})
+		return u
+	}
+
+	loader := NewFakeLoader()
+	objs, err := loader.Register(
+		owned("self", "uid-self", "self", "uid-self"),
+		owned("a", "uid-a", "c", "uid-c"),
+		owned("b", "uid-b", "a", "uid-a"),
+		owned("c", "uid-c", "b", "uid-b"),
+	)
+	require.NoError(t, err)
+
+	evaluator := NewEvaluator([]AnalyzerInit{
+		func(e *Evaluator) Analyzer { return ownedAnalyzer{e: e} },
+	}, loader)
+
+	// The self-owning object is reported under itself once, with an error.
+	os := evaluator.Eval(t.Context(), objs[0])
+	require.Len(t, os.SubStatuses, 1)
+	sub := os.SubStatuses[0]
+	assert.Equal(t, types.UID("uid-self"), sub.Object.UID)
+	assert.Empty(t, sub.SubStatuses)
+	assert.ErrorContains(t, sub.Status().Err, "ownership cycle detected: ConfigMap/self")
+
+	// a -> b -> c -> a
+	os = evaluator.Eval(t.Context(), objs[1])
+	var path []string
+	for s := os; len(s.SubStatuses) > 0; s = s.SubStatuses[0] {
+		path = append(path, s.SubStatuses[0].Object.GetName())
+	}
+	assert.Equal(t, []string{"b", "c", "a"}, path)
+
+	// The depth limit applies regardless of the cycles.
+	os = evaluator.WithMaxEvalDepth(1).Eval(t.Context(), objs[1])
+	require.Len(t, os.SubStatuses, 1)
+	require.Len(t, os.SubStatuses[0].SubStatuses, 1)
+	assert.ErrorContains(t, os.SubStatuses[0].SubStatuses[0].Status().Err,
+		"maximum evaluation depth (1) exceeded at ConfigMap/c")
+}
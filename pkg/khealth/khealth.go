@@ -5,17 +5,24 @@
 package khealth
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/rhobs/kube-health/pkg/analyze"
 	"github.com/rhobs/kube-health/pkg/eval"
+	"github.com/rhobs/kube-health/pkg/print"
+	"github.com/rhobs/kube-health/pkg/status"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/rest"
 )
 
 // NewHealthEvaluator creates a new kube-health evaluator using the provided rest.Config.
 // If nil is passed, the in-cluster configuration will be used by default.
-func NewHealthEvaluator(restConfig *rest.Config) (*eval.Evaluator, error) {
+// Optional plugins extend the built-in analyzers with custom ones,
+// or adjust the ignored kinds (see analyze.IgnoreKinds and analyze.IncludeKinds)
+// and the evaluation of the conditions (see analyze.AddConditionRules).
+// The built-in analyzers are configured by the analyze.WithOptions plugin.
+func NewHealthEvaluator(restConfig *rest.Config, plugins ...analyze.Plugin) (*eval.Evaluator, error) {
 	cf := genericclioptions.NewConfigFlags(true)
 
 	if restConfig != nil {
@@ -32,9 +39,18 @@ func NewHealthEvaluator(restConfig *rest.Config) (*eval.Evaluator, error) {
 		}
 	}
 
-	ldr, err := eval.NewRealLoader(cf)
+	ldr, err := eval.NewRealLoader(context.Background(), cf, eval.LoaderOptions{
+		MaxConcurrentLists: eval.DefaultMaxConcurrentLists,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("can't create kube-health loader: %w", err)
 	}
-	return eval.NewEvaluator(analyze.DefaultAnalyzers(), ldr), nil
+	return eval.NewEvaluator(analyze.AnalyzersWithPlugins(plugins...), ldr), nil
+}
+
+// Check evaluates a single object and returns its overall status, together
+// with a one-line human readable summary (see print.OneLine).
+func Check(ctx context.Context, e *eval.Evaluator, obj *status.Object) (status.Status, string) {
+	os := e.Eval(ctx, obj)
+	return os.Status(), print.OneLine(os)
 }
@@ -1,10 +1,15 @@
 package monitor
 
 import (
+	"fmt"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/klog/v2"
 )
@@ -17,15 +22,38 @@ type Config struct {
 type Target struct {
 	Kinds    []schema.GroupKind
 	Category string `yaml:"omitempty"`
-	// TODO: add support for namespaces filtering
-	// Namespaces []string `yaml:"omitempty"`
+	// Namespaces limits the target to the objects in the namespaces.
+	// All namespaces are evaluated when empty.
+	Namespaces []string `yaml:"omitempty"`
+	// Selector limits the target to the objects matching the label selector.
+	Selector labels.Selector `yaml:"omitempty"`
+	// Interval overrides the global refresh interval for the target.
+	Interval time.Duration `yaml:"omitempty"`
+	// ExtraLabels are the OptionalLabels added to the metrics of the target.
+	ExtraLabels []string `yaml:"omitempty"`
+	// DropLabels are the DroppableLabels removed from the metrics of the target,
+	// reducing the cardinality for targets matching many objects.
+	DropLabels []string `yaml:"omitempty"`
+	// GroupByNamespace splits the statuses of the target by the namespaces
+	// of the objects, e.g. to get the score of each namespace when monitoring
+	// the objects matching the selector cluster-wide.
+	GroupByNamespace bool `yaml:"omitempty"`
 }
 
 type YAMLConfig struct {
 	Targets []struct {
 		Category string
 		Kinds    []string
-		// Namespaces []string
+		// Namespace is a shorthand for a single-item Namespaces.
+		Namespace  string
+		Namespaces []string
+		Selector   string
+		Interval   string
+		// ExtraLabels and DropLabels adjust the labels of the metrics.
+		ExtraLabels []string `yaml:"extraLabels"`
+		DropLabels  []string `yaml:"dropLabels"`
+		// GroupByNamespace splits the target statuses by namespace.
+		GroupByNamespace bool `yaml:"groupByNamespace"`
 	}
 }
 
@@ -38,7 +66,7 @@ func ReadConfig(mapper meta.RESTMapper, path string) (Config, error) {
 		return cfg, err
 	}
 
-	err = yaml.Unmarshal(b, &yamlCfg)
+	err = yaml.Unmarshal([]byte(expandEnv(string(b))), &yamlCfg)
 	if err != nil {
 		return cfg, err
 	}
@@ -53,16 +81,77 @@ func ReadConfig(mapper meta.RESTMapper, path string) (Config, error) {
 			}
 			kinds = append(kinds, kind)
 		}
+		var selector labels.Selector
+		if t.Selector != "" {
+			selector, err = labels.Parse(t.Selector)
+			if err != nil {
+				return cfg, fmt.Errorf("invalid selector %q in target %q: %w", t.Selector, t.Category, err)
+			}
+		}
+
+		var interval time.Duration
+		if t.Interval != "" {
+			interval, err = time.ParseDuration(t.Interval)
+			if err != nil || interval <= 0 {
+				return cfg, fmt.Errorf("invalid interval %q in target %q: expected a positive duration, e.g. 5m",
+					t.Interval, t.Category)
+			}
+		}
+
+		if err := validateLabels(t.ExtraLabels, OptionalLabels); err != nil {
+			return cfg, fmt.Errorf("invalid extraLabels in target %q: %w", t.Category, err)
+		}
+		if err := validateLabels(t.DropLabels, DroppableLabels); err != nil {
+			return cfg, fmt.Errorf("invalid dropLabels in target %q: %w", t.Category, err)
+		}
+
+		namespaces := t.Namespaces
+		if t.Namespace != "" && !slices.Contains(namespaces, t.Namespace) {
+			namespaces = append([]string{t.Namespace}, namespaces...)
+		}
 		cfg.Targets = append(cfg.Targets, Target{
-			Category: t.Category,
-			Kinds:    kinds,
-			// Namespaces: t.Namespaces,
+			Category:   t.Category,
+			Kinds:      kinds,
+			Namespaces: namespaces,
+			Selector:   selector,
+			Interval:   interval,
+
+			ExtraLabels: t.ExtraLabels,
+			DropLabels:  t.DropLabels,
+
+			GroupByNamespace: t.GroupByNamespace,
 		})
 	}
 
 	return cfg, nil
 }
 
+func validateLabels(labels, allowed []string) error {
+	for _, l := range labels {
+		if !slices.Contains(allowed, l) {
+			return fmt.Errorf("unsupported label %q, expected one of: %s", l, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// expandEnv replaces ${VAR} and $VAR references with values from the process
+// environment. ${VAR:-default} uses the default value when VAR is unset or empty.
+// Use $$ to produce a literal $.
+func expandEnv(s string) string {
+	return os.Expand(s, func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		name, def, hasDefault := strings.Cut(name, ":-")
+		val := os.Getenv(name)
+		if val == "" && hasDefault {
+			return def
+		}
+		return val
+	})
+}
+
 func parseKind(mapper meta.RESTMapper, s string) (schema.GroupKind, error) {
 	gr := schema.ParseGroupResource(s)
 	gvk, err := mapper.KindFor(gr.WithVersion(""))
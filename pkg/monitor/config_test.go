@@ -0,0 +1,188 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func testMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Node"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	return mapper
+}
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "monitor.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("KH_SET", "value")
+	t.Setenv("KH_EMPTY", "")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"plain", "plain"},
+		{"$KH_SET", "value"},
+		{"${KH_SET}-suffix", "value-suffix"},
+		{"${KH_UNSET}", ""},
+		{"${KH_UNSET:-default}", "default"},
+		{"${KH_EMPTY:-default}", "default"},
+		{"${KH_SET:-default}", "value"},
+		{"$$KH_SET", "$KH_SET"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, expandEnv(tt.input))
+		})
+	}
+}
+
+func TestReadConfigExpandsEnv(t *testing.T) {
+	t.Setenv("KH_CATEGORY", "compute")
+	t.Setenv("KH_KIND", "node")
+
+	path := writeConfig(t, `
+targets:
+- category: ${KH_CATEGORY}
+  kinds:
+  - $KH_KIND
+- category: ${KH_WORKLOADS_CATEGORY:-workloads}
+  kinds:
+  - ${KH_WORKLOAD_KIND:-deployment}
+`)
+
+	cfg, err := ReadConfig(testMapper(), path)
+	require.NoError(t, err)
+	require.Len(t, cfg.Targets, 2)
+	assert.Equal(t, "compute", cfg.Targets[0].Category)
+	assert.Equal(t, []schema.GroupKind{{Kind: "Node"}}, cfg.Targets[0].Kinds)
+	assert.Equal(t, "workloads", cfg.Targets[1].Category)
+	assert.Equal(t, []schema.GroupKind{{Group: "apps", Kind: "Deployment"}}, cfg.Targets[1].Kinds)
+}
+
+func TestReadConfigNamespaces(t *testing.T) {
+	path := writeConfig(t, `
+targets:
+- category: workloads
+  namespace: ns1
+  namespaces:
+  - ns2
+  kinds:
+  - deployment
+- category: compute
+  kinds:
+  - node
+`)
+
+	cfg, err := ReadConfig(testMapper(), path)
+	require.NoError(t, err)
+	require.Len(t, cfg.Targets, 2)
+	assert.Equal(t, []string{"ns1", "ns2"}, cfg.Targets[0].Namespaces)
+	assert.Empty(t, cfg.Targets[1].Namespaces)
+}
+
+func TestReadConfigSelector(t *testing.T) {
+	path := writeConfig(t, `
+targets:
+- category: payments
+  selector: team=payments,tier!=test
+  kinds:
+  - deployment
+`)
+
+	cfg, err := ReadConfig(testMapper(), path)
+	require.NoError(t, err)
+	require.Len(t, cfg.Targets, 1)
+	assert.Equal(t, "team=payments,tier!=test", cfg.Targets[0].Selector.String())
+	assert.False(t, cfg.Targets[0].GroupByNamespace)
+
+	path = writeConfig(t, `
+targets:
+- category: payments
+  selector: team=payments
+  groupByNamespace: true
+  kinds:
+  - deployment
+`)
+	cfg, err = ReadConfig(testMapper(), path)
+	require.NoError(t, err)
+	assert.True(t, cfg.Targets[0].GroupByNamespace)
+
+	path = writeConfig(t, `
+targets:
+- category: payments
+  selector: team in (payments
+  kinds:
+  - deployment
+`)
+	_, err = ReadConfig(testMapper(), path)
+	assert.ErrorContains(t, err, `invalid selector "team in (payments" in target "payments"`)
+}
+
+func TestReadConfigInterval(t *testing.T) {
+	path := writeConfig(t, `
+targets:
+- category: compute
+  interval: 5m
+  kinds:
+  - node
+- category: workloads
+  kinds:
+  - deployment
+`)
+
+	cfg, err := ReadConfig(testMapper(), path)
+	require.NoError(t, err)
+	require.Len(t, cfg.Targets, 2)
+	assert.Equal(t, 5*time.Minute, cfg.Targets[0].Interval)
+	assert.Zero(t, cfg.Targets[1].Interval)
+
+	path = writeConfig(t, `
+targets:
+- category: compute
+  interval: often
+  kinds:
+  - node
+`)
+	_, err = ReadConfig(testMapper(), path)
+	assert.ErrorContains(t, err, `invalid interval "often" in target "compute"`)
+}
+
+func TestReadConfigLabels(t *testing.T) {
+	path := writeConfig(t, `
+targets:
+- category: workloads
+  extraLabels: [uid, group]
+  dropLabels: [name]
+  kinds:
+  - deployment
+`)
+
+	cfg, err := ReadConfig(testMapper(), path)
+	require.NoError(t, err)
+	require.Len(t, cfg.Targets, 1)
+	assert.Equal(t, []string{"uid", "group"}, cfg.Targets[0].ExtraLabels)
+	assert.Equal(t, []string{"name"}, cfg.Targets[0].DropLabels)
+
+	path = writeConfig(t, `
+targets:
+- category: workloads
+  dropLabels: [kind]
+  kinds:
+  - deployment
+`)
+	_, err = ReadConfig(testMapper(), path)
+	assert.ErrorContains(t, err, `invalid dropLabels in target "workloads": unsupported label "kind"`)
+}
@@ -2,6 +2,9 @@ package monitor
 
 import (
 	"context"
+	"maps"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	"k8s.io/klog/v2"
@@ -11,11 +14,17 @@ import (
 )
 
 // StatusPoller polls the status of a set of objects at a regular interval.
+// The interval can be overridden per target: the targets are then refreshed
+// independently, each update carrying the latest results of all the targets.
 type MonitorPoller struct {
 	interval  time.Duration
 	evaluator *eval.Evaluator
 	cfg       Config
 	eventChan chan TargetsStatusUpdate
+
+	nextRun []time.Time        // when the targets are due for a refresh, by index
+	latest  [][]TargetStatuses // the latest results of the targets, by index
+	jitter  float64            // the maximum factor to extend the intervals by
 }
 
 func NewMonitorPoller(interval time.Duration, evaluator *eval.Evaluator, cfg Config) *MonitorPoller {
@@ -24,16 +33,36 @@ func NewMonitorPoller(interval time.Duration, evaluator *eval.Evaluator, cfg Con
 		evaluator: evaluator,
 		cfg:       cfg,
 		eventChan: make(chan TargetsStatusUpdate),
+
+		nextRun: make([]time.Time, len(cfg.Targets)),
+		latest:  make([][]TargetStatuses, len(cfg.Targets)),
+		jitter:  eval.DefaultPollJitter,
 	}
 }
 
+// WithJitter sets the maximum factor by which the refresh intervals are
+// randomly changed (eval.DefaultPollJitter by default), so that the targets
+// and the monitor instances drift apart instead of polling the API at
+// the same time. Zero disables it.
+func (s *MonitorPoller) WithJitter(factor float64) *MonitorPoller {
+	s.jitter = factor
+	return s
+}
+
 type TargetStatuses struct {
 	Target   Target
 	Statuses []status.ObjectStatus
+	// Namespace is the namespace of the statuses when the target
+	// is grouped by namespace (see Target.GroupByNamespace).
+	Namespace string
 }
 
 type TargetsStatusUpdate struct {
 	Statuses []TargetStatuses
+	// Duration is how long it took to evaluate the targets.
+	Duration time.Duration
+	// FailedTargets is the number of targets that failed to evaluate.
+	FailedTargets int
 }
 
 func (t TargetsStatusUpdate) ToStatusUpdate() eval.StatusUpdate {
@@ -53,13 +82,13 @@ func (s *MonitorPoller) Start(ctx context.Context) <-chan TargetsStatusUpdate {
 	go func() {
 		defer close(s.eventChan)
 		// Initial run
-		s.run(ctx)
+		s.run(ctx, time.Now())
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case <-time.After(s.interval):
-				s.run(ctx)
+			case <-time.After(time.Until(s.nextDue())):
+				s.run(ctx, time.Now())
 			}
 		}
 	}()
@@ -67,35 +96,112 @@ func (s *MonitorPoller) Start(ctx context.Context) <-chan TargetsStatusUpdate {
 	return s.eventChan
 }
 
-func (s *MonitorPoller) run(ctx context.Context) {
+// targetInterval returns the refresh interval of the target.
+func (s *MonitorPoller) targetInterval(target Target) time.Duration {
+	if target.Interval > 0 {
+		return target.Interval
+	}
+	return s.interval
+}
+
+// nextDue returns the time when the next target is due for a refresh.
+func (s *MonitorPoller) nextDue() time.Time {
+	next := time.Now().Add(s.interval)
+	for _, t := range s.nextRun {
+		if t.Before(next) {
+			next = t
+		}
+	}
+	return next
+}
+
+// run refreshes the targets that are due and sends the update.
+func (s *MonitorPoller) run(ctx context.Context, now time.Time) {
 	// Reset the evaluator to clear the cache from previous run.
 	s.evaluator.Reset()
 
 	klog.V(1).Info("reloading health data")
 	start := time.Now()
 
-	statuses := make([]TargetStatuses, 0)
-	for _, target := range s.cfg.Targets {
-		querySpec := eval.KindQuerySpec{
-			GK: eval.GroupKindMatcher{IncludedKinds: target.Kinds},
-			Ns: expandNamespace(""),
-			// TODO: add namespace support
-			//Namespace: target.Namespace,
+	failed := 0
+	for i, target := range s.cfg.Targets {
+		if now.Before(s.nextRun[i]) {
+			continue
+		}
+		s.nextRun[i] = now.Add(eval.JitterInterval(s.targetInterval(target), s.jitter))
+
+		// Keep the previous results of the target if it fails to evaluate.
+		if ts, ok := s.evalTarget(ctx, target); ok {
+			s.latest[i] = ts
+		} else {
+			failed++
+		}
+	}
+
+	for _, w := range s.evaluator.Warnings() {
+		klog.InfoS("health data loaded partially", "warning", w)
+	}
+	duration := time.Since(start)
+	klog.V(1).InfoS("health data reloaded", "duration", duration)
+
+	statuses := make([]TargetStatuses, 0, len(s.latest))
+	for _, ts := range s.latest {
+		statuses = append(statuses, ts...)
+	}
+	s.eventChan <- TargetsStatusUpdate{
+		Statuses:      statuses,
+		Duration:      duration,
+		FailedTargets: failed,
+	}
+}
+
+// evalTarget evaluates the target across its namespaces. It returns false
+// if none of the queries succeeded. Unless the target is grouped by namespace,
+// a single TargetStatuses is returned.
+func (s *MonitorPoller) evalTarget(ctx context.Context, target Target) ([]TargetStatuses, bool) {
+	namespaces := target.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+
+	// The namespaces are evaluated in parallel (within the limit of
+	// the evaluator) and their statuses merged under the target.
+	var evaluated atomic.Bool
+	targetStatuses := s.evaluator.RunParallel(len(namespaces), func(i int) []status.ObjectStatus {
+		querySpec := eval.SelectorQuerySpec{
+			GK:       eval.GroupKindMatcher{IncludedKinds: target.Kinds},
+			Ns:       expandNamespace(namespaces[i]),
+			Selector: target.Selector,
 		}
 		s, err := s.evaluator.EvalQuery(ctx, querySpec, nil)
 		if err != nil {
 			klog.ErrorS(err, "failed to evaluate query", "query", querySpec)
-			continue
+			return nil
 		}
 		klog.V(3).InfoS("evaluated query", "query", querySpec, "objects", len(s))
-		statuses = append(statuses, TargetStatuses{Target: target, Statuses: s})
+		evaluated.Store(true)
+		return s
+	})
+	if target.GroupByNamespace {
+		return groupByNamespace(target, targetStatuses), evaluated.Load()
 	}
+	return []TargetStatuses{{Target: target, Statuses: targetStatuses}}, evaluated.Load()
+}
 
-	klog.V(1).InfoS("health data reloaded", "duration", time.Since(start))
+// groupByNamespace splits the statuses by the namespaces of the objects,
+// ordered by the namespace.
+func groupByNamespace(target Target, statuses []status.ObjectStatus) []TargetStatuses {
+	byNs := make(map[string][]status.ObjectStatus)
+	for _, s := range statuses {
+		ns := s.Object.GetNamespace()
+		byNs[ns] = append(byNs[ns], s)
+	}
 
-	s.eventChan <- TargetsStatusUpdate{
-		Statuses: statuses,
+	ret := make([]TargetStatuses, 0, len(byNs))
+	for _, ns := range slices.Sorted(maps.Keys(byNs)) {
+		ret = append(ret, TargetStatuses{Target: target, Statuses: byNs[ns], Namespace: ns})
 	}
+	return ret
 }
 
 func expandNamespace(ns string) string {
@@ -0,0 +1,181 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/rhobs/kube-health/pkg/analyze"
+	"github.com/rhobs/kube-health/pkg/eval"
+)
+
+func testConfigMap(ns, name string, lbls ...string) unstructured.Unstructured {
+	u := unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind("ConfigMap")
+	u.SetNamespace(ns)
+	u.SetName(name)
+	u.SetUID(types.UID(ns + "-" + name))
+	if len(lbls) > 0 {
+		labelsMap := make(map[string]string)
+		for _, l := range lbls {
+			k, v, _ := strings.Cut(l, "=")
+			labelsMap[k] = v
+		}
+		u.SetLabels(labelsMap)
+	}
+	return u
+}
+
+func TestMonitorPollerNamespaces(t *testing.T) {
+	loader := eval.NewFakeLoader()
+	_, err := loader.Register(
+		testConfigMap("ns1", "cm1"),
+		testConfigMap("ns2", "cm2", "team=payments"),
+		testConfigMap("ns3", "cm3"),
+	)
+	require.NoError(t, err)
+
+	cfg := Config{Targets: []Target{{
+		Category:   "config",
+		Kinds:      []schema.GroupKind{{Kind: "ConfigMap"}},
+		Namespaces: []string{"ns1", "ns2"},
+	}}}
+	evaluator := eval.NewEvaluator(analyze.DefaultAnalyzers(), loader)
+	poller := NewMonitorPoller(time.Hour, evaluator, cfg)
+
+	update := <-poller.Start(t.Context())
+	require.Len(t, update.Statuses, 1)
+	assert.ElementsMatch(t, []string{"cm1", "cm2"}, names(update.Statuses[0]))
+
+	cfg.Targets[0].Selector = labels.SelectorFromSet(labels.Set{"team": "payments"})
+	evaluator = eval.NewEvaluator(analyze.DefaultAnalyzers(), loader)
+	poller = NewMonitorPoller(time.Hour, evaluator, cfg)
+
+	update = <-poller.Start(t.Context())
+	require.Len(t, update.Statuses, 1)
+	assert.Equal(t, []string{"cm2"}, names(update.Statuses[0]))
+}
+
+func TestMonitorPollerGroupByNamespace(t *testing.T) {
+	loader := eval.NewFakeLoader()
+	_, err := loader.Register(
+		testConfigMap("ns1", "cm1", "team=payments"),
+		testConfigMap("ns1", "cm2", "team=payments"),
+		testConfigMap("ns2", "cm3", "team=payments"),
+		testConfigMap("ns2", "cm4"),
+		testConfigMap("ns3", "cm5"),
+	)
+	require.NoError(t, err)
+
+	// All the namespaces are evaluated and the results split by namespace.
+	cfg := Config{Targets: []Target{{
+		Category:         "payments",
+		Kinds:            []schema.GroupKind{{Kind: "ConfigMap"}},
+		Selector:         labels.SelectorFromSet(labels.Set{"team": "payments"}),
+		GroupByNamespace: true,
+	}}}
+	poller := NewMonitorPoller(time.Hour, eval.NewEvaluator(analyze.DefaultAnalyzers(), loader), cfg)
+
+	update := <-poller.Start(t.Context())
+	require.Len(t, update.Statuses, 2)
+	assert.Equal(t, "ns1", update.Statuses[0].Namespace)
+	assert.ElementsMatch(t, []string{"cm1", "cm2"}, names(update.Statuses[0]))
+	assert.Equal(t, "ns2", update.Statuses[1].Namespace)
+	assert.Equal(t, []string{"cm3"}, names(update.Statuses[1]))
+
+	e := NewExporter(nil, nil, "kube:health", "")
+	e.digestUpdate(update)
+	ms := e.nsScoreMs.(*metricSet)
+	assert.Equal(t, "kube:health:namespace_score", ms.name)
+	require.Len(t, ms.metrics, 2)
+	assert.Equal(t, prom.Labels{"category": "payments", "namespace": "ns1"}, ms.metrics[0].Labels)
+	assert.Equal(t, prom.Labels{"category": "payments", "namespace": "ns2"}, ms.metrics[1].Labels)
+}
+
+func names(ts TargetStatuses) []string {
+	var ret []string
+	for _, s := range ts.Statuses {
+		ret = append(ret, s.Object.Name)
+	}
+	return ret
+}
+
+func TestMonitorPollerTargetIntervals(t *testing.T) {
+	loader := eval.NewFakeLoader()
+	_, err := loader.Register(testConfigMap("fast", "cm1"), testConfigMap("slow", "cm1"))
+	require.NoError(t, err)
+
+	cfg := Config{Targets: []Target{
+		{
+			Category:   "fast",
+			Kinds:      []schema.GroupKind{{Kind: "ConfigMap"}},
+			Namespaces: []string{"fast"},
+			Interval:   time.Minute,
+		},
+		{
+			Category:   "slow",
+			Kinds:      []schema.GroupKind{{Kind: "ConfigMap"}},
+			Namespaces: []string{"slow"},
+		},
+	}}
+	poller := NewMonitorPoller(time.Hour, eval.NewEvaluator(analyze.DefaultAnalyzers(), loader), cfg).
+		WithJitter(0)
+
+	run := func(now time.Time) TargetsStatusUpdate {
+		go poller.run(t.Context(), now)
+		return <-poller.eventChan
+	}
+
+	start := time.Now()
+	update := run(start)
+	require.Len(t, update.Statuses, 2)
+	assert.Equal(t, start.Add(time.Minute), poller.nextDue())
+
+	_, err = loader.Register(testConfigMap("fast", "cm2"), testConfigMap("slow", "cm2"))
+	require.NoError(t, err)
+
+	// Only the fast target is due: the slow one keeps the previous results.
+	update = run(start.Add(2 * time.Minute))
+	require.Len(t, update.Statuses, 2)
+	assert.ElementsMatch(t, []string{"cm1", "cm2"}, names(update.Statuses[0]))
+	assert.Equal(t, []string{"cm1"}, names(update.Statuses[1]))
+
+	update = run(start.Add(time.Hour))
+	assert.ElementsMatch(t, []string{"cm1", "cm2"}, names(update.Statuses[1]))
+}
+
+func TestMonitorPollerJitter(t *testing.T) {
+	loader := eval.NewFakeLoader()
+	cfg := Config{Targets: []Target{{
+		Category: "config",
+		Kinds:    []schema.GroupKind{{Kind: "ConfigMap"}},
+	}}}
+	poller := NewMonitorPoller(time.Minute, eval.NewEvaluator(analyze.DefaultAnalyzers(), loader), cfg).
+		WithJitter(0.1)
+
+	now := time.Now()
+	var shorter, longer bool
+	for range 50 {
+		go poller.run(t.Context(), now)
+		<-poller.eventChan
+
+		delay := poller.nextRun[0].Sub(now)
+		assert.GreaterOrEqual(t, delay, 54*time.Second)
+		assert.LessOrEqual(t, delay, 66*time.Second)
+		shorter = shorter || delay < time.Minute
+		longer = longer || delay > time.Minute
+		now = poller.nextRun[0]
+	}
+	// The jitter goes both ways, keeping the average interval.
+	assert.True(t, shorter)
+	assert.True(t, longer)
+}
@@ -2,10 +2,14 @@ package monitor
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	prom "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -22,8 +26,10 @@ type Metric struct {
 type metricSet struct {
 	mtx     sync.RWMutex
 	metrics []Metric
-	name    string
-	help    string
+	// series are the keys of the label sets currently exposed.
+	series map[string]prom.Labels
+	name   string
+	help   string
 }
 
 // MetricSet is an expasion of prom.Collector interface that allows batch
@@ -38,16 +44,54 @@ func NewMetricSet(name, help string) *metricSet {
 	return &metricSet{name: name, help: help}
 }
 
+// Update replaces the exposed metrics. The series not present in the new
+// metrics are dropped, so that the deleted objects are no longer reported.
+// Metrics repeating the same label set are skipped: the registry would
+// otherwise reject the whole collection.
 func (m *metricSet) Update(metrics []Metric) {
 	m.mtx.Lock()
 	defer m.mtx.Unlock()
-	m.metrics = metrics
+
+	series := make(map[string]prom.Labels, len(metrics))
+	unique := make([]Metric, 0, len(metrics))
+	for _, metric := range metrics {
+		key := labelsKey(metric.Labels)
+		if _, found := series[key]; found {
+			klog.V(2).InfoS("Skipping duplicate series", "metric", m.name, "labels", metric.Labels)
+			continue
+		}
+		series[key] = metric.Labels
+		unique = append(unique, metric)
+	}
+
+	for key, labels := range m.series {
+		if _, found := series[key]; !found {
+			klog.V(2).InfoS("Dropping vanished series", "metric", m.name, "labels", labels)
+		}
+	}
+
+	m.metrics = unique
+	m.series = series
 }
 
 func (m *metricSet) Reset() {
 	m.mtx.Lock()
 	defer m.mtx.Unlock()
 	m.metrics = nil
+	m.series = nil
+}
+
+// labelsKey returns a key identifying the series by its label set.
+func labelsKey(labels prom.Labels) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		if k == "__name__" {
+			continue
+		}
+		pairs = append(pairs, k+"\xff"+v)
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, "\x00")
 }
 
 func (m *metricSet) Collect(ch chan<- prom.Metric) {
@@ -86,8 +130,18 @@ type SimpleServer struct {
 	host string
 	port int
 	mux  *http.ServeMux
+
+	// certFile and keyFile enable serving over HTTPS when set.
+	certFile string
+	keyFile  string
+	// token, when set, is required as a bearer token by the requests.
+	token string
 }
 
+// unauthenticatedPaths are served without the bearer token, so that
+// the probes don't need to be configured with it.
+var unauthenticatedPaths = []string{"/healthz", "/readyz"}
+
 func NewSimpleServer(host string, port int) *SimpleServer {
 	return &SimpleServer{
 		host: host,
@@ -96,6 +150,21 @@ func NewSimpleServer(host string, port int) *SimpleServer {
 	}
 }
 
+// WithTLS makes the server serve over HTTPS with the certificate and key
+// loaded from the files.
+func (s *SimpleServer) WithTLS(certFile, keyFile string) *SimpleServer {
+	s.certFile = certFile
+	s.keyFile = keyFile
+	return s
+}
+
+// WithBearerToken makes the server require the token in the Authorization
+// header. The requests without it are rejected with 401.
+func (s *SimpleServer) WithBearerToken(token string) *SimpleServer {
+	s.token = token
+	return s
+}
+
 func (s *SimpleServer) Handle(pattern string, handler http.Handler) {
 	s.mux.Handle(pattern, handler)
 }
@@ -103,7 +172,7 @@ func (s *SimpleServer) Handle(pattern string, handler http.Handler) {
 func (s *SimpleServer) Start(ctx context.Context) error {
 	server := &http.Server{
 		Addr:    fmt.Sprintf("%s:%d", s.host, s.port),
-		Handler: s.mux,
+		Handler: s.handler(),
 	}
 	var err error
 	stop := make(chan struct{})
@@ -119,7 +188,11 @@ func (s *SimpleServer) Start(ctx context.Context) error {
 	}()
 
 	go func() {
-		err = server.ListenAndServe()
+		if s.certFile != "" {
+			err = server.ListenAndServeTLS(s.certFile, s.keyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
 		close(stop)
 	}()
 
@@ -127,55 +200,200 @@ func (s *SimpleServer) Start(ctx context.Context) error {
 	return err
 }
 
+const (
+	// ScrapeDurationMetric exposes how long the last evaluation of the targets took.
+	ScrapeDurationMetric = "kube_health_scrape_duration_seconds"
+	// FailedTargetsMetric counts the evaluations of the targets that failed.
+	FailedTargetsMetric = "kube_health_failed_target_evaluations_total"
+)
+
+// handler returns the handler serving the requests, checking the bearer
+// token when configured.
+func (s *SimpleServer) handler() http.Handler {
+	if s.token == "" {
+		return s.mux
+	}
+	expected := []byte("Bearer " + s.token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !slices.Contains(unauthenticatedPaths, r.URL.Path) &&
+			subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		s.mux.ServeHTTP(w, r)
+	})
+}
+
 type Exporter struct {
-	updatesChan <-chan TargetsStatusUpdate
-	server      Server
-	ms          MetricSet
+	updatesChan    <-chan TargetsStatusUpdate
+	server         Server
+	ms             MetricSet
+	scoreMs        MetricSet
+	oldestMs       MetricSet
+	nsScoreMs      MetricSet
+	conditionMs    MetricSet
+	scrapeDuration prom.Gauge
+	failedTargets  prom.Counter
+	scoreWeights   status.ScoreWeights
+	// conditionTypes is the allowlist of the condition types exposed
+	// via conditionMs.
+	conditionTypes []string
+	// ready is set once the first update gets digested.
+	ready atomic.Bool
 }
 
+// NewExporter creates an exporter exposing the statuses under metricName.
+// The overall health score is exposed under metricName + ":score" and the object
+// unhealthy for the longest time under metricName + ":oldest_unhealthy_seconds".
+// The targets grouped by namespace get the score of each namespace exposed
+// under metricName + ":namespace_score". The conditions allowed by
+// WithConditionMetrics are exposed under metricName + ":condition".
+// The monitor itself is observed via ScrapeDurationMetric and FailedTargetsMetric.
 func NewExporter(updatesChan <-chan TargetsStatusUpdate, server Server,
 	metricName, metricDescription string) *Exporter {
 	return &Exporter{
 		updatesChan: updatesChan,
 		server:      server,
 		ms:          NewMetricSet(metricName, metricDescription),
+		scoreMs:     NewMetricSet(metricName+":score", "Overall health score of the monitored objects (0-100)"),
+		oldestMs: NewMetricSet(metricName+":oldest_unhealthy_seconds",
+			"Time in seconds since the longest unhealthy monitored object got unhealthy"),
+		nsScoreMs: NewMetricSet(metricName+":namespace_score",
+			"Health score of the monitored objects (0-100) per namespace, for the targets grouped by namespace"),
+		conditionMs: NewMetricSet(metricName+":condition",
+			"Health status of the conditions of the monitored objects"),
+		scrapeDuration: prom.NewGauge(prom.GaugeOpts{
+			Name: ScrapeDurationMetric,
+			Help: "Time in seconds the last evaluation of the monitored targets took",
+		}),
+		failedTargets: prom.NewCounter(prom.CounterOpts{
+			Name: FailedTargetsMetric,
+			Help: "Number of the monitored target evaluations that failed",
+		}),
+		scoreWeights: status.DefaultScoreWeights,
 	}
 }
 
+// WithScoreWeights sets the weights used for calculating the health score.
+func (e *Exporter) WithScoreWeights(weights status.ScoreWeights) *Exporter {
+	e.scoreWeights = weights
+	return e
+}
+
+// WithConditionMetrics enables a metric per condition of the monitored objects
+// for the condition types (case-insensitive). The allowlist bounds
+// the cardinality: no condition metrics are exposed when empty.
+func (e *Exporter) WithConditionMetrics(types ...string) *Exporter {
+	e.conditionTypes = types
+	return e
+}
+
 func (e *Exporter) Start(ctx context.Context) error {
 	go e.digestUpdates()
 	e.registerMetrics()
+	e.registerProbes()
 
 	return e.startServer(ctx)
 }
 
 func (e *Exporter) digestUpdates() {
 	for update := range e.updatesChan {
-		var metrics []Metric
-		for _, part := range update.Statuses {
-			klog.V(2).InfoS("Received update", "objects", len(part.Statuses))
-			for _, status := range part.Statuses {
-				metric := statusToMetric(part.Target.Category, status)
-				klog.V(3).InfoS("Converted status to metric", "metric", metric)
-				metrics = append(metrics, metric)
+		e.digestUpdate(update)
+	}
+}
+
+func (e *Exporter) digestUpdate(update TargetsStatusUpdate) {
+	var metrics, nsScoreMetrics, conditionMetrics []Metric
+	for _, part := range update.Statuses {
+		klog.V(2).InfoS("Received update", "objects", len(part.Statuses))
+		for _, status := range part.Statuses {
+			metric := statusToMetric(part.Target, status)
+			klog.V(3).InfoS("Converted status to metric", "metric", metric)
+			metrics = append(metrics, metric)
+
+			for _, cond := range status.Conditions {
+				if e.conditionAllowed(cond.Type) {
+					conditionMetrics = append(conditionMetrics, conditionToMetric(part.Target, status, cond))
+				}
 			}
 		}
-		e.ms.Update(metrics)
+		if part.Target.GroupByNamespace {
+			nsScoreMetrics = append(nsScoreMetrics, Metric{
+				Labels: prom.Labels{"category": part.Target.Category, "namespace": part.Namespace},
+				Value:  status.Score(part.Statuses, e.scoreWeights),
+			})
+		}
+	}
+	e.ms.Update(metrics)
+	e.nsScoreMs.Update(nsScoreMetrics)
+	e.conditionMs.Update(conditionMetrics)
+
+	statusUpdate := update.ToStatusUpdate()
+	score := statusUpdate.Score(e.scoreWeights)
+	e.scoreMs.Update([]Metric{{Labels: prom.Labels{}, Value: score}})
+
+	var oldestMetrics []Metric
+	if oldest, since, found := status.OldestUnhealthy(statusUpdate.Statuses); found {
+		oldestMetrics = append(oldestMetrics, Metric{
+			Labels: prom.Labels{
+				"kind":      oldest.Object.Kind,
+				"name":      oldest.Object.Name,
+				"namespace": oldest.Object.Namespace,
+			},
+			Value: time.Since(since).Seconds(),
+		})
 	}
+	e.oldestMs.Update(oldestMetrics)
+
+	e.scrapeDuration.Set(update.Duration.Seconds())
+	e.failedTargets.Add(float64(update.FailedTargets))
+
+	e.ready.Store(true)
 }
 
 func (e *Exporter) registerMetrics() {
 	reg := prom.NewRegistry()
 	reg.MustRegister(e.ms)
+	reg.MustRegister(e.scoreMs)
+	reg.MustRegister(e.oldestMs)
+	reg.MustRegister(e.nsScoreMs)
+	reg.MustRegister(e.conditionMs)
+	reg.MustRegister(e.scrapeDuration)
+	reg.MustRegister(e.failedTargets)
 
 	e.server.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
 }
 
+// registerProbes registers the liveness and readiness probe handlers.
+// The exporter is ready once the metrics get populated by the first update.
+func (e *Exporter) registerProbes() {
+	e.server.Handle("/healthz", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	}))
+	e.server.Handle("/readyz", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !e.ready.Load() {
+			http.Error(w, "waiting for the first poll", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	}))
+}
+
 func (e *Exporter) startServer(ctx context.Context) error {
 	return e.server.Start(ctx)
 }
 
-func statusToMetric(category string, objStatus status.ObjectStatus) Metric {
+// OptionalLabels are the labels that can be added to the status metrics
+// via the target extraLabels. They identify the objects unambiguously.
+var OptionalLabels = []string{"uid", "group"}
+
+// DroppableLabels are the labels that can be removed from the status metrics
+// via the target dropLabels. The objects sharing the rest of the labels are
+// then exposed as a single series.
+var DroppableLabels = []string{"name", "namespace"}
+
+func statusToMetric(target Target, objStatus status.ObjectStatus) Metric {
 	status := objStatus.Status()
 	// We add "progressing" as extra result + expose the original value as result_details.
 	statusStr := strings.ToLower(status.Result.String())
@@ -183,19 +401,51 @@ func statusToMetric(category string, objStatus status.ObjectStatus) Metric {
 		statusStr = "progressing"
 	}
 
+	labels := prom.Labels{
+		"kind":      objStatus.Object.Kind,
+		"name":      objStatus.Object.Name,
+		"namespace": objStatus.Object.Namespace,
+		"status":    statusStr,
+		"result":    strings.ToLower(status.Result.String()),
+		"category":  target.Category,
+	}
+	for _, l := range target.ExtraLabels {
+		switch l {
+		case "uid":
+			labels["uid"] = string(objStatus.Object.GetUID())
+		case "group":
+			labels["group"] = objStatus.Object.GroupVersionKind().Group
+		}
+	}
+	for _, l := range target.DropLabels {
+		delete(labels, l)
+	}
+
 	return Metric{
-		Labels: prom.Labels{
-			"kind":      objStatus.Object.Kind,
-			"name":      objStatus.Object.Name,
-			"namespace": objStatus.Object.Namespace,
-			"status":    statusStr,
-			"result":    strings.ToLower(status.Result.String()),
-			"category":  category,
-		},
-		Value: resultToValue(status),
+		Labels: labels,
+		Value:  resultToValue(status),
 	}
 }
 
+func (e *Exporter) conditionAllowed(condType string) bool {
+	return slices.ContainsFunc(e.conditionTypes, func(t string) bool {
+		return strings.EqualFold(t, condType)
+	})
+}
+
+// conditionToMetric converts the condition of the object to a metric.
+// Besides the labels identifying the object (see statusToMetric), it has
+// the type and status of the condition, and the result it contributes.
+func conditionToMetric(target Target, objStatus status.ObjectStatus, cond status.ConditionStatus) Metric {
+	metric := statusToMetric(target, objStatus)
+	result := cond.Status()
+	metric.Labels["type"] = cond.Type
+	metric.Labels["status"] = string(cond.Condition.Status)
+	metric.Labels["result"] = strings.ToLower(result.Result.String())
+	metric.Value = resultToValue(result)
+	return metric
+}
+
 // resultToValue converts status.Result to a float64 value.
 // The value can be used to represent the status in Prometheus metrics
 func resultToValue(s status.Status) float64 {
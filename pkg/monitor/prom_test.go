@@ -0,0 +1,201 @@
+package monitor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestExporterScore(t *testing.T) {
+	e := NewExporter(nil, nil, "kube:health", "")
+	ms := e.scoreMs.(*metricSet)
+
+	update := testUpdate("n1", "n2", "n3", "n4")
+	update.Statuses[0].Statuses[2].ObjStatus.Result = status.Warning
+	update.Statuses[0].Statuses[3].ObjStatus.Result = status.Error
+
+	e.digestUpdate(update)
+	require.Len(t, ms.metrics, 1)
+	assert.Equal(t, "kube:health:score", ms.name)
+	assert.InDelta(t, 62.5, ms.metrics[0].Value, 0.001)
+
+	e.WithScoreWeights(status.ScoreWeights{status.Ok: 1})
+	e.digestUpdate(update)
+	assert.InDelta(t, 50, ms.metrics[0].Value, 0.001)
+}
+
+func TestExporterOldestUnhealthy(t *testing.T) {
+	e := NewExporter(nil, nil, "kube:health", "")
+	ms := e.oldestMs.(*metricSet)
+
+	update := testUpdate("n1", "n2")
+	e.digestUpdate(update)
+	assert.Empty(t, ms.metrics)
+
+	n2 := &update.Statuses[0].Statuses[1]
+	n2.ObjStatus.Result = status.Error
+	n2.Conditions = []status.ConditionStatus{{
+		Condition: &metav1.Condition{
+			Type:               "Ready",
+			LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Hour)),
+		},
+		CondStatus: &status.Status{Result: status.Error},
+	}}
+
+	e.digestUpdate(update)
+	require.Len(t, ms.metrics, 1)
+	assert.Equal(t, "kube:health:oldest_unhealthy_seconds", ms.name)
+	assert.Equal(t, "n2", ms.metrics[0].Labels["name"])
+	assert.InDelta(t, 3600, ms.metrics[0].Value, 60)
+}
+
+func TestExporterConditionMetrics(t *testing.T) {
+	e := NewExporter(nil, nil, "kube:health", "")
+	ms := e.conditionMs.(*metricSet)
+
+	update := testUpdate("n1")
+	n1 := &update.Statuses[0].Statuses[0]
+	cond := func(condType string, condStatus metav1.ConditionStatus, result status.Result) status.ConditionStatus {
+		return status.ConditionStatus{
+			Condition:  &metav1.Condition{Type: condType, Status: condStatus},
+			CondStatus: &status.Status{Result: result},
+		}
+	}
+	n1.Conditions = []status.ConditionStatus{
+		cond("Ready", metav1.ConditionTrue, status.Ok),
+		cond("MemoryPressure", metav1.ConditionTrue, status.Warning),
+		cond("DiskPressure", metav1.ConditionFalse, status.Ok),
+	}
+
+	// Disabled unless the condition types are allowed.
+	e.digestUpdate(update)
+	assert.Empty(t, ms.metrics)
+
+	e.WithConditionMetrics("ready", "MemoryPressure")
+	e.digestUpdate(update)
+	assert.Equal(t, "kube:health:condition", ms.name)
+	require.Len(t, ms.metrics, 2)
+	assert.Equal(t, prom.Labels{"kind": "Node", "name": "n1", "namespace": "", "category": "compute",
+		"type": "Ready", "status": "True", "result": "ok"}, ms.metrics[0].Labels)
+	assert.InDelta(t, 0, ms.metrics[0].Value, 0.001)
+	assert.Equal(t, prom.Labels{"kind": "Node", "name": "n1", "namespace": "", "category": "compute",
+		"type": "MemoryPressure", "status": "True", "result": "warning"}, ms.metrics[1].Labels)
+	assert.InDelta(t, 1, ms.metrics[1].Value, 0.001)
+}
+
+func TestExporterSelfMetrics(t *testing.T) {
+	e := NewExporter(nil, nil, "kube:health", "")
+
+	update := testUpdate("n1")
+	update.Duration = 1500 * time.Millisecond
+	update.FailedTargets = 2
+	e.digestUpdate(update)
+
+	update.Duration = 500 * time.Millisecond
+	update.FailedTargets = 1
+	e.digestUpdate(update)
+
+	assert.InDelta(t, 0.5, testutil.ToFloat64(e.scrapeDuration), 0.001)
+	assert.InDelta(t, 3, testutil.ToFloat64(e.failedTargets), 0.001)
+}
+
+func TestExporterProbes(t *testing.T) {
+	server := NewSimpleServer("localhost", 0)
+	e := NewExporter(nil, server, "kube:health", "")
+	e.registerProbes()
+
+	get := func(path string) int {
+		rec := httptest.NewRecorder()
+		server.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, get("/healthz"))
+	assert.Equal(t, http.StatusServiceUnavailable, get("/readyz"))
+
+	e.digestUpdate(testUpdate("n1"))
+	assert.Equal(t, http.StatusOK, get("/healthz"))
+	assert.Equal(t, http.StatusOK, get("/readyz"))
+}
+
+func TestSimpleServerBearerToken(t *testing.T) {
+	server := NewSimpleServer("localhost", 0).WithBearerToken("secret")
+	server.Handle("/metrics", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Handle("/healthz", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	get := func(path, auth string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		server.handler().ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, get("/metrics", ""))
+	assert.Equal(t, http.StatusUnauthorized, get("/metrics", "Bearer wrong"))
+	assert.Equal(t, http.StatusOK, get("/metrics", "Bearer secret"))
+	// The probes don't need the token.
+	assert.Equal(t, http.StatusOK, get("/healthz", ""))
+}
+
+func TestExporterDropsVanishedSeries(t *testing.T) {
+	e := NewExporter(nil, nil, "kube:health", "")
+
+	seriesNames := func() []string {
+		var ret []string
+		for _, m := range e.ms.(*metricSet).metrics {
+			ret = append(ret, m.Labels["name"])
+		}
+		return ret
+	}
+
+	e.digestUpdate(testUpdate("n1", "n2"))
+	assert.Equal(t, 2, testutil.CollectAndCount(e.ms))
+
+	// n2 got deleted in the meantime.
+	e.digestUpdate(testUpdate("n1"))
+	assert.Equal(t, 1, testutil.CollectAndCount(e.ms))
+	assert.Equal(t, []string{"n1"}, seriesNames())
+
+	// The same object reported twice is exposed only once.
+	update := testUpdate("n1")
+	update.Statuses = append(update.Statuses, update.Statuses[0])
+	e.digestUpdate(update)
+	assert.Equal(t, 1, testutil.CollectAndCount(e.ms))
+}
+
+func TestExporterTargetLabels(t *testing.T) {
+	e := NewExporter(nil, nil, "kube:health", "")
+	ms := e.ms.(*metricSet)
+
+	update := testUpdate("n1", "n2")
+	e.digestUpdate(update)
+	require.Len(t, ms.metrics, 2)
+	assert.Equal(t, prom.Labels{
+		"kind": "Node", "name": "n1", "namespace": "", "status": "ok", "result": "ok", "category": "compute",
+	}, ms.metrics[0].Labels)
+
+	update.Statuses[0].Target.ExtraLabels = []string{"uid", "group"}
+	update.Statuses[0].Statuses[0].Object.UID = "uid-1"
+	e.digestUpdate(update)
+	assert.Equal(t, "uid-1", ms.metrics[0].Labels["uid"])
+	assert.Contains(t, ms.metrics[0].Labels, "group")
+
+	// Without the name, the nodes share a single series.
+	update.Statuses[0].Target.ExtraLabels = nil
+	update.Statuses[0].Target.DropLabels = []string{"name"}
+	e.digestUpdate(update)
+	require.Len(t, ms.metrics, 1)
+	assert.NotContains(t, ms.metrics[0].Labels, "name")
+}
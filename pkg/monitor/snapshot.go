@@ -0,0 +1,92 @@
+package monitor
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/klog/v2"
+
+	"github.com/rhobs/kube-health/pkg/print"
+)
+
+// SnapshotWriter writes the latest status update into a file, so that it can
+// be consumed by tools without access to the metrics endpoint. The snapshot
+// includes the time of the write, telling a stale file from a fresh one.
+type SnapshotWriter struct {
+	path    string
+	printer printers.ResourcePrinter
+}
+
+// NewSnapshotWriter creates a writer for the given path. Supported formats
+// are "json" and "yaml".
+func NewSnapshotWriter(path, format string) (*SnapshotWriter, error) {
+	var p printers.ResourcePrinter
+	switch format {
+	case "json":
+		p = &printers.JSONPrinter{}
+	case "yaml":
+		p = &printers.YAMLPrinter{}
+	default:
+		return nil, fmt.Errorf("unsupported snapshot format %q: use one of (json, yaml)", format)
+	}
+
+	return &SnapshotWriter{
+		path:    path,
+		printer: p,
+	}, nil
+}
+
+// Write replaces the content of the file with the update. The file is
+// replaced atomically, so the readers never see a partially written snapshot.
+func (w *SnapshotWriter) Write(update TargetsStatusUpdate) error {
+	report := print.NewHealthReport(update.ToStatusUpdate().Statuses)
+	report.Timestamp = &metav1.Time{Time: time.Now()}
+
+	var buf bytes.Buffer
+	if err := w.printer.PrintObj(report, &buf); err != nil {
+		return fmt.Errorf("failed to print snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(w.path), "."+filepath.Base(w.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	// The temporary file is private to the owner: make the snapshot
+	// readable by the other tools as a regularly created file would be.
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to set snapshot file mode: %w", err)
+	}
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot file: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), w.path)
+}
+
+// Filter writes every update passing through the channel into the snapshot
+// file and forwards it unchanged.
+func (w *SnapshotWriter) Filter(updateChan <-chan TargetsStatusUpdate) <-chan TargetsStatusUpdate {
+	outChan := make(chan TargetsStatusUpdate)
+	go func() {
+		defer close(outChan)
+		for update := range updateChan {
+			if err := w.Write(update); err != nil {
+				klog.ErrorS(err, "failed to write status snapshot", "path", w.path)
+			}
+			outChan <- update
+		}
+	}()
+	return outChan
+}
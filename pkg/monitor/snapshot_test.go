@@ -0,0 +1,82 @@
+package monitor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func testUpdate(names ...string) TargetsStatusUpdate {
+	var statuses []status.ObjectStatus
+	for _, name := range names {
+		statuses = append(statuses, status.OkStatus(&status.Object{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Node"},
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+		}, nil))
+	}
+	return TargetsStatusUpdate{Statuses: []TargetStatuses{
+		{Target: Target{Category: "compute"}, Statuses: statuses},
+	}}
+}
+
+func readSnapshotNames(t *testing.T, path string) []string {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var snapshot struct {
+		Timestamp *metav1.Time `json:"timestamp"`
+		Items     []struct {
+			Object struct {
+				Name string `json:"name"`
+			} `json:"object"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(data, &snapshot))
+	assert.NotNil(t, snapshot.Timestamp, "the snapshot tells when it was written")
+
+	var names []string
+	for _, item := range snapshot.Items {
+		names = append(names, item.Object.Name)
+	}
+	return names
+}
+
+func TestSnapshotWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	w, err := NewSnapshotWriter(path, "json")
+	require.NoError(t, err)
+
+	in := make(chan TargetsStatusUpdate)
+	out := w.Filter(in)
+
+	in <- testUpdate("node-1")
+	assert.Equal(t, testUpdate("node-1"), <-out)
+	assert.Equal(t, []string{"node-1"}, readSnapshotNames(t, path))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o644), info.Mode().Perm())
+
+	in <- testUpdate("node-1", "node-2")
+	<-out
+	assert.Equal(t, []string{"node-1", "node-2"}, readSnapshotNames(t, path))
+
+	close(in)
+	_, open := <-out
+	assert.False(t, open)
+
+	// No leftover temporary files.
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	_, err = NewSnapshotWriter(path, "xml")
+	assert.Error(t, err)
+}
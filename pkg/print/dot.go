@@ -0,0 +1,96 @@
+package print
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// DotPrinter prints the objects and their sub-objects as a Graphviz DOT
+// graph, with the nodes colored by the result. Unlike the tree, an object
+// shared by multiple parents (e.g. a Pod selected by several Services)
+// is a single node with multiple incoming edges.
+//
+// The output is meant to be piped into the dot tool, e.g. `dot -Tsvg`.
+type DotPrinter struct{}
+
+var dotColors = map[status.Result]string{
+	status.Unknown: "#d3d3d3",
+	status.Ok:      "#b7e4b7",
+	status.Warning: "#ffe08a",
+	status.Error:   "#f4a6a6",
+}
+
+func (DotPrinter) PrintStatuses(statuses []status.ObjectStatus, w io.Writer) {
+	g := &dotGraph{nodes: make(map[string]bool), edges: make(map[[2]string]bool)}
+	sortObjects(statuses, SortByName)
+	for _, obj := range statuses {
+		g.addObject(obj, true)
+	}
+
+	fmt.Fprintln(w, "digraph kubehealth {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, `  node [shape=box, style="rounded,filled"];`)
+	for _, line := range g.lines {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// dotGraph collects the nodes and edges, keeping each of them only once.
+type dotGraph struct {
+	nodes map[string]bool
+	edges map[[2]string]bool
+	lines []string
+}
+
+// addObject adds the object with its sub-objects to the graph and returns
+// the node ID.
+func (g *dotGraph) addObject(obj status.ObjectStatus, root bool) string {
+	id := dotNodeID(obj)
+	if g.nodes[id] {
+		return id
+	}
+	g.nodes[id] = true
+
+	name := fmt.Sprintf("%s/%s", obj.Object.Kind, obj.Object.GetName())
+	if root {
+		name = formatObjectName(obj)
+	}
+	st := obj.Status()
+	attrs := fmt.Sprintf("label=%s, fillcolor=%q", dotQuote(name+"\n"+statusMessage(st)), dotColors[st.Result])
+	if st.Progressing {
+		attrs += `, style="rounded,filled,dashed"`
+	}
+	g.lines = append(g.lines, fmt.Sprintf("%s [%s];", dotQuote(id), attrs))
+
+	subObjects := obj.SubStatuses
+	sortObjects(subObjects, SortByName)
+	for _, sub := range subObjects {
+		subID := g.addObject(sub, false)
+		edge := [2]string{id, subID}
+		if g.edges[edge] {
+			continue
+		}
+		g.edges[edge] = true
+		g.lines = append(g.lines, fmt.Sprintf("%s -> %s;", dotQuote(id), dotQuote(subID)))
+	}
+	return id
+}
+
+// dotNodeID identifies the object in the graph: by its UID when available
+// (e.g. not for the objects from manifests), by its kind and name otherwise.
+func dotNodeID(obj status.ObjectStatus) string {
+	if uid := obj.Object.GetUID(); uid != "" {
+		return string(uid)
+	}
+	return fmt.Sprintf("%s/%s/%s", obj.Object.GetNamespace(), obj.Object.Kind, obj.Object.GetName())
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
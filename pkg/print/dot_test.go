@@ -0,0 +1,35 @@
+package print_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/analyze"
+	"github.com/rhobs/kube-health/pkg/print"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestDotPrinter(t *testing.T) {
+	// The pod is selected by both services.
+	pod := analyze.AggregateResult(testObject("Pod", "p1"), nil, []status.ConditionStatus{
+		analyze.SyntheticConditionError("Ready", "ContainersNotReady", "")})
+	svc1 := analyze.AggregateResult(testObject("Service", "svc1"), []status.ObjectStatus{pod}, nil)
+	svc2 := analyze.AggregateResult(testObject("Service", "svc2"), []status.ObjectStatus{pod}, nil)
+	cm := status.OkStatus(testObject("ConfigMap", `cm"1`), nil)
+
+	sb := &strings.Builder{}
+	print.DotPrinter{}.PrintStatuses([]status.ObjectStatus{svc2, svc1, cm}, sb)
+	test.AssertStr(t, `
+digraph kubehealth {
+  rankdir=LR;
+  node [shape=box, style="rounded,filled"];
+  "uid-cm\"1" [label="default/ConfigMap/cm\"1\nOk", fillcolor="#b7e4b7"];
+  "uid-svc1" [label="default/Service/svc1\nError", fillcolor="#f4a6a6"];
+  "uid-p1" [label="Pod/p1\nError", fillcolor="#f4a6a6"];
+  "uid-svc1" -> "uid-p1";
+  "uid-svc2" [label="default/Service/svc2\nError", fillcolor="#f4a6a6"];
+  "uid-svc2" -> "uid-p1";
+}
+`, sb.String())
+}
@@ -0,0 +1,38 @@
+package print
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/rhobs/kube-health/pkg/eval"
+)
+
+// PrintDrift prints the differences between the expected and live objects
+// as a table, one changed field per line. The warnings go first.
+func PrintDrift(report eval.DriftReport, w io.Writer) {
+	for _, warning := range report.Warnings {
+		fmt.Fprintf(w, "Warning: %s\n", warning)
+	}
+	if report.InSync() {
+		fmt.Fprintln(w, "No drift detected")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "DRIFT\tOBJECT\tDETAILS")
+	for _, d := range report.Drifts {
+		if len(d.Fields) == 0 {
+			fmt.Fprintf(tw, "%s\t%s\t\n", d.Type, d.Identity)
+			continue
+		}
+		for i, f := range d.Fields {
+			if i == 0 {
+				fmt.Fprintf(tw, "%s\t%s\t%s\n", d.Type, d.Identity, f)
+			} else {
+				fmt.Fprintf(tw, "\t\t%s\n", f)
+			}
+		}
+	}
+	tw.Flush()
+}
@@ -0,0 +1,45 @@
+package print_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/eval"
+	"github.com/rhobs/kube-health/pkg/print"
+)
+
+func TestPrintDrift(t *testing.T) {
+	gk := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+	report := eval.DriftReport{Drifts: []eval.Drift{
+		{
+			Type:     eval.DriftChanged,
+			Identity: eval.ObjectIdentity{GroupKind: gk, Namespace: "default", Name: "dp1"},
+			Fields: []eval.FieldDrift{
+				{Path: "spec.replicas", Expected: int64(3), Actual: int64(1)},
+				{Path: "metadata.labels.app", Expected: "dp1", Actual: nil},
+			},
+		},
+		{Type: eval.DriftMissing, Identity: eval.ObjectIdentity{GroupKind: gk, Namespace: "default", Name: "dp2"}},
+	}}
+
+	sb := &strings.Builder{}
+	print.PrintDrift(report, sb)
+	test.AssertStr(t, `
+DRIFT    OBJECT                       DETAILS
+Changed  default/Deployment.apps/dp1  spec.replicas: expected 3, got 1
+                                      metadata.labels.app: expected dp1, got <nil>
+Missing  default/Deployment.apps/dp2
+`, sb.String())
+
+	sb.Reset()
+	print.PrintDrift(eval.DriftReport{}, sb)
+	assert.Equal(t, "No drift detected\n", sb.String())
+
+	sb.Reset()
+	print.PrintDrift(eval.DriftReport{Warnings: []string{"Can't load the live objects of Secret"}}, sb)
+	assert.Equal(t, "Warning: Can't load the live objects of Secret\nNo drift detected\n", sb.String())
+}
@@ -0,0 +1,123 @@
+package print
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// JUnitPrinter prints the statuses as a JUnit XML report, to be consumed
+// by the CI systems. Each top-level object maps to a testcase:
+//   - objects with Error result are reported as failures,
+//   - objects with Warning result are reported as failures only with FailOnWarning,
+//   - objects with Unknown result are reported as skipped,
+//   - the rest passes.
+//
+// The failure text lists the unhealthy conditions of the object and its
+// sub-objects.
+type JUnitPrinter struct {
+	FailOnWarning bool
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+func (p JUnitPrinter) PrintStatuses(statuses []status.ObjectStatus, w io.Writer) {
+	suite := junitTestSuite{Name: "kube-health"}
+	for _, s := range statuses {
+		tc := junitTestCase{
+			Name:      formatObjectName(s),
+			ClassName: s.Object.Kind,
+		}
+
+		res := s.Status().Result
+		details := strings.Join(unhealthyConditions(s), "\n")
+		switch {
+		case res == status.Error || (res == status.Warning && p.FailOnWarning):
+			tc.Failure = &junitMessage{Message: res.String(), Type: res.String(), Text: details}
+			suite.Failures++
+		case res == status.Unknown:
+			tc.Skipped = &junitMessage{Message: "Unknown status", Text: details}
+			suite.Skipped++
+		default:
+			tc.SystemOut = details
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	suite.Tests = len(suite.TestCases)
+
+	report := junitTestSuites{
+		Name:     suite.Name,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	out, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Fprint(w, xml.Header)
+	fmt.Fprintln(w, string(out))
+}
+
+// unhealthyConditions returns a line for each condition of the object and
+// its sub-objects that is not OK.
+func unhealthyConditions(obj status.ObjectStatus) []string {
+	var ret []string
+	if err := obj.ObjStatus.Err; err != nil {
+		ret = append(ret, fmt.Sprintf("%s: %s", formatObjectName(obj), err))
+	}
+	for _, cond := range obj.Conditions {
+		if cond.CondStatus.Result <= status.Ok && !cond.CondStatus.Progressing {
+			continue
+		}
+		res := cond.CondStatus.Result.String()
+		if cond.CondStatus.Progressing {
+			res = "Progressing"
+		}
+		line := fmt.Sprintf("%s: %s (%s)", formatObjectName(obj), cond.Type, res)
+		for _, s := range []string{cond.Reason, cond.Message} {
+			if s != "" {
+				line += " " + s
+			}
+		}
+		ret = append(ret, line)
+	}
+	for _, sub := range obj.SubStatuses {
+		ret = append(ret, unhealthyConditions(sub)...)
+	}
+	return ret
+}
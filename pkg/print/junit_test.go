@@ -0,0 +1,60 @@
+package print_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/analyze"
+	"github.com/rhobs/kube-health/pkg/print"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestJUnitPrinter(t *testing.T) {
+	warning := analyze.AggregateResult(testObject("Node", "n1"), nil, []status.ConditionStatus{
+		analyze.SyntheticConditionWarning("MemoryPressure", "KubeletHasInsufficientMemory", "low memory")})
+	ok := analyze.AggregateResult(testObject("Service", "svc"), nil, []status.ConditionStatus{
+		analyze.SyntheticConditionOk("Ready", "")})
+	unknown := status.UnknownStatus(testObject("Foo", "foo"))
+	statuses := append(testTree(), warning, ok, unknown)
+
+	sb := &strings.Builder{}
+	print.JUnitPrinter{}.PrintStatuses(statuses, sb)
+	test.AssertStr(t, `
+<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="kube-health" tests="4" failures="1" skipped="1">
+  <testsuite name="kube-health" tests="4" failures="1" skipped="1">
+    <testcase name="default/Deployment/dp" classname="Deployment">
+      <failure message="Error" type="Error">default/ReplicaSet/rs: ReplicasReady (Error) NotReady Ready: 1/2&#xA;default/Pod/p1: Ready (Error) ContainersNotReady&#xA;default/Container/c1: Waiting (Error) CrashLoopBackOff back-off restarting failed container</failure>
+    </testcase>
+    <testcase name="default/Node/n1" classname="Node">
+      <system-out>default/Node/n1: MemoryPressure (Warning) KubeletHasInsufficientMemory low memory</system-out>
+    </testcase>
+    <testcase name="default/Service/svc" classname="Service"></testcase>
+    <testcase name="default/Foo/foo" classname="Foo">
+      <skipped message="Unknown status"></skipped>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, sb.String())
+
+	sb = &strings.Builder{}
+	print.JUnitPrinter{FailOnWarning: true}.PrintStatuses(statuses, sb)
+	test.AssertStr(t, `
+<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="kube-health" tests="4" failures="2" skipped="1">
+  <testsuite name="kube-health" tests="4" failures="2" skipped="1">
+    <testcase name="default/Deployment/dp" classname="Deployment">
+      <failure message="Error" type="Error">default/ReplicaSet/rs: ReplicasReady (Error) NotReady Ready: 1/2&#xA;default/Pod/p1: Ready (Error) ContainersNotReady&#xA;default/Container/c1: Waiting (Error) CrashLoopBackOff back-off restarting failed container</failure>
+    </testcase>
+    <testcase name="default/Node/n1" classname="Node">
+      <failure message="Warning" type="Warning">default/Node/n1: MemoryPressure (Warning) KubeletHasInsufficientMemory low memory</failure>
+    </testcase>
+    <testcase name="default/Service/svc" classname="Service"></testcase>
+    <testcase name="default/Foo/foo" classname="Foo">
+      <skipped message="Unknown status"></skipped>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, sb.String())
+}
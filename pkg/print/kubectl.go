@@ -4,10 +4,8 @@ import (
 	"io"
 
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/cli-runtime/pkg/printers"
 
 	"github.com/rhobs/kube-health/pkg/status"
@@ -16,50 +14,95 @@ import (
 // Genric printer as a wrapper around kubectl standard printers, to produce
 // json, yaml and other standard printing capabilities.
 
-type KubectlPrinter struct {
-	Printer printers.ResourcePrinter
-}
+const (
+	// HealthReportAPIVersion and HealthReportKind identify the structured output.
+	HealthReportAPIVersion = "kube-health.io/v1"
+	HealthReportKind       = "HealthReport"
+	// HealthReportSchemaVersion is bumped on incompatible changes of the
+	// structure of the items. Adding new fields is considered compatible.
+	HealthReportSchemaVersion = "1"
+)
 
-type objectWrapper struct {
-	Object     corev1.ObjectReference   `json:"object"`
-	Status     status.Status            `json:"health"`
-	Conditions []status.ConditionStatus `json:"conditions,omitempty"`
-	Subobjects []*objectWrapper         `json:"subobjects,omitempty"`
+// HealthReport is the envelope of the structured (json, yaml, ...) output.
+type HealthReport struct {
+	metav1.TypeMeta `json:",inline"`
+	SchemaVersion   string `json:"schemaVersion"`
+	// Timestamp tells when the statuses were evaluated, if known.
+	Timestamp *metav1.Time  `json:"timestamp,omitempty"`
+	Items     []*ReportItem `json:"items"`
 }
 
-// objectWrapper implements runtime.Object interface
-var _ runtime.Object = &objectWrapper{}
+// HealthReport implements runtime.Object interface
+var _ runtime.Object = &HealthReport{}
 
-func (ow *objectWrapper) GetObjectKind() schema.ObjectKind {
-	return schema.EmptyObjectKind
+// NewHealthReport wraps the statuses into the report envelope.
+func NewHealthReport(statuses []status.ObjectStatus) *HealthReport {
+	items := make([]*ReportItem, 0, len(statuses))
+	for _, s := range statuses {
+		items = append(items, NewReportItem(s))
+	}
 
+	return &HealthReport{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: HealthReportAPIVersion,
+			Kind:       HealthReportKind,
+		},
+		SchemaVersion: HealthReportSchemaVersion,
+		Items:         items,
+	}
 }
 
-func (ow *objectWrapper) DeepCopyObject() runtime.Object {
-	return ow.DeepCopy()
+func (r *HealthReport) DeepCopyObject() runtime.Object {
+	items := make([]*ReportItem, 0, len(r.Items))
+	for _, item := range r.Items {
+		items = append(items, item.DeepCopy())
+	}
+	return &HealthReport{
+		TypeMeta:      r.TypeMeta,
+		SchemaVersion: r.SchemaVersion,
+		Timestamp:     r.Timestamp.DeepCopy(),
+		Items:         items,
+	}
 }
 
-func (ow *objectWrapper) DeepCopy() *objectWrapper {
-	var conditions []status.ConditionStatus
-	for _, c := range ow.Conditions {
-		conditions = append(conditions, *c.DeepCopy())
-	}
+type KubectlPrinter struct {
+	Printer printers.ResourcePrinter
+}
 
-	var subobjects []*objectWrapper
-	for _, o := range ow.Subobjects {
-		subobjects = append(subobjects, o.DeepCopy())
-	}
+// ReportItem is the status of an object in the structured output, including
+// the statuses of its sub-objects. Unlike status.ObjectStatus, it can be
+// unmarshalled back from the output.
+type ReportItem struct {
+	Object     corev1.ObjectReference `json:"object"`
+	Health     ReportStatus           `json:"health"`
+	Conditions []ReportCondition      `json:"conditions,omitempty"`
+	Subobjects []*ReportItem          `json:"subobjects,omitempty"`
+	Logs       *ReportLogs            `json:"logs,omitempty"`
+}
 
-	return &objectWrapper{
-		Object:     *ow.Object.DeepCopy(),
-		Status:     *ow.Status.DeepCopy(),
-		Conditions: conditions,
-		Subobjects: subobjects,
-	}
+// ReportStatus is the serializable form of status.Status.
+type ReportStatus struct {
+	Result      status.Result `json:"result"`
+	Progressing bool          `json:"progressing"`
+	Err         string        `json:"err,omitempty"`
+}
+
+// ReportCondition is the serializable form of status.ConditionStatus.
+type ReportCondition struct {
+	metav1.Condition `json:",inline"`
+	Health           ReportStatus `json:"health"`
+}
+
+// ReportLogs is the serializable form of status.Logs.
+type ReportLogs struct {
+	Previous bool   `json:"previous,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Err      string `json:"err,omitempty"`
 }
 
-func wrapObjectStatus(s status.ObjectStatus) *objectWrapper {
-	ret := objectWrapper{
+// NewReportItem converts the status tree of the object.
+func NewReportItem(s status.ObjectStatus) *ReportItem {
+	ret := ReportItem{
 		Object: corev1.ObjectReference{
 			APIVersion: s.Object.APIVersion,
 			Kind:       s.Object.Kind,
@@ -67,33 +110,67 @@ func wrapObjectStatus(s status.ObjectStatus) *objectWrapper {
 			Namespace:  s.Object.Namespace,
 			UID:        s.Object.UID,
 		},
-		Status:     s.ObjStatus,
-		Conditions: s.Conditions,
+		Health: newReportStatus(s.ObjStatus),
+	}
+
+	for _, c := range s.Conditions {
+		ret.Conditions = append(ret.Conditions, ReportCondition{
+			Condition: *c.Condition,
+			Health:    newReportStatus(c.Status()),
+		})
 	}
 
 	for _, ss := range s.SubStatuses {
-		ret.Subobjects = append(ret.Subobjects, wrapObjectStatus(ss))
+		ret.Subobjects = append(ret.Subobjects, NewReportItem(ss))
+	}
+
+	if s.Logs != nil {
+		ret.Logs = &ReportLogs{Previous: s.Logs.Previous, Text: s.Logs.Text}
+		if s.Logs.Err != nil {
+			ret.Logs.Err = s.Logs.Err.Error()
+		}
 	}
 
 	return &ret
 }
 
-func (p KubectlPrinter) PrintStatuses(statuses []status.ObjectStatus, w io.Writer) {
-	objects := make([]runtime.Object, 0, len(statuses))
-	for _, s := range statuses {
-		objects = append(objects, wrapObjectStatus(s))
+func newReportStatus(s status.Status) ReportStatus {
+	ret := ReportStatus{Result: s.Result, Progressing: s.Progressing}
+	if s.Err != nil {
+		ret.Err = s.Err.Error()
 	}
+	return ret
+}
 
-	list := &corev1.List{
-		TypeMeta: metav1.TypeMeta{
-			Kind:       "List",
-			APIVersion: "v1",
-		},
-		ListMeta: metav1.ListMeta{},
+func (ri *ReportItem) DeepCopy() *ReportItem {
+	var conditions []ReportCondition
+	for _, c := range ri.Conditions {
+		conditions = append(conditions, ReportCondition{
+			Condition: *c.Condition.DeepCopy(),
+			Health:    c.Health,
+		})
+	}
+
+	var subobjects []*ReportItem
+	for _, o := range ri.Subobjects {
+		subobjects = append(subobjects, o.DeepCopy())
 	}
-	if err := meta.SetList(list, objects); err != nil {
-		panic(err)
+
+	var logs *ReportLogs
+	if ri.Logs != nil {
+		l := *ri.Logs
+		logs = &l
 	}
 
-	p.Printer.PrintObj(list, w)
+	return &ReportItem{
+		Object:     *ri.Object.DeepCopy(),
+		Health:     ri.Health,
+		Conditions: conditions,
+		Subobjects: subobjects,
+		Logs:       logs,
+	}
+}
+
+func (p KubectlPrinter) PrintStatuses(statuses []status.ObjectStatus, w io.Writer) {
+	p.Printer.PrintObj(NewHealthReport(statuses), w)
 }
@@ -0,0 +1,98 @@
+package print_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/cli-runtime/pkg/printers"
+	"sigs.k8s.io/yaml"
+
+	"github.com/rhobs/kube-health/pkg/analyze"
+	"github.com/rhobs/kube-health/pkg/print"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestKubectlPrinterEnvelope(t *testing.T) {
+	sb := &strings.Builder{}
+	print.KubectlPrinter{Printer: &printers.JSONPrinter{}}.PrintStatuses(testTree(), sb)
+
+	var report map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(sb.String()), &report))
+	assert.Equal(t, "kube-health.io/v1", report["apiVersion"])
+	assert.Equal(t, "HealthReport", report["kind"])
+	assert.Equal(t, "1", report["schemaVersion"])
+
+	items, ok := report["items"].([]interface{})
+	require.True(t, ok)
+	require.Len(t, items, 1)
+	item := items[0].(map[string]interface{})
+	assert.Equal(t, "dp", item["object"].(map[string]interface{})["name"])
+	assert.Equal(t, "error", item["health"].(map[string]interface{})["result"])
+
+	sb = &strings.Builder{}
+	print.KubectlPrinter{Printer: &printers.YAMLPrinter{}}.PrintStatuses(testTree(), sb)
+	assert.True(t, strings.HasPrefix(sb.String(), "apiVersion: kube-health.io/v1\n"), sb.String())
+	assert.Contains(t, sb.String(), "\nkind: HealthReport\n")
+	assert.Contains(t, sb.String(), "\nschemaVersion: \"1\"\n")
+}
+
+func TestKubectlPrinterRoundTrip(t *testing.T) {
+	// The times get unmarshalled in the local time zone.
+	since := time.Date(2025, 1, 28, 13, 9, 44, 0, time.UTC).Local()
+	c1 := analyze.AggregateResult(testObject("Container", "c1"), nil, []status.ConditionStatus{
+		analyze.ConditionStatusError(analyze.SyntheticCondition("Waiting", true, "CrashLoopBackOff",
+			"back-off restarting failed container", since))})
+	p1 := analyze.AggregateResult(testObject("Pod", "p1"), []status.ObjectStatus{c1}, []status.ConditionStatus{
+		analyze.SyntheticConditionError("Ready", "ContainersNotReady", "")})
+	unknown := status.UnknownStatusWithError(testObject("Foo", "foo"), errors.New("failed to load"))
+	statuses := []status.ObjectStatus{p1, unknown}
+	expected := print.NewHealthReport(statuses)
+
+	for _, tc := range []struct {
+		printer   printers.ResourcePrinter
+		unmarshal func([]byte, any) error
+	}{
+		{&printers.JSONPrinter{}, json.Unmarshal},
+		{&printers.YAMLPrinter{}, func(data []byte, v any) error { return yaml.Unmarshal(data, v) }},
+	} {
+		sb := &strings.Builder{}
+		print.KubectlPrinter{Printer: tc.printer}.PrintStatuses(statuses, sb)
+
+		var report print.HealthReport
+		require.NoError(t, tc.unmarshal([]byte(sb.String()), &report))
+		assert.Equal(t, expected, &report)
+
+		// The nested containers keep their conditions.
+		container := report.Items[0].Subobjects[0]
+		assert.Equal(t, "c1", container.Object.Name)
+		assert.Equal(t, status.Error, container.Health.Result)
+		assert.True(t, since.Equal(container.Conditions[0].LastTransitionTime.Time))
+		assert.Equal(t, "failed to load", report.Items[1].Health.Err)
+	}
+}
+
+func TestKubectlPrinterLogs(t *testing.T) {
+	c1 := analyze.AggregateResult(testObject("Container", "c1"), nil, []status.ConditionStatus{
+		analyze.SyntheticConditionError("Ready", "NotReady", "")})
+	c1.Logs = &status.Logs{Text: "Line 1\nLine 2\n"}
+	c2 := analyze.AggregateResult(testObject("Container", "c2"), nil, []status.ConditionStatus{
+		analyze.SyntheticConditionError("Ready", "NotReady", "")})
+	c2.Logs = &status.Logs{Previous: true, Err: errors.New("container not found")}
+	p1 := analyze.AggregateResult(testObject("Pod", "p1"), []status.ObjectStatus{c1, c2}, nil)
+
+	sb := &strings.Builder{}
+	print.KubectlPrinter{Printer: &printers.JSONPrinter{}}.PrintStatuses([]status.ObjectStatus{p1}, sb)
+
+	var report print.HealthReport
+	require.NoError(t, json.Unmarshal([]byte(sb.String()), &report))
+	require.Len(t, report.Items, 1)
+	require.Len(t, report.Items[0].Subobjects, 2)
+	assert.Nil(t, report.Items[0].Logs)
+	assert.Equal(t, &print.ReportLogs{Text: "Line 1\nLine 2\n"}, report.Items[0].Subobjects[0].Logs)
+	assert.Equal(t, &print.ReportLogs{Previous: true, Err: "container not found"}, report.Items[0].Subobjects[1].Logs)
+}
@@ -0,0 +1,48 @@
+package print
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// legendEntry explains a single status or symbol used in the tree output.
+type legendEntry struct {
+	term        string
+	status      *status.Status // used for coloring the term, if set
+	description string
+}
+
+var legendEntries = []legendEntry{
+	{"Ok", &status.Status{Result: status.Ok}, "the object is healthy"},
+	{"Warning", &status.Status{Result: status.Warning}, "the object works, but something needs attention"},
+	{"Error", &status.Status{Result: status.Error}, "the object is failing"},
+	{"Unknown", &status.Status{Result: status.Unknown}, "the health can't be determined, e.g. the object failed to load"},
+	{"Progressing", &status.Status{Progressing: true}, "the object is still changing, e.g. during a rollout"},
+	{"├─ └─", nil, "sub-objects (owned or related) of the object above"},
+	{"(Error) Type=True", nil, "a condition of the object, with the result it contributes if not OK"},
+}
+
+// PrintLegend prints a short key explaining the statuses and the symbols
+// used by the TreePrinter. The statuses are colored when PrintOptions.Color
+// is set, the same way as in the tree.
+func PrintLegend(w io.Writer, o PrintOptions) {
+	width := 0
+	for _, e := range legendEntries {
+		width = max(width, visibleLen(e.term))
+	}
+
+	fmt.Fprintln(w, "LEGEND")
+	for _, e := range legendEntries {
+		term := e.term
+		if o.Color && e.status != nil {
+			if color, setColor := statusColor(*e.status); setColor {
+				term = SprintfWithColor(color, "%s", term)
+			}
+		}
+		term = padStringKeepControl(term, width)
+		fmt.Fprintf(w, "  %s%s%s\n", term, cellSep, e.description)
+	}
+	fmt.Fprintln(w)
+}
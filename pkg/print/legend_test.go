@@ -0,0 +1,31 @@
+package print_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/print"
+)
+
+func TestPrintLegend(t *testing.T) {
+	sb := &strings.Builder{}
+	print.PrintLegend(sb, print.PrintOptions{})
+	test.AssertStr(t, `
+LEGEND
+  Ok                 the object is healthy
+  Warning            the object works, but something needs attention
+  Error              the object is failing
+  Unknown            the health can't be determined, e.g. the object failed to load
+  Progressing        the object is still changing, e.g. during a rollout
+  ├─ └─              sub-objects (owned or related) of the object above
+  (Error) Type=True  a condition of the object, with the result it contributes if not OK
+`, sb.String())
+
+	// The statuses are colored the same way as in the tree.
+	sb = &strings.Builder{}
+	print.PrintLegend(sb, print.PrintOptions{Color: true})
+	assert.Contains(t, sb.String(), print.SprintfWithColor(print.RED, "Error")+"              the object is failing")
+}
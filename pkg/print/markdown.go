@@ -0,0 +1,135 @@
+package print
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// MarkdownPrinter prints the statuses as a nested Markdown list, to be
+// embedded in reports. Each object is a list item with its status, followed
+// by its conditions and sub-objects as nested items. Like in the tree,
+// the details are shown only for objects not in OK state, unless ShowOk
+// is set. The colors are replaced by emoji.
+type MarkdownPrinter struct {
+	PrintOpts PrintOptions
+}
+
+func (p MarkdownPrinter) PrintStatuses(statuses []status.ObjectStatus, w io.Writer) {
+	sortObjects(statuses, p.PrintOpts.SortBy)
+	for _, obj := range statuses {
+		p.printObject(w, obj, "", true)
+	}
+}
+
+func (p MarkdownPrinter) printObject(w io.Writer, obj status.ObjectStatus, indent string, root bool) {
+	name := fmt.Sprintf("%s/%s", obj.Object.Kind, obj.Object.GetName())
+	if root {
+		name = formatObjectName(obj)
+	}
+	st := obj.Status()
+	line := fmt.Sprintf("%s- %s **%s** %s", indent, markdownEmoji(st), statusMessage(st), markdownEscape(name))
+	if st.Err != nil {
+		line += ": " + markdownEscape(st.Err.Error())
+	}
+	fmt.Fprintln(w, line)
+
+	if !p.PrintOpts.ShowOk && st.Result <= status.Ok && !st.Progressing {
+		return
+	}
+
+	indent += "  "
+	for _, cond := range obj.Conditions {
+		fmt.Fprintf(w, "%s- %s\n", indent, p.formatCondition(cond))
+	}
+	if obj.Logs != nil {
+		p.printLogs(w, *obj.Logs, indent)
+	}
+	subObjects := obj.SubStatuses
+	sortObjects(subObjects, p.PrintOpts.SortBy)
+	for _, sub := range subObjects {
+		p.printObject(w, sub, indent, false)
+	}
+}
+
+// formatCondition renders the condition as a single line, such as:
+//
+//	❌ `Ready=False` ContainersNotReady (5m): containers with unready status: [app]
+func (p MarkdownPrinter) formatCondition(cond status.ConditionStatus) string {
+	st := cond.Status()
+	line := fmt.Sprintf("%s `%s=%s`", markdownEmoji(st), cond.Type, cond.Condition.Status)
+	if cond.Reason != "" {
+		line += " " + markdownEscape(cond.Reason)
+	}
+	if age := formatTime(p.PrintOpts, cond.LastTransitionTime.Time, time.Now()); age != "" {
+		line += fmt.Sprintf(" (%s)", age)
+	}
+	if cond.Message != "" && (st.Result > status.Ok || st.Progressing) {
+		line += ": " + markdownEscape(cond.Message)
+	}
+	return line
+}
+
+// printLogs renders the logs as a code block in a collapsed section,
+// unless HideLogs is set.
+func (p MarkdownPrinter) printLogs(w io.Writer, logs status.Logs, indent string) {
+	if logs.Err != nil {
+		fmt.Fprintf(w, "%s- Error loading logs: %s\n", indent, markdownEscape(logs.Err.Error()))
+		return
+	}
+	summary := "Logs"
+	if logs.Previous {
+		summary += " (previous instance)"
+	}
+	if p.PrintOpts.HideLogs {
+		fmt.Fprintf(w, "%s- %s: %d lines hidden\n", indent, summary, strings.Count(logs.Text, "\n"))
+		return
+	}
+
+	fmt.Fprintf(w, "%s- <details><summary>%s</summary>\n\n", indent, summary)
+	fmt.Fprintf(w, "%s  ```\n", indent)
+	for _, line := range strings.Split(strings.TrimSuffix(logs.Text, "\n"), "\n") {
+		fmt.Fprintf(w, "%s  %s\n", indent, line)
+	}
+	fmt.Fprintf(w, "%s  ```\n\n", indent)
+	fmt.Fprintf(w, "%s  </details>\n", indent)
+}
+
+func markdownEmoji(s status.Status) string {
+	if s.Progressing {
+		return "🔄"
+	}
+	switch s.Result {
+	case status.Ok:
+		return "✅"
+	case status.Warning:
+		return "⚠️"
+	case status.Error:
+		return "❌"
+	}
+	return "❔"
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	`*`, `\*`,
+	`_`, `\_`,
+	`[`, `\[`,
+	`]`, `\]`,
+	`<`, `&lt;`,
+	`>`, `&gt;`,
+	`|`, `\|`,
+	"\r\n", "<br>",
+	"\n", "<br>",
+)
+
+// markdownEscape escapes the text, so that it doesn't break the formatting
+// of the list (or the tables, when embedded in one). The line breaks
+// are preserved as <br>.
+func markdownEscape(s string) string {
+	return markdownEscaper.Replace(strings.TrimRight(s, "\n"))
+}
@@ -0,0 +1,52 @@
+package print_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/analyze"
+	"github.com/rhobs/kube-health/pkg/print"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestMarkdownPrinter(t *testing.T) {
+	cm := analyze.AggregateResult(testObject("ConfigMap", "cm"), nil, []status.ConditionStatus{
+		analyze.SyntheticConditionWarning("Valid", "Invalid_Key", "key a|b:\n  *not* allowed")})
+	statuses := append(testTree(), cm)
+
+	sb := &strings.Builder{}
+	print.MarkdownPrinter{}.PrintStatuses(statuses, sb)
+	test.AssertStr(t, `
+- ⚠️ **Warning** default/ConfigMap/cm
+  - ⚠️ `+"`Valid=True`"+` Invalid\_Key: key a\|b:<br>  \*not\* allowed
+- ❌ **Error** default/Deployment/dp
+  - ✅ `+"`Available=True`"+`
+  - ❌ **Error** ReplicaSet/rs
+    - ❌ `+"`ReplicasReady=True`"+` NotReady: Ready: 1/2
+    - ❌ **Error** Pod/p1
+      - ❌ `+"`Ready=True`"+` ContainersNotReady
+      - ❌ **Error** Container/c1
+        - ❌ `+"`Waiting=True`"+` CrashLoopBackOff: back-off restarting failed container
+    - ✅ **Ok** Pod/p2
+`, sb.String())
+}
+
+func TestMarkdownPrinterLogs(t *testing.T) {
+	c1 := analyze.AggregateResult(testObject("Container", "c1"), nil, []status.ConditionStatus{
+		analyze.SyntheticConditionError("Ready", "NotReady", "")})
+	c1.Logs = &status.Logs{Text: "Line 1\nLine 2\n"}
+
+	sb := &strings.Builder{}
+	print.MarkdownPrinter{}.PrintStatuses([]status.ObjectStatus{c1}, sb)
+	test.AssertStr(t, "- ❌ **Error** default/Container/c1\n"+
+		"  - ❌ `Ready=True` NotReady\n"+
+		"  - <details><summary>Logs</summary>\n"+
+		"\n"+
+		"    ```\n"+
+		"    Line 1\n"+
+		"    Line 2\n"+
+		"    ```\n"+
+		"\n"+
+		"    </details>\n", sb.String())
+}
@@ -0,0 +1,73 @@
+package print
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// OneLinePrinter prints a single line per object: its result, followed by
+// the most severe condition found in the object or its sub-objects.
+// It's meant for scripts checking a few objects.
+type OneLinePrinter struct{}
+
+func (OneLinePrinter) PrintStatuses(statuses []status.ObjectStatus, w io.Writer) {
+	for _, s := range statuses {
+		fmt.Fprintln(w, OneLine(s))
+	}
+}
+
+// OneLine returns the health of the object as a single line, such as:
+//
+//	default/Deployment/dp: Error (Pod/dp-x Ready: ContainersNotReady)
+func OneLine(obj status.ObjectStatus) string {
+	st := obj.Status()
+	line := fmt.Sprintf("%s: %s", formatObjectName(obj), st.Result)
+	if st.Progressing {
+		line += ", progressing"
+	}
+	if st.Err != nil {
+		return fmt.Sprintf("%s (%s)", line, st.Err)
+	}
+
+	owner, cond := worstCondition(obj)
+	if cond == nil {
+		return line
+	}
+	detail := cond.Type
+	if owner.Object != obj.Object {
+		detail = fmt.Sprintf("%s/%s %s", owner.Object.Kind, owner.Object.GetName(), cond.Type)
+	}
+	if reason := cond.Reason; reason != "" {
+		detail += ": " + reason
+	} else if cond.Message != "" {
+		detail += ": " + cond.Message
+	}
+	return fmt.Sprintf("%s (%s)", line, detail)
+}
+
+// worstCondition returns the first condition with the highest result that's
+// not OK, preferring the conditions of the object over its sub-objects.
+func worstCondition(obj status.ObjectStatus) (status.ObjectStatus, *status.ConditionStatus) {
+	var (
+		owner status.ObjectStatus
+		worst *status.ConditionStatus
+	)
+	for i := range obj.Conditions {
+		cond := &obj.Conditions[i]
+		if cond.Status().Result <= status.Ok && !cond.Status().Progressing {
+			continue
+		}
+		if worst == nil || cond.Status().Result > worst.Status().Result {
+			owner, worst = obj, cond
+		}
+	}
+	for _, sub := range obj.SubStatuses {
+		subOwner, cond := worstCondition(sub)
+		if cond != nil && (worst == nil || cond.Status().Result > worst.Status().Result) {
+			owner, worst = subOwner, cond
+		}
+	}
+	return owner, worst
+}
@@ -0,0 +1,27 @@
+package print_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/analyze"
+	"github.com/rhobs/kube-health/pkg/print"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestOneLinePrinter(t *testing.T) {
+	warning := analyze.AggregateResult(testObject("Node", "n1"), nil, []status.ConditionStatus{
+		analyze.SyntheticConditionWarning("MemoryPressure", "KubeletHasInsufficientMemory", "low memory")})
+	ok := analyze.AggregateResult(testObject("Service", "svc"), nil, []status.ConditionStatus{
+		analyze.SyntheticConditionOk("Ready", "")})
+	statuses := append(testTree(), warning, ok)
+
+	sb := &strings.Builder{}
+	print.OneLinePrinter{}.PrintStatuses(statuses, sb)
+	test.AssertStr(t, `
+default/Deployment/dp: Error (ReplicaSet/rs ReplicasReady: NotReady)
+default/Node/n1: Warning (MemoryPressure: KubeletHasInsufficientMemory)
+default/Service/svc: Ok
+`, sb.String())
+}
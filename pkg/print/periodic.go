@@ -11,26 +11,48 @@ import (
 
 // PeriodicPrinter prints status updates to the terminal, as they arrive
 // to the update channel.
-// It tracks the number of lines printed and clears the screen before printing
-// the next update.
+// When printing to a terminal (see WithTerminal), it tracks the lines printed
+// and clears them before printing the next update. Otherwise, the updates
+// are appended to the output.
 type PeriodicPrinter struct {
-	printer       StatusPrinter
-	out           OutStreams
-	previousLines int
-	updateChan    <-chan eval.StatusUpdate
-	callback      func([]status.ObjectStatus)
+	printer    StatusPrinter
+	out        OutStreams
+	updateChan <-chan eval.StatusUpdate
+	callback   func([]status.ObjectStatus)
+	// warningsSeen avoids repeating the same warnings on every update.
+	warningsSeen map[string]struct{}
+
+	// termWidth returns the current width of the terminal, or 0 if unknown.
+	// It's nil when the output is not a terminal.
+	termWidth func() int
+	// autoWidth enables re-wrapping the output to the current terminal width.
+	autoWidth bool
+	// previousLines are the visible lengths of the lines of the last update.
+	previousLines []int
+}
+
+// resizable is implemented by the printers wrapping the output to a width.
+type resizable interface {
+	SetWidth(width int)
 }
 
+// lineCountWriter tracks the visible lengths of the written lines.
 type lineCountWriter struct {
-	w     io.Writer
-	lines int
+	w       io.Writer
+	lines   []int
+	current strings.Builder // the unfinished line
 }
 
 func (lcw *lineCountWriter) Write(p []byte) (n int, err error) {
 	n, err = lcw.w.Write(p)
-	var sb strings.Builder
-	sb.Write(p[:n])
-	lcw.lines += strings.Count(sb.String(), "\n")
+	for _, line := range strings.SplitAfter(string(p[:n]), "\n") {
+		text, complete := strings.CutSuffix(line, "\n")
+		lcw.current.WriteString(text)
+		if complete {
+			lcw.lines = append(lcw.lines, visibleLen(lcw.current.String()))
+			lcw.current.Reset()
+		}
+	}
 
 	return n, err
 }
@@ -42,39 +64,87 @@ func NewPeriodicPrinter(printer StatusPrinter, out OutStreams, updateChan <-chan
 		out:        out,
 		updateChan: updateChan,
 		callback:   callback,
+
+		warningsSeen: make(map[string]struct{}),
 	}
 }
 
+// WithTerminal makes the printer replace the previous update on the screen
+// instead of appending the updates. The width function is called before each
+// update to account for the lines wrapped by the terminal, including after
+// resizing it. It should return 0 when the width can't be determined.
+func (p *PeriodicPrinter) WithTerminal(width func() int) *PeriodicPrinter {
+	p.termWidth = width
+	return p
+}
+
+// WithAutoWidth makes the printer wrap each update to the current terminal
+// width, if the underlying printer supports it (such as TreePrinter).
+func (p *PeriodicPrinter) WithAutoWidth() *PeriodicPrinter {
+	p.autoWidth = true
+	return p
+}
+
 func (p *PeriodicPrinter) Start() {
 	for update := range p.updateChan {
 		if update.Error != nil {
 			fmt.Fprintf(p.out.Err, "Error: %s", update.Error)
-			p.previousLines = 0
+			p.previousLines = nil
+		}
+		for _, w := range update.Warnings {
+			if _, seen := p.warningsSeen[w.Error()]; seen {
+				continue
+			}
+			p.warningsSeen[w.Error()] = struct{}{}
+			fmt.Fprintf(p.out.Err, "Warning: %s\n", w)
+			p.previousLines = nil
+		}
+
+		width := 0
+		if p.termWidth != nil {
+			width = p.termWidth()
+			p.resetScreen(width)
+		}
+		if r, ok := p.printer.(resizable); ok && p.autoWidth && width > 0 {
+			r.SetWidth(width)
 		}
-		p.resetScreen()
 
-		// Wrap writer to count number of emited lines.
+		// Wrap writer to track the emitted lines.
 		lcw := &lineCountWriter{w: p.out.Std}
 		p.printer.PrintStatuses(update.Statuses, lcw)
 		p.previousLines = lcw.lines
 
-		if p.callback != nil {
+		// Partial updates are only shown: the decisions are made
+		// based on the complete results.
+		if p.callback != nil && !update.Partial {
 			p.callback(update.Statuses)
 		}
 	}
 }
 
-func (p *PeriodicPrinter) resetScreen() {
-	for i := 0; i < p.previousLines; i++ {
-		p.moveUp()
-		p.eraseCurrentLine()
+// resetScreen moves the cursor to the beginning of the previous update
+// and clears the screen from there.
+func (p *PeriodicPrinter) resetScreen(width int) {
+	rows := screenRows(p.previousLines, width)
+	if rows == 0 {
+		return
 	}
+	// Move up, to the first column, and erase till the end of the screen.
+	fmt.Fprintf(p.out.Std, "%c[%dA\r%c[J", ESC, rows, ESC)
 }
 
-func (p *PeriodicPrinter) moveUp() {
-	fmt.Fprintf(p.out.Std, "%c[%dA", ESC, 1)
-}
-
-func (p *PeriodicPrinter) eraseCurrentLine() {
-	fmt.Fprintf(p.out.Std, "%c[2K\r", ESC)
+// screenRows returns the number of rows the lines take on the screen
+// of the given width, including the lines wrapped by the terminal.
+// The terminals re-wrap the lines on resize, so the rows need to be
+// calculated with the current width.
+func screenRows(lines []int, width int) int {
+	rows := 0
+	for _, l := range lines {
+		if width > 0 && l > width {
+			rows += (l + width - 1) / width
+		} else {
+			rows++
+		}
+	}
+	return rows
 }
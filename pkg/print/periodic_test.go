@@ -0,0 +1,54 @@
+package print_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rhobs/kube-health/pkg/eval"
+	"github.com/rhobs/kube-health/pkg/print"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func printUpdates(pp func(*print.PeriodicPrinter), updates int) string {
+	ch := make(chan eval.StatusUpdate, updates)
+	for range updates {
+		ch <- eval.StatusUpdate{Statuses: testTree()}
+	}
+	close(ch)
+
+	sb := &strings.Builder{}
+	p := print.NewPeriodicPrinter(print.NewTreePrinter(print.PrintOptions{Compact: true}),
+		print.OutStreams{Std: sb, Err: sb}, ch, func([]status.ObjectStatus) {})
+	pp(p)
+	p.Start()
+	return sb.String()
+}
+
+func TestPeriodicPrinterNotTerminal(t *testing.T) {
+	// The updates are appended, without any cursor control.
+	out := printUpdates(func(*print.PeriodicPrinter) {}, 2)
+	assert.NotContains(t, out, fmt.Sprintf("%c[", print.ESC))
+	assert.Equal(t, 2, strings.Count(out, "Error default/Deployment/dp"))
+}
+
+func TestPeriodicPrinterTerminal(t *testing.T) {
+	// 6 lines, none wrapped.
+	out := printUpdates(func(p *print.PeriodicPrinter) {
+		p.WithTerminal(func() int { return 80 })
+	}, 2)
+	assert.Equal(t, 1, strings.Count(out, fmt.Sprintf("%c[6A\r%c[J", print.ESC, print.ESC)))
+
+	// After resizing the terminal, the 3 lines longer than 20 characters
+	// take 2 rows each.
+	width := 80
+	out = printUpdates(func(p *print.PeriodicPrinter) {
+		p.WithTerminal(func() int {
+			defer func() { width = 20 }()
+			return width
+		})
+	}, 2)
+	assert.Equal(t, 1, strings.Count(out, fmt.Sprintf("%c[9A\r%c[J", print.ESC, print.ESC)))
+}
@@ -1,6 +1,7 @@
 package print
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/rhobs/kube-health/pkg/status"
@@ -11,6 +12,69 @@ type PrintOptions struct {
 	ShowOk    bool // By default, OK statuses are not shown.
 	Width     int  // Width of the output. If 0, wrapping is disabled.
 	Color     bool // Use colors to indicate the health.
+	Compact   bool // Print only the object lines, without conditions.
+	HideLogs  bool // Collapse the container logs to a single line.
+
+	// MinResult hides the objects with a lower result, unless some of their
+	// sub-objects reach it. Unknown (the default) shows all the objects.
+	MinResult status.Result
+	// Results hides the objects with other results, unless some of their
+	// sub-objects have one of them. Empty (the default) shows all the objects.
+	Results []status.Result
+	// MaxDepth limits the levels of sub-objects printed under the top-level
+	// objects. The deeper objects are summarized in a single line.
+	// Zero means unlimited.
+	MaxDepth int
+	// Focus shows only the sub-objects leading to the worst result under
+	// each top-level object, hiding their siblings entirely.
+	Focus bool
+
+	TimeFormat TimeFormat // How to render the times. Relative by default.
+	SortBy     SortOrder  // How to order the objects. By name by default.
+
+	ShowScore    bool                // Print the overall health score after the statuses.
+	ScoreWeights status.ScoreWeights // Weights for the score. If nil, status.DefaultScoreWeights are used.
+}
+
+// TimeFormat controls how the times (e.g. condition transitions) are rendered.
+type TimeFormat string
+
+const (
+	TimeRelative TimeFormat = ""      // Age relative to now, e.g. 5m.
+	TimeLocal    TimeFormat = "local" // Absolute time in the local timezone.
+	TimeUTC      TimeFormat = "utc"   // Absolute time in UTC.
+)
+
+// ParseTimeFormat converts the user-provided value to TimeFormat.
+func ParseTimeFormat(s string) (TimeFormat, error) {
+	switch s {
+	case "", "relative":
+		return TimeRelative, nil
+	case string(TimeLocal):
+		return TimeLocal, nil
+	case string(TimeUTC):
+		return TimeUTC, nil
+	}
+	return TimeRelative, fmt.Errorf("unknown time format %q, expected one of: relative, local, utc", s)
+}
+
+// SortOrder controls the order of the objects in the tree.
+type SortOrder string
+
+const (
+	SortByName     SortOrder = ""         // By namespace, kind and name.
+	SortBySeverity SortOrder = "severity" // The most severe results first, then by name.
+)
+
+// ParseSortOrder converts the user-provided value to SortOrder.
+func ParseSortOrder(s string) (SortOrder, error) {
+	switch s {
+	case "", "name":
+		return SortByName, nil
+	case string(SortBySeverity):
+		return SortBySeverity, nil
+	}
+	return SortByName, fmt.Errorf("unknown sort order %q, expected one of: name, severity", s)
 }
 
 type OutStreams struct {
@@ -0,0 +1,59 @@
+package print
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+// ExitSummary is a compact, machine-readable counterpart of the exit code:
+// the final result of each root object, so that scripts can tell which
+// objects caused the code without parsing the tree.
+type ExitSummary struct {
+	ExitCode int                `json:"exitCode"`
+	Objects  []ExitSummaryEntry `json:"objects"`
+}
+
+// ExitSummaryEntry is the final result of a root object.
+type ExitSummaryEntry struct {
+	APIVersion  string        `json:"apiVersion"`
+	Kind        string        `json:"kind"`
+	Namespace   string        `json:"namespace,omitempty"`
+	Name        string        `json:"name"`
+	Result      status.Result `json:"result"`
+	Progressing bool          `json:"progressing"`
+	Err         string        `json:"err,omitempty"`
+}
+
+// NewExitSummary builds the summary of the root objects.
+func NewExitSummary(statuses []status.ObjectStatus, exitCode int) ExitSummary {
+	ret := ExitSummary{ExitCode: exitCode, Objects: make([]ExitSummaryEntry, 0, len(statuses))}
+	for _, s := range statuses {
+		st := s.Status()
+		entry := ExitSummaryEntry{
+			APIVersion:  s.Object.APIVersion,
+			Kind:        s.Object.Kind,
+			Namespace:   s.Object.GetNamespace(),
+			Name:        s.Object.GetName(),
+			Result:      st.Result,
+			Progressing: st.Progressing,
+		}
+		if st.Err != nil {
+			entry.Err = st.Err.Error()
+		}
+		ret.Objects = append(ret.Objects, entry)
+	}
+	return ret
+}
+
+// PrintExitSummaryJSON prints the summary as a single JSON line.
+func PrintExitSummaryJSON(w io.Writer, statuses []status.ObjectStatus, exitCode int) error {
+	data, err := json.Marshal(NewExitSummary(statuses, exitCode))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
@@ -0,0 +1,23 @@
+package print_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/analyze"
+	"github.com/rhobs/kube-health/pkg/print"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func TestPrintExitSummaryJSON(t *testing.T) {
+	ok := analyze.AggregateResult(testObject("Service", "svc"), nil, []status.ConditionStatus{
+		analyze.SyntheticConditionOk("Ready", "")})
+	statuses := append(testTree(), ok)
+
+	sb := &strings.Builder{}
+	print.PrintExitSummaryJSON(sb, statuses, 2)
+	test.AssertStr(t, `
+{"exitCode":2,"objects":[{"apiVersion":"","kind":"Deployment","namespace":"default","name":"dp","result":"error","progressing":false},{"apiVersion":"","kind":"Service","namespace":"default","name":"svc","result":"ok","progressing":false}]}
+`, sb.String())
+}
@@ -0,0 +1,57 @@
+package print
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatTime(t *testing.T) {
+	now := time.Date(2025, 1, 28, 15, 0, 0, 0, time.UTC)
+
+	origLocal := time.Local
+	time.Local = time.FixedZone("CET", 3600)
+	defer func() { time.Local = origLocal }()
+
+	tests := []struct {
+		format   TimeFormat
+		t        time.Time
+		expected string
+	}{
+		{TimeRelative, time.Time{}, ""},
+		{TimeRelative, now.Add(-42 * time.Second), "42s"},
+		{TimeRelative, now.Add(-5 * time.Minute), "5m"},
+		{TimeRelative, now.Add(-26 * time.Hour), "26h"},
+		{TimeRelative, now.Add(-36 * time.Hour), "36h"},
+		{TimeRelative, now.Add(-38 * time.Hour), "2d"},
+		{TimeRelative, now.Add(-30 * 24 * time.Hour), "30d"},
+		{TimeRelative, now.Add(-(30*24 + 13) * time.Hour), "31d"},
+		{TimeRelative, now.Add(-56 * 24 * time.Hour), "56d"},
+		{TimeRelative, now.Add(-60 * 24 * time.Hour), "9w"},
+		{TimeRelative, now.Add(-400 * 24 * time.Hour), "57w"},
+		{TimeUTC, time.Time{}, ""},
+		{TimeUTC, now.Add(-5 * time.Minute), "2025-01-28 14:55:00Z"},
+		{TimeLocal, now.Add(-5 * time.Minute), "2025-01-28 15:55:00"},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.expected, formatTime(PrintOptions{TimeFormat: tc.format}, tc.t, now))
+	}
+}
+
+func TestParseTimeFormat(t *testing.T) {
+	for in, expected := range map[string]TimeFormat{
+		"":         TimeRelative,
+		"relative": TimeRelative,
+		"local":    TimeLocal,
+		"utc":      TimeUTC,
+	} {
+		f, err := ParseTimeFormat(in)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, f)
+	}
+
+	_, err := ParseTimeFormat("iso")
+	assert.Error(t, err)
+}
@@ -105,6 +105,18 @@ var (
 			FormatFn:    FormatFn(formatConditionMessage),
 		},
 	}
+	// The logs are aligned with the condition messages.
+	logsCols = []Column{
+		conditionMessageCols[0],
+		conditionMessageCols[1],
+		{
+			Header:      "MESSAGE",
+			Width:       40,
+			MaxLineWrap: 3,
+			WrapPrefix:  "    ",
+			FormatFn:    FormatFn(formatLogs),
+		},
+	}
 )
 
 func formatConditionType(o PrintOptions, cond status.ConditionStatus) string {
@@ -160,22 +172,65 @@ func statusMessage(s status.Status) string {
 	}
 }
 
+// conditionsColumns returns the columns for printing the conditions,
+// adjusted to the print options.
+func conditionsColumns(o PrintOptions) []Column {
+	if o.TimeFormat == TimeRelative {
+		return conditionsCols
+	}
+
+	cols := slices.Clone(conditionsCols)
+	for i := range cols {
+		if cols[i].Header == "AGE" {
+			cols[i].Header = "SINCE"
+			cols[i].Width = len(time.DateTime) + 1
+		}
+	}
+	return cols
+}
+
 func formatConditionAge(o PrintOptions, cond status.ConditionStatus) string {
-	return formatTimeSince(cond.Condition.LastTransitionTime.Time)
+	return formatTime(o, cond.Condition.LastTransitionTime.Time, time.Now())
 }
 
-func formatTimeSince(t time.Time) string {
+// formatTime renders the time according to the TimeFormat option.
+// The now argument is used as the reference for relative times.
+func formatTime(o PrintOptions, t, now time.Time) string {
 	if t.IsZero() {
 		return ""
 	}
-	since := time.Since(t)
+
+	switch o.TimeFormat {
+	case TimeLocal:
+		return t.Local().Format(time.DateTime)
+	case TimeUTC:
+		return t.UTC().Format(time.DateTime + "Z")
+	default:
+		return formatTimeSince(t, now)
+	}
+}
+
+const (
+	day  = 24 * time.Hour
+	week = 7 * day
+)
+
+// formatTimeSince renders the time elapsed since t in the largest unit
+// that still keeps the value precise enough, rounded to the whole units:
+// e.g. 42s, 5m, 26h, 30d, 9w.
+func formatTimeSince(t, now time.Time) string {
+	since := now.Sub(t)
 	switch {
 	case since.Seconds() <= 90:
 		return fmt.Sprintf("%ds", integer.RoundToInt32(since.Round(time.Second).Seconds()))
 	case since.Minutes() <= 90:
 		return fmt.Sprintf("%dm", integer.RoundToInt32(since.Round(time.Minute).Minutes()))
-	default:
+	case since.Hours() <= 36:
 		return fmt.Sprintf("%dh", integer.RoundToInt32(since.Round(time.Hour).Hours()))
+	case since <= 8*week:
+		return fmt.Sprintf("%dd", integer.RoundToInt32(float64(since.Round(day)/day)))
+	default:
+		return fmt.Sprintf("%dw", integer.RoundToInt32(float64(since.Round(week)/week)))
 	}
 }
 
@@ -187,6 +242,22 @@ func formatConditionMessage(o PrintOptions, cond status.ConditionStatus) string
 	return cond.Message
 }
 
+// formatLogs renders the logs under a header line. With HideLogs,
+// only the header with the number of lines is shown.
+func formatLogs(o PrintOptions, logs status.Logs) string {
+	if logs.Err != nil {
+		return "Error loading logs: " + logs.Err.Error()
+	}
+	header := "Logs"
+	if logs.Previous {
+		header += " (previous instance)"
+	}
+	if o.HideLogs {
+		return fmt.Sprintf("%s: %d lines hidden", header, strings.Count(logs.Text, "\n"))
+	}
+	return header + ":\n" + logs.Text
+}
+
 func formatObject(o PrintOptions, obj status.ObjectStatus, root, printGroups bool) string {
 	status := formatStatus(o, obj)
 	fullName := ""
@@ -214,24 +285,64 @@ func NewTreePrinter(opts PrintOptions) *TreePrinter {
 	}
 }
 
+// SetWidth changes the width of the output, e.g. when the terminal
+// gets resized.
+func (t *TreePrinter) SetWidth(width int) {
+	t.PrintOpts.Width = width
+}
+
 func (t *TreePrinter) PrintStatuses(objects []status.ObjectStatus, w io.Writer) {
-	t.printHeader(w, conditionsCols)
+	// The lines are collected first so that the columns can be sized
+	// based on the content of all the rows.
+	tbl := &table{}
+	if t.PrintOpts.Compact {
+		t.printHeader(tbl, []Column{objectIndentCol})
+	} else {
+		t.printHeader(tbl, conditionsColumns(t.PrintOpts))
+	}
 
-	sortObjects(objects)
+	sortObjects(objects, t.PrintOpts.SortBy)
 
-	for _, obj := range objects {
+	for _, obj := range t.filterResults(objects) {
 		subObjects := obj.SubStatuses
 		prefixTail := ""
-		printSubResources := len(subObjects) > 0 && t.shouldPrintDetails(obj)
-		if printSubResources {
+		printSubResources := len(subObjects) > 0 && t.shouldPrintSubTree(obj)
+		if printSubResources && !t.PrintOpts.Compact {
 			prefixTail = "│ "
 		}
-		t.printObjectWithConditions(w, obj, "", prefixTail)
+		t.printObjectWithConditions(tbl, obj, "", prefixTail)
 
 		if printSubResources {
-			t.printSubTree(w, subObjects, "")
+			t.printSubTree(tbl, subObjects, "", 1)
 		}
 	}
+
+	t.writeTable(w, tbl)
+
+	if t.PrintOpts.ShowScore {
+		t.printScore(w, objects)
+	}
+}
+
+func (t *TreePrinter) printScore(w io.Writer, objects []status.ObjectStatus) {
+	weights := t.PrintOpts.ScoreWeights
+	if weights == nil {
+		weights = status.DefaultScoreWeights
+	}
+	t.printf(w, "\nHealth score: %.1f%%\n", status.Score(objects, weights))
+
+	if oldest, since, found := status.OldestUnhealthy(objects); found {
+		t.printf(w, "Oldest unhealthy: %s (since %s)\n",
+			formatObjectName(oldest), formatTime(t.PrintOpts, since, time.Now()))
+	}
+}
+
+// formatObjectName returns the namespaced name of the object, including its kind.
+func formatObjectName(obj status.ObjectStatus) string {
+	if ns := obj.Object.GetNamespace(); ns != "" {
+		return fmt.Sprintf("%s/%s/%s", ns, obj.Object.Kind, obj.Object.GetName())
+	}
+	return fmt.Sprintf("%s/%s", obj.Object.Kind, obj.Object.GetName())
 }
 
 // shouldPrintDetails decides whether to print the details of the object.
@@ -242,29 +353,83 @@ func (t *TreePrinter) shouldPrintDetails(obj status.ObjectStatus) bool {
 	return obj.Status().Result > status.Ok || obj.Status().Progressing
 }
 
-func (t *TreePrinter) printObjectWithConditions(w io.Writer, obj status.ObjectStatus, prefixHead, prefixTail string) {
-	t.printObject(w, obj, prefixHead)
+// shouldPrintSubTree decides whether to print the sub-objects of the object.
+// With the result filters (MinResult, Results) set, the matching sub-objects
+// are shown even under a healthy object, as a path to the problem.
+func (t *TreePrinter) shouldPrintSubTree(obj status.ObjectStatus) bool {
 	if t.shouldPrintDetails(obj) {
-		t.printConditions(w, obj, prefixTail)
+		return true
 	}
+	return t.filteringResults() && len(t.filterResults(obj.SubStatuses)) > 0
 }
 
-func (t *TreePrinter) printObject(w io.Writer, obj status.ObjectStatus, prefix string) {
-	t.printf(w, "%s%s\n", prefix, formatObject(t.PrintOpts, obj, prefix == "", t.PrintOpts.ShowGroup))
+func (t *TreePrinter) filteringResults() bool {
+	return t.PrintOpts.MinResult > status.Unknown || len(t.PrintOpts.Results) > 0
 }
 
-func (t *TreePrinter) printConditions(w io.Writer, obj status.ObjectStatus, prefix string) {
+// filterResults returns the objects matching the result filters, either
+// by themselves or by some of their sub-objects.
+func (t *TreePrinter) filterResults(objects []status.ObjectStatus) []status.ObjectStatus {
+	if !t.filteringResults() {
+		return objects
+	}
+	var ret []status.ObjectStatus
+	for _, obj := range objects {
+		if t.leadsToMatch(obj) {
+			ret = append(ret, obj)
+		}
+	}
+	return ret
+}
+
+func (t *TreePrinter) leadsToMatch(obj status.ObjectStatus) bool {
+	if t.matchesResults(obj) {
+		return true
+	}
+	for _, sub := range obj.SubStatuses {
+		if t.leadsToMatch(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesResults reports whether the result of the object itself passes
+// the result filters.
+func (t *TreePrinter) matchesResults(obj status.ObjectStatus) bool {
+	result := obj.Status().Result
+	if result < t.PrintOpts.MinResult {
+		return false
+	}
+	return len(t.PrintOpts.Results) == 0 || slices.Contains(t.PrintOpts.Results, result)
+}
+
+func (t *TreePrinter) printObjectWithConditions(tbl *table, obj status.ObjectStatus, prefixHead, prefixTail string) {
+	t.printObject(tbl, obj, prefixHead)
+	if t.shouldPrintDetails(obj) && !t.PrintOpts.Compact {
+		t.printConditions(tbl, obj, prefixTail)
+	}
+}
+
+func (t *TreePrinter) printObject(tbl *table, obj status.ObjectStatus, prefix string) {
+	tbl.addText(prefix + formatObject(t.PrintOpts, obj, prefix == "", t.PrintOpts.ShowGroup))
+}
+
+func (t *TreePrinter) printConditions(tbl *table, obj status.ObjectStatus, prefix string) {
 	for _, cond := range obj.Conditions {
-		row := formatRow(conditionsCols, t.PrintOpts, cond)
-		t.printRow(w, row, prefix, prefix)
+		row := formatRow(conditionsColumns(t.PrintOpts), t.PrintOpts, cond)
+		tbl.addRow(row, prefix, prefix)
 		if cond.Status().Result > status.Ok || cond.Status().Progressing {
 			row = formatRow(conditionMessageCols, t.PrintOpts, cond)
-			t.printRow(w, row, prefix, prefix)
+			tbl.addRow(row, prefix, prefix)
 		}
 	}
+	if obj.Logs != nil {
+		tbl.addRow(formatRow(logsCols, t.PrintOpts, *obj.Logs), prefix, prefix)
+	}
 }
 
-func (t *TreePrinter) printHeader(w io.Writer, cols []Column) {
+func (t *TreePrinter) printHeader(tbl *table, cols []Column) {
 	row := make([]Cell, len(cols))
 	for i, col := range cols {
 		row[i] = Cell{
@@ -273,7 +438,104 @@ func (t *TreePrinter) printHeader(w io.Writer, cols []Column) {
 		}
 	}
 
-	t.printRow(w, row, "", "")
+	tbl.addRow(row, "", "")
+}
+
+// table buffers the lines to be printed: either plain text lines (objects)
+// or rows of cells (header and conditions).
+type table struct {
+	lines []tableLine
+}
+
+type tableLine struct {
+	text       string // used when row is nil
+	row        []Cell
+	prefixHead string
+	prefixTail string
+}
+
+func (tbl *table) addText(text string) {
+	tbl.lines = append(tbl.lines, tableLine{text: text})
+}
+
+func (tbl *table) addRow(row []Cell, prefixHead, prefixTail string) {
+	tbl.lines = append(tbl.lines, tableLine{row: row, prefixHead: prefixHead, prefixTail: prefixTail})
+}
+
+// columnWidths calculates the widths of the columns based on the content.
+// The columns are identified by their headers. The width defined in the
+// column is used as the minimum. The last columns of the rows are excluded,
+// as they take the remaining width of the output. When the output width is
+// known, the columns shrink back (down to their minimum) to leave at least
+// the minimal width for the last column.
+func (t *TreePrinter) columnWidths(tbl *table) map[string]int {
+	widths := make(map[string]int)
+	var order []string // headers in order of appearance
+	lastMinWidth := 0  // minimal width of the last column
+	for _, line := range tbl.lines {
+		for i, cell := range line.row {
+			if i == len(line.row)-1 {
+				lastMinWidth = max(lastMinWidth, cell.Column.Width)
+				continue
+			}
+
+			header := cell.Column.Header
+			if _, found := widths[header]; !found {
+				widths[header] = cell.Column.Width
+				order = append(order, header)
+			}
+
+			width := visibleLen(cell.Content)
+			if i == 0 {
+				// The prefix is rendered as part of the first column.
+				width += max(visibleLen(line.prefixHead), visibleLen(line.prefixTail))
+			}
+			widths[header] = max(widths[header], width)
+		}
+	}
+
+	if t.PrintOpts.Width <= 0 {
+		return widths
+	}
+
+	total := 0
+	for _, header := range order {
+		total += widths[header] + len(cellSep)
+	}
+
+	minWidths := make(map[string]int)
+	for _, line := range tbl.lines {
+		for _, cell := range line.row {
+			minWidths[cell.Column.Header] = cell.Column.Width
+		}
+	}
+
+	// Shrink the columns from the right until the output fits.
+	excess := total + lastMinWidth - t.PrintOpts.Width
+	for i := len(order) - 1; i >= 0 && excess > 0; i-- {
+		header := order[i]
+		shrink := min(excess, widths[header]-minWidths[header])
+		widths[header] -= shrink
+		excess -= shrink
+	}
+
+	return widths
+}
+
+func (t *TreePrinter) writeTable(w io.Writer, tbl *table) {
+	widths := t.columnWidths(tbl)
+	for _, line := range tbl.lines {
+		if line.row == nil {
+			t.printf(w, "%s\n", line.text)
+			continue
+		}
+
+		row := slices.Clone(line.row)
+		for i := range row[:len(row)-1] {
+			row[i].Column.Width = widths[row[i].Column.Header]
+		}
+		t.printRow(w, row, line.prefixHead, line.prefixTail)
+	}
 }
 
 func (t *TreePrinter) printRow(w io.Writer, row []Cell, prefixHead, prefixTail string) {
@@ -340,9 +602,20 @@ func (t *TreePrinter) printRow(w io.Writer, row []Cell, prefixHead, prefixTail s
 
 // printSubTree prints out any subresources that belong to the
 // object. This function takes care of printing the correct tree
-// structure and indentation.
-func (t *TreePrinter) printSubTree(w io.Writer, objects []status.ObjectStatus, prefix string) {
-	sortObjects(objects)
+// structure and indentation. The depth is the level of the objects
+// below the top-level ones, starting at 1.
+func (t *TreePrinter) printSubTree(tbl *table, objects []status.ObjectStatus, prefix string, depth int) {
+	sortObjects(objects, t.PrintOpts.SortBy)
+	objects = t.filterResults(objects)
+	if t.PrintOpts.Focus {
+		objects = focusObjects(objects)
+	}
+	if t.PrintOpts.MaxDepth > 0 && depth > t.PrintOpts.MaxDepth {
+		if len(objects) > 0 {
+			tbl.addText(prefix + "└─ " + t.collapsedSummary(objects))
+		}
+		return
+	}
 	for j, obj := range objects {
 		var newPrefixHead, newPrefixTail string
 		if j < len(objects)-1 {
@@ -353,12 +626,12 @@ func (t *TreePrinter) printSubTree(w io.Writer, objects []status.ObjectStatus, p
 			newPrefixTail = "   "
 		}
 
-		if t.shouldPrintDetails(obj) && len(obj.SubStatuses) > 0 {
+		if t.shouldPrintSubTree(obj) && len(obj.SubStatuses) > 0 && !t.PrintOpts.Compact {
 			// Add an extra level of indentation if there are subresources to print.
 			newPrefixTail += "│ "
 		}
 
-		t.printObjectWithConditions(w, obj, prefix+newPrefixHead, prefix+newPrefixTail)
+		t.printObjectWithConditions(tbl, obj, prefix+newPrefixHead, prefix+newPrefixTail)
 
 		var newPrefix string
 		if j < len(objects)-1 {
@@ -366,10 +639,75 @@ func (t *TreePrinter) printSubTree(w io.Writer, objects []status.ObjectStatus, p
 		} else {
 			newPrefix = "   "
 		}
-		if t.shouldPrintDetails(obj) {
-			t.printSubTree(w, obj.SubStatuses, prefix+newPrefix)
+		if t.shouldPrintSubTree(obj) {
+			t.printSubTree(tbl, obj.SubStatuses, prefix+newPrefix, depth+1)
+		}
+	}
+}
+
+// focusObjects returns the objects leading to the worst result among them,
+// based on their own results and the results of their sub-objects.
+// Nothing is returned when all of them are healthy.
+func focusObjects(objects []status.ObjectStatus) []status.ObjectStatus {
+	worst := 0
+	ranks := make([]int, len(objects))
+	for i, obj := range objects {
+		ranks[i] = worstRank(obj)
+		worst = max(worst, ranks[i])
+	}
+	if worst == 0 {
+		return nil
+	}
+
+	var ret []status.ObjectStatus
+	for i, obj := range objects {
+		if ranks[i] == worst {
+			ret = append(ret, obj)
+		}
+	}
+	return ret
+}
+
+// worstRank returns the highest severityRank in the tree of the object.
+func worstRank(obj status.ObjectStatus) int {
+	rank := severityRank(obj.Status().Result)
+	for _, sub := range obj.SubStatuses {
+		rank = max(rank, worstRank(sub))
+	}
+	return rank
+}
+
+// collapsedSummary describes the objects (including their sub-objects)
+// beyond the MaxDepth, such as "… 12 more objects (3 Error)".
+func (t *TreePrinter) collapsedSummary(objects []status.ObjectStatus) string {
+	total := 0
+	counts := make(map[status.Result]int)
+	var count func(objects []status.ObjectStatus)
+	count = func(objects []status.ObjectStatus) {
+		for _, obj := range t.filterResults(objects) {
+			total++
+			counts[obj.Status().Result]++
+			count(obj.SubStatuses)
+		}
+	}
+	count(objects)
+
+	noun := "objects"
+	if total == 1 {
+		noun = "object"
+	}
+	ret := fmt.Sprintf("… %d more %s", total, noun)
+
+	var details []string
+	for _, r := range []status.Result{status.Error, status.Warning, status.Unknown} {
+		if counts[r] > 0 {
+			details = append(details, fmt.Sprintf("%d %s", counts[r], r))
 		}
 	}
+	if len(details) > 0 {
+		ret += fmt.Sprintf(" (%s)", strings.Join(details, ", "))
+	}
+	return ret
 }
 
 func (t *TreePrinter) printf(w io.Writer, format string, a ...interface{}) {
@@ -379,11 +717,31 @@ func (t *TreePrinter) printf(w io.Writer, format string, a ...interface{}) {
 	}
 }
 
-func sortObjects(objects []status.ObjectStatus) {
+func sortObjects(objects []status.ObjectStatus, order SortOrder) {
 	fullName := func(obj status.ObjectStatus) string {
 		return fmt.Sprintf("%s %s %s", obj.Object.GetNamespace(), obj.Object.Kind, obj.Object.GetName())
 	}
 	slices.SortFunc(objects, func(a, b status.ObjectStatus) int {
+		if order == SortBySeverity {
+			if c := severityRank(b.Status().Result) - severityRank(a.Status().Result); c != 0 {
+				return c
+			}
+		}
 		return strings.Compare(fullName(a), fullName(b))
 	})
 }
+
+// severityRank orders the results from the least severe: Ok, Unknown,
+// Warning, Error. Unlike the Result values, Unknown ranks above Ok,
+// as it deserves attention.
+func severityRank(r status.Result) int {
+	switch r {
+	case status.Error:
+		return 3
+	case status.Warning:
+		return 2
+	case status.Unknown:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,315 @@
+package print_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/rhobs/kube-health/internal/test"
+	"github.com/rhobs/kube-health/pkg/analyze"
+	"github.com/rhobs/kube-health/pkg/print"
+	"github.com/rhobs/kube-health/pkg/status"
+)
+
+func testObject(kind, name string) *status.Object {
+	return &status.Object{
+		TypeMeta:   metav1.TypeMeta{Kind: kind},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID("uid-" + name)},
+	}
+}
+
+// testTree returns a multi-level tree with a failing container deep inside.
+func testTree() []status.ObjectStatus {
+	c1 := analyze.AggregateResult(testObject("Container", "c1"), nil, []status.ConditionStatus{
+		analyze.SyntheticConditionError("Waiting", "CrashLoopBackOff", "back-off restarting failed container")})
+	c2 := analyze.AggregateResult(testObject("Container", "c2"), nil, []status.ConditionStatus{
+		analyze.SyntheticConditionOk("Running", "")})
+	p1 := analyze.AggregateResult(testObject("Pod", "p1"), []status.ObjectStatus{c1}, []status.ConditionStatus{
+		analyze.SyntheticConditionError("Ready", "ContainersNotReady", "")})
+	p2 := analyze.AggregateResult(testObject("Pod", "p2"), []status.ObjectStatus{c2}, []status.ConditionStatus{
+		analyze.SyntheticConditionOk("Ready", "")})
+	rs := analyze.AggregateResult(testObject("ReplicaSet", "rs"), []status.ObjectStatus{p1, p2}, []status.ConditionStatus{
+		analyze.SyntheticConditionError("ReplicasReady", "NotReady", "Ready: 1/2")})
+	dp := analyze.AggregateResult(testObject("Deployment", "dp"), []status.ObjectStatus{rs}, []status.ConditionStatus{
+		analyze.SyntheticConditionOk("Available", "")})
+	return []status.ObjectStatus{dp}
+}
+
+func TestTreePrinterCompact(t *testing.T) {
+	sb := &strings.Builder{}
+	print.NewTreePrinter(print.PrintOptions{Compact: true}).PrintStatuses(testTree(), sb)
+	test.AssertStr(t, `
+OBJECT
+Error default/Deployment/dp
+└─ Error ReplicaSet/rs
+   ├─ Error Pod/p1
+   │  └─ Error Container/c1
+   └─ Ok Pod/p2
+`, sb.String())
+
+	sb = &strings.Builder{}
+	print.NewTreePrinter(print.PrintOptions{Compact: true, ShowOk: true}).PrintStatuses(testTree(), sb)
+	test.AssertStr(t, `
+OBJECT
+Error default/Deployment/dp
+└─ Error ReplicaSet/rs
+   ├─ Error Pod/p1
+   │  └─ Error Container/c1
+   └─ Ok Pod/p2
+      └─ Ok Container/c2
+`, sb.String())
+}
+
+func TestTreePrinterScore(t *testing.T) {
+	ok := status.OkStatus(testObject("ConfigMap", "cm"), nil)
+	statuses := append(testTree(), ok)
+
+	sb := &strings.Builder{}
+	print.NewTreePrinter(print.PrintOptions{Compact: true, ShowScore: true}).PrintStatuses(statuses, sb)
+	test.AssertStr(t, `
+OBJECT
+Ok default/ConfigMap/cm
+Error default/Deployment/dp
+└─ Error ReplicaSet/rs
+   ├─ Error Pod/p1
+   │  └─ Error Container/c1
+   └─ Ok Pod/p2
+
+Health score: 50.0%
+`, sb.String())
+}
+
+func TestTreePrinterOldestUnhealthy(t *testing.T) {
+	since := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	cond := analyze.SyntheticConditionError("Ready", "NotReady", "")
+	cond.LastTransitionTime = metav1.NewTime(since)
+	statuses := []status.ObjectStatus{
+		analyze.AggregateResult(testObject("Pod", "p1"), nil, []status.ConditionStatus{cond}),
+		status.OkStatus(testObject("ConfigMap", "cm"), nil),
+	}
+
+	sb := &strings.Builder{}
+	print.NewTreePrinter(print.PrintOptions{Compact: true, ShowScore: true, TimeFormat: print.TimeUTC}).
+		PrintStatuses(statuses, sb)
+	test.AssertStr(t, `
+OBJECT
+Ok default/ConfigMap/cm
+Error default/Pod/p1
+
+Health score: 50.0%
+Oldest unhealthy: default/Pod/p1 (since 2025-01-02 03:04:05Z)
+`, sb.String())
+}
+
+func TestTreePrinterAutoSize(t *testing.T) {
+	statuses := []status.ObjectStatus{
+		analyze.AggregateResult(testObject("Pod", "p1"), nil, []status.ConditionStatus{
+			analyze.SyntheticConditionOk("PodReadyToStartContainersAndSomeMore", ""),
+			analyze.SyntheticConditionError("Ready", "ContainersNotReady", "not ready")}),
+	}
+
+	sb := &strings.Builder{}
+	print.NewTreePrinter(print.PrintOptions{}).PrintStatuses(statuses, sb)
+	test.AssertStr(t, `
+OBJECT           CONDITION                                  AGE    REASON
+Error default/Pod/p1
+                 PodReadyToStartContainersAndSomeMore=True
+                 (Error) Ready=True                                ContainersNotReady
+                   not ready
+`, sb.String())
+
+	// When the output width is limited, the columns keep their default width.
+	sb = &strings.Builder{}
+	print.NewTreePrinter(print.PrintOptions{Width: 90}).PrintStatuses(statuses, sb)
+	test.AssertStr(t, `
+OBJECT           CONDITION                       AGE    REASON
+Error default/Pod/p1
+                 PodReadyToStartContainersAndSo
+                 (Error) Ready=True                     ContainersNotReady
+                   not ready
+`, sb.String())
+}
+
+func TestTreePrinterMinResult(t *testing.T) {
+	// The Deployment and ReplicaSet report OK, while one of the pods is failing.
+	c1 := analyze.AggregateResult(testObject("Container", "c1"), nil, []status.ConditionStatus{
+		analyze.SyntheticConditionError("Waiting", "CrashLoopBackOff", "back-off restarting failed container")})
+	c2 := analyze.AggregateResult(testObject("Container", "c2"), nil, []status.ConditionStatus{
+		analyze.SyntheticConditionOk("Running", "")})
+	p1 := analyze.AggregateResult(testObject("Pod", "p1"), []status.ObjectStatus{c1}, []status.ConditionStatus{
+		analyze.SyntheticConditionError("Ready", "ContainersNotReady", "")})
+	p2 := analyze.AggregateResult(testObject("Pod", "p2"), []status.ObjectStatus{c2}, []status.ConditionStatus{
+		analyze.SyntheticConditionOk("Ready", "")})
+	rs := status.OkStatus(testObject("ReplicaSet", "rs"), []status.ObjectStatus{p1, p2})
+	dp := status.OkStatus(testObject("Deployment", "dp"), []status.ObjectStatus{rs})
+	statuses := []status.ObjectStatus{dp, status.OkStatus(testObject("ConfigMap", "cm"), nil)}
+
+	sb := &strings.Builder{}
+	print.NewTreePrinter(print.PrintOptions{MinResult: status.Warning}).PrintStatuses(statuses, sb)
+	test.AssertStr(t, `
+OBJECT           CONDITION                       AGE    REASON
+Ok default/Deployment/dp
+└─ Ok ReplicaSet/rs
+   └─ Error Pod/p1
+      │          (Error) Ready=True                     ContainersNotReady
+      │
+      └─ Error Container/c1
+                 (Error) Waiting=True                   CrashLoopBackOff
+                   back-off restarting failed container
+`, sb.String())
+}
+
+func TestTreePrinterMaxDepth(t *testing.T) {
+	sb := &strings.Builder{}
+	print.NewTreePrinter(print.PrintOptions{Compact: true, ShowOk: true, MaxDepth: 1}).PrintStatuses(testTree(), sb)
+	test.AssertStr(t, `
+OBJECT
+Error default/Deployment/dp
+└─ Error ReplicaSet/rs
+   └─ … 4 more objects (2 Error)
+`, sb.String())
+
+	sb = &strings.Builder{}
+	print.NewTreePrinter(print.PrintOptions{MaxDepth: 2}).PrintStatuses(testTree(), sb)
+	test.AssertStr(t, `
+OBJECT           CONDITION                       AGE    REASON
+Error default/Deployment/dp
+│                Available=True
+└─ Error ReplicaSet/rs
+   │             (Error) ReplicasReady=True             NotReady
+   │               Ready: 1/2
+   ├─ Error Pod/p1
+   │  │          (Error) Ready=True                     ContainersNotReady
+   │  │
+   │  └─ … 1 more object (1 Error)
+   └─ Ok Pod/p2
+`, sb.String())
+}
+
+func TestTreePrinterSortBySeverity(t *testing.T) {
+	warn := analyze.AggregateResult(testObject("ConfigMap", "cm-warn"), nil, []status.ConditionStatus{
+		analyze.SyntheticConditionWarning("Valid", "Deprecated", "")})
+	// The failing pod goes before the healthy one, despite the name.
+	pa := status.OkStatus(testObject("Pod", "pa"), nil)
+	pb := analyze.AggregateResult(testObject("Pod", "pb"), nil, []status.ConditionStatus{
+		analyze.SyntheticConditionError("Ready", "ContainersNotReady", "")})
+	sts := analyze.AggregateResult(testObject("StatefulSet", "sts"), []status.ObjectStatus{pa, pb}, nil)
+	statuses := []status.ObjectStatus{
+		status.OkStatus(testObject("ConfigMap", "cm-ok"), nil),
+		warn,
+		status.UnknownStatus(testObject("ConfigMap", "cm-unknown")),
+		sts,
+	}
+
+	sb := &strings.Builder{}
+	print.NewTreePrinter(print.PrintOptions{Compact: true, SortBy: print.SortBySeverity}).PrintStatuses(statuses, sb)
+	test.AssertStr(t, `
+OBJECT
+Error default/StatefulSet/sts
+├─ Error Pod/pb
+└─ Ok Pod/pa
+Warning default/ConfigMap/cm-warn
+Unknown default/ConfigMap/cm-unknown
+Ok default/ConfigMap/cm-ok
+`, sb.String())
+}
+
+func TestTreePrinterResults(t *testing.T) {
+	warn := analyze.AggregateResult(testObject("ConfigMap", "cm-warn"), nil, []status.ConditionStatus{
+		analyze.SyntheticConditionWarning("Valid", "Deprecated", "")})
+	statuses := append(testTree(), warn, status.OkStatus(testObject("ConfigMap", "cm-ok"), nil))
+
+	// The healthy pod and the warning are pruned.
+	sb := &strings.Builder{}
+	print.NewTreePrinter(print.PrintOptions{Compact: true, ShowOk: true, Results: []status.Result{status.Error}}).
+		PrintStatuses(statuses, sb)
+	test.AssertStr(t, `
+OBJECT
+Error default/Deployment/dp
+└─ Error ReplicaSet/rs
+   └─ Error Pod/p1
+      └─ Error Container/c1
+`, sb.String())
+
+	// Unlike MinResult, any combination of the results can be selected.
+	sb = &strings.Builder{}
+	print.NewTreePrinter(print.PrintOptions{Compact: true, Results: []status.Result{status.Ok, status.Warning}}).
+		PrintStatuses(statuses, sb)
+	test.AssertStr(t, `
+OBJECT
+Ok default/ConfigMap/cm-ok
+Warning default/ConfigMap/cm-warn
+Error default/Deployment/dp
+└─ Error ReplicaSet/rs
+   └─ Ok Pod/p2
+      └─ Ok Container/c2
+`, sb.String())
+}
+
+func TestTreePrinterLogs(t *testing.T) {
+	c1 := analyze.AggregateResult(testObject("Container", "c1"), nil, []status.ConditionStatus{
+		analyze.SyntheticConditionError("Waiting", "CrashLoopBackOff", "back-off restarting failed container")})
+	c1.Logs = &status.Logs{Previous: true, Text: "starting\npanic: missing config\n"}
+	p1 := analyze.AggregateResult(testObject("Pod", "p1"), []status.ObjectStatus{c1}, nil)
+
+	sb := &strings.Builder{}
+	print.NewTreePrinter(print.PrintOptions{}).PrintStatuses([]status.ObjectStatus{p1}, sb)
+	test.AssertStr(t, `
+OBJECT           CONDITION                       AGE    REASON
+Error default/Pod/p1
+└─ Error Container/c1
+                 (Error) Waiting=True                   CrashLoopBackOff
+                   back-off restarting failed container
+                   Logs (previous instance):
+                   starting
+                   panic: missing config
+`, sb.String())
+
+	sb = &strings.Builder{}
+	print.NewTreePrinter(print.PrintOptions{HideLogs: true}).PrintStatuses([]status.ObjectStatus{p1}, sb)
+	test.AssertStr(t, `
+OBJECT           CONDITION                       AGE    REASON
+Error default/Pod/p1
+└─ Error Container/c1
+                 (Error) Waiting=True                   CrashLoopBackOff
+                   back-off restarting failed container
+                   Logs (previous instance): 2 lines hidden
+`, sb.String())
+}
+
+func TestTreePrinterFocus(t *testing.T) {
+	// The ReplicaSet has a warning pod next to the failing one.
+	c3 := analyze.AggregateResult(testObject("Container", "c3"), nil, []status.ConditionStatus{
+		analyze.SyntheticConditionWarning("Restarts", "Restarted", "restarted 3 times")})
+	p3 := analyze.AggregateResult(testObject("Pod", "p3"), []status.ObjectStatus{c3}, nil)
+	statuses := testTree()
+	rs := &statuses[0].SubStatuses[0]
+	rs.SubStatuses = append(rs.SubStatuses, p3)
+
+	sb := &strings.Builder{}
+	print.NewTreePrinter(print.PrintOptions{Compact: true, ShowOk: true}).PrintStatuses(statuses, sb)
+	test.AssertStr(t, `
+OBJECT
+Error default/Deployment/dp
+└─ Error ReplicaSet/rs
+   ├─ Error Pod/p1
+   │  └─ Error Container/c1
+   ├─ Ok Pod/p2
+   │  └─ Ok Container/c2
+   └─ Warning Pod/p3
+      └─ Warning Container/c3
+`, sb.String())
+
+	sb = &strings.Builder{}
+	print.NewTreePrinter(print.PrintOptions{Compact: true, ShowOk: true, Focus: true}).PrintStatuses(statuses, sb)
+	test.AssertStr(t, `
+OBJECT
+Error default/Deployment/dp
+└─ Error ReplicaSet/rs
+   └─ Error Pod/p1
+      └─ Error Container/c1
+`, sb.String())
+}
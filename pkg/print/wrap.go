@@ -95,6 +95,12 @@ func writeLineWrapped(w *strings.Builder, s string, width, maxLineWrap int, wrap
 
 // padStringKeepControl pads the string to the specified length, but
 // keeps the control characters in the string.
+// visibleLen returns the number of characters in the string, ignoring
+// the control sequences.
+func visibleLen(s string) int {
+	return len([]rune(controlRe.ReplaceAllString(s, "")))
+}
+
 func padStringKeepControl(s string, length int) string {
 	// Find all control characters in the string.
 	controls := controlRe.FindAllStringIndex(s, -1)
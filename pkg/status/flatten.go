@@ -0,0 +1,38 @@
+package status
+
+import "k8s.io/apimachinery/pkg/types"
+
+// FlatStatus is an entry of the status tree flattened by Flatten.
+type FlatStatus struct {
+	ObjectStatus
+	// Parent is the index of the parent entry in the flattened list,
+	// or -1 for the root.
+	Parent int
+	// ParentUID is the UID of the parent object. It's empty for the root
+	// and for the parents without UID (e.g. the containers).
+	ParentUID types.UID
+	// Depth is the number of the ancestors of the entry.
+	Depth int
+}
+
+// Flatten returns the object with all its sub-objects, in the depth-first
+// order: each entry comes right before its sub-objects. The root is the first
+// entry.
+func Flatten(s ObjectStatus) []FlatStatus {
+	var ret []FlatStatus
+	var walk func(s ObjectStatus, parent int, depth int)
+	walk = func(s ObjectStatus, parent int, depth int) {
+		entry := FlatStatus{ObjectStatus: s, Parent: parent, Depth: depth}
+		if parent >= 0 && ret[parent].Object != nil {
+			entry.ParentUID = ret[parent].Object.UID
+		}
+		ret = append(ret, entry)
+
+		idx := len(ret) - 1
+		for _, sub := range s.SubStatuses {
+			walk(sub, idx, depth+1)
+		}
+	}
+	walk(s, -1, 0)
+	return ret
+}
@@ -0,0 +1,47 @@
+package status
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestFlatten(t *testing.T) {
+	objStatus := func(name string, uid types.UID, subs ...ObjectStatus) ObjectStatus {
+		return ObjectStatus{
+			Object:      &Object{ObjectMeta: metav1.ObjectMeta{Name: name, UID: uid}},
+			SubStatuses: subs,
+		}
+	}
+
+	tree := objStatus("dp", "uid-dp",
+		objStatus("rs1", "uid-rs1",
+			objStatus("pod1", "uid-pod1",
+				objStatus("container", "")),
+			objStatus("pod2", "uid-pod2")),
+		objStatus("rs2", "uid-rs2"))
+
+	type entry struct {
+		name      string
+		parent    int
+		parentUID types.UID
+		depth     int
+	}
+	var entries []entry
+	for _, fs := range Flatten(tree) {
+		entries = append(entries, entry{fs.Object.Name, fs.Parent, fs.ParentUID, fs.Depth})
+	}
+
+	assert.Equal(t, []entry{
+		{"dp", -1, "", 0},
+		{"rs1", 0, "uid-dp", 1},
+		{"pod1", 1, "uid-rs1", 2},
+		{"container", 2, "uid-pod1", 3},
+		{"pod2", 1, "uid-rs1", 2},
+		{"rs2", 0, "uid-dp", 1},
+	}, entries)
+
+	assert.Len(t, Flatten(objStatus("single", "uid-single")), 1)
+}
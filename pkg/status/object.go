@@ -35,3 +35,30 @@ func NewObjectFromUnstructured(unst *unstructured.Unstructured) (*Object, error)
 
 	return obj, nil
 }
+
+// NewObjectsFromUnstructured is like NewObjectFromUnstructured, flattening
+// the lists (such as the v1 List from `kubectl get -o yaml`) into their items.
+func NewObjectsFromUnstructured(unst *unstructured.Unstructured) ([]*Object, error) {
+	if !unst.IsList() {
+		obj, err := NewObjectFromUnstructured(unst)
+		if err != nil {
+			return nil, err
+		}
+		return []*Object{obj}, nil
+	}
+
+	list, err := unst.ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read list items: %w", err)
+	}
+	var ret []*Object
+	for i := range list.Items {
+		// The lists might be nested.
+		objs, err := NewObjectsFromUnstructured(&list.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, objs...)
+	}
+	return ret, nil
+}
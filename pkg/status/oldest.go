@@ -0,0 +1,52 @@
+package status
+
+import "time"
+
+// UnhealthySince returns the time since when the object is unhealthy: the
+// earliest transition time of its Warning or Error conditions, including
+// the ones of its sub-objects. It's zero when the object is healthy or
+// the times are not known.
+func UnhealthySince(s ObjectStatus) time.Time {
+	var since time.Time
+	for _, cond := range s.Conditions {
+		if cond.Condition == nil || cond.Status().Result < Warning {
+			continue
+		}
+		t := cond.LastTransitionTime.Time
+		if !t.IsZero() && (since.IsZero() || t.Before(since)) {
+			since = t
+		}
+	}
+
+	for _, sub := range s.SubStatuses {
+		t := UnhealthySince(sub)
+		if !t.IsZero() && (since.IsZero() || t.Before(since)) {
+			since = t
+		}
+	}
+	return since
+}
+
+// OldestUnhealthy returns the top-level object that has been unhealthy
+// (in Warning or Error state) for the longest time, together with the time
+// since when it's unhealthy. The last return value is false if there is
+// no such object.
+func OldestUnhealthy(statuses []ObjectStatus) (ObjectStatus, time.Time, bool) {
+	var oldest ObjectStatus
+	var oldestSince time.Time
+	found := false
+
+	for _, s := range statuses {
+		if s.Status().Result < Warning {
+			continue
+		}
+		since := UnhealthySince(s)
+		if since.IsZero() {
+			continue
+		}
+		if !found || since.Before(oldestSince) {
+			oldest, oldestSince, found = s, since, true
+		}
+	}
+	return oldest, oldestSince, found
+}
@@ -0,0 +1,46 @@
+package status
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func condWithTime(result Result, t time.Time) ConditionStatus {
+	return ConditionStatus{
+		Condition:  &metav1.Condition{Type: "Ready", LastTransitionTime: metav1.NewTime(t)},
+		CondStatus: &Status{Result: result},
+	}
+}
+
+func TestOldestUnhealthy(t *testing.T) {
+	now := time.Now()
+	objStatus := func(name string, result Result, conds []ConditionStatus, subs ...ObjectStatus) ObjectStatus {
+		return ObjectStatus{
+			Object:      &Object{ObjectMeta: metav1.ObjectMeta{Name: name}},
+			ObjStatus:   Status{Result: result},
+			Conditions:  conds,
+			SubStatuses: subs,
+		}
+	}
+
+	statuses := []ObjectStatus{
+		// Healthy objects are not considered, even with old transitions.
+		objStatus("ok", Ok, []ConditionStatus{condWithTime(Ok, now.Add(-72*time.Hour))}),
+		objStatus("warning", Warning, []ConditionStatus{condWithTime(Warning, now.Add(-2*time.Hour))}),
+		// The time comes from the sub-object.
+		objStatus("error", Error, []ConditionStatus{condWithTime(Ok, now.Add(-48*time.Hour))},
+			objStatus("sub", Error, []ConditionStatus{condWithTime(Error, now.Add(-5*time.Hour))})),
+		objStatus("no-time", Error, []ConditionStatus{condWithTime(Error, time.Time{})}),
+	}
+
+	oldest, since, found := OldestUnhealthy(statuses)
+	assert.True(t, found)
+	assert.Equal(t, "error", oldest.Object.Name)
+	assert.True(t, since.Equal(now.Add(-5*time.Hour)))
+
+	_, _, found = OldestUnhealthy(statuses[:1])
+	assert.False(t, found)
+}
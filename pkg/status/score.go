@@ -0,0 +1,82 @@
+package status
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ScoreWeights assigns each result a weight between 0 (unhealthy)
+// and 1 (healthy) used when calculating the health score.
+type ScoreWeights map[Result]float64
+
+// DefaultScoreWeights counts Ok objects as healthy, Warning objects as half
+// healthy and the rest as unhealthy.
+var DefaultScoreWeights = ScoreWeights{
+	Ok:      1,
+	Warning: 0.5,
+	Error:   0,
+	Unknown: 0,
+}
+
+// Score calculates the health score of the statuses as a percentage:
+//
+//	score = 100 * sum(weights[result of object]) / number of objects
+//
+// Only the top-level statuses are considered: the results of sub-objects are
+// already reflected in their parents. Results missing in the weights count
+// as 0. An empty list of statuses has the score of 100.
+func Score(statuses []ObjectStatus, weights ScoreWeights) float64 {
+	if len(statuses) == 0 {
+		return 100
+	}
+
+	var sum float64
+	for _, s := range statuses {
+		sum += weights[s.Status().Result]
+	}
+	return 100 * sum / float64(len(statuses))
+}
+
+// ParseScoreWeights parses weights in the form of `result=weight,...`,
+// e.g. `warning=0.8,unknown=0.2`. Results not mentioned keep their
+// default weight.
+func ParseScoreWeights(s string) (ScoreWeights, error) {
+	weights := ScoreWeights{}
+	for r, w := range DefaultScoreWeights {
+		weights[r] = w
+	}
+
+	if strings.TrimSpace(s) == "" {
+		return weights, nil
+	}
+
+	for _, part := range strings.Split(s, ",") {
+		name, val, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			return nil, fmt.Errorf("invalid score weight %q, expected result=weight", part)
+		}
+
+		result, err := ParseResult(name)
+		if err != nil {
+			return nil, err
+		}
+
+		w, err := strconv.ParseFloat(val, 64)
+		if err != nil || w < 0 || w > 1 {
+			return nil, fmt.Errorf("invalid weight %q for %s, expected a number between 0 and 1", val, name)
+		}
+		weights[result] = w
+	}
+	return weights, nil
+}
+
+// ParseResult converts the name of the result (case insensitive) to Result.
+func ParseResult(s string) (Result, error) {
+	for _, r := range []Result{Unknown, Ok, Warning, Error} {
+		if strings.EqualFold(r.String(), s) {
+			return r, nil
+		}
+	}
+	return Unknown, fmt.Errorf("unknown result %q", s)
+}
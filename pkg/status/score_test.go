@@ -0,0 +1,55 @@
+package status
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func statusesWithResults(results ...Result) []ObjectStatus {
+	var ret []ObjectStatus
+	for _, r := range results {
+		ret = append(ret, ObjectStatus{ObjStatus: Status{Result: r}})
+	}
+	return ret
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		results  []Result
+		expected float64
+	}{
+		{"empty", nil, 100},
+		{"all ok", []Result{Ok, Ok, Ok}, 100},
+		{"all error", []Result{Error, Error}, 0},
+		{"mixed", []Result{Ok, Ok, Warning, Error}, 62.5},
+		{"unknown", []Result{Ok, Unknown}, 50},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.InDelta(t, tc.expected,
+				Score(statusesWithResults(tc.results...), DefaultScoreWeights), 0.001)
+		})
+	}
+}
+
+func TestParseScoreWeights(t *testing.T) {
+	weights, err := ParseScoreWeights("warning=0.8, Unknown=0.25")
+	require.NoError(t, err)
+	assert.Equal(t, ScoreWeights{Ok: 1, Warning: 0.8, Error: 0, Unknown: 0.25}, weights)
+
+	assert.InDelta(t, 51.25,
+		Score(statusesWithResults(Ok, Warning, Error, Unknown), weights), 0.001)
+
+	weights, err = ParseScoreWeights("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultScoreWeights, weights)
+
+	for _, invalid := range []string{"warning", "fine=1", "error=2", "ok=x"} {
+		_, err = ParseScoreWeights(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
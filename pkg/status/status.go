@@ -34,6 +34,21 @@ func (r Result) MarshalJSON() ([]byte, error) {
 	return json.Marshal(strings.ToLower(r.String()))
 }
 
+func (r *Result) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	for _, res := range []Result{Ok, Warning, Error} {
+		if strings.EqualFold(s, res.String()) {
+			*r = res
+			return nil
+		}
+	}
+	*r = Unknown
+	return nil
+}
+
 // Status is the core structure representing the status of an object.
 type Status struct {
 	Result      Result `json:"result"`        // mapping to Result enum
@@ -54,6 +69,14 @@ type ObjectStatus struct {
 	ObjStatus   Status            // overall status of the object
 	SubStatuses []ObjectStatus    // statuses of the sub-objects (e.g. pods of a replicaset)
 	Conditions  []ConditionStatus // conditions of the object
+	Logs        *Logs             // tail of the logs explaining the status (e.g. of a failing container), if loaded
+}
+
+// Logs are the last lines of the logs of a container.
+type Logs struct {
+	Previous bool   // the logs come from the previous instance of the container
+	Text     string // the log lines, each ending with a newline
+	Err      error  // error appeared while loading the logs
 }
 
 func (os ObjectStatus) Status() Status {